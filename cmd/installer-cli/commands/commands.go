@@ -0,0 +1,80 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package commands
+
+import (
+	"fmt"
+	installer_cli "github.com/nalej/installer/internal/app/installer-cli"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var commandsJSON bool
+
+var commandsLongHelp = `
+List the commands available to the install and uninstall workflows
+
+The commands are shown with their type and their specific parameters. All the commands
+also accept the common parameters listed at the end, such as the retries or the timeout.
+`
+
+var commandsExample = `
+
+# List all the commands
+installer-cli commands
+
+# Show the parameters of some commands
+installer-cli commands applyManifest waitFor
+
+# List the commands in JSON
+installer-cli commands --json
+`
+
+var commandsCmd = &cobra.Command{
+	Use:     "commands [name...]",
+	Short:   "List the workflow commands",
+	Long:    commandsLongHelp,
+	Example: commandsExample,
+	Run: func(cmd *cobra.Command, args []string) {
+		SetupLogging()
+		ListCommands(args)
+	},
+}
+
+func init() {
+	commandsCmd.Flags().BoolVar(&commandsJSON, "json", false,
+		"Print the commands in JSON")
+	rootCmd.AddCommand(commandsCmd)
+}
+
+// ListCommands prints the documentation of the registered commands.
+func ListCommands(names []string) {
+	list, err := installer_cli.ListCommands(names)
+	if err != nil {
+		log.Fatal().Str("error", err.DebugReport()).Msg("cannot list the commands")
+	}
+	if !commandsJSON {
+		fmt.Print(list.String())
+		return
+	}
+	raw, err := list.ToJSON()
+	if err != nil {
+		log.Fatal().Str("error", err.DebugReport()).Msg("cannot list the commands")
+	}
+	fmt.Println(raw)
+}
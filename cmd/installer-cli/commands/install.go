@@ -30,6 +30,8 @@ import (
 
 var explainPlan bool
 
+var dryRun bool
+
 var installKubernetes bool
 var kubeConfigPath string
 var username string
@@ -59,6 +61,13 @@ var networkingMode string
 
 var istioPath string
 
+var rollbackOnFailure bool
+
+var maxParallelism int
+
+var templatesPath string
+var workflowTemplate string
+
 var environment entities.Environment
 
 var cliCmd = &cobra.Command{
@@ -74,6 +83,8 @@ var cliCmd = &cobra.Command{
 func init() {
 	cliCmd.PersistentFlags().BoolVar(&explainPlan, "explainPlan", false,
 		"Show install plan instead of performing the install")
+	cliCmd.PersistentFlags().BoolVar(&dryRun, "dryRun", false,
+		"Print the changes of the install instead of applying them")
 	cliCmd.PersistentFlags().BoolVar(&installKubernetes, "installK8s", false,
 		"Whether kubernetes should be installed")
 	cliCmd.PersistentFlags().StringVar(&kubeConfigPath, "kubeConfigPath", "~/.kube/config",
@@ -120,6 +131,11 @@ func init() {
 		"Networking mode to be used [zt, istio]")
 	cliCmd.PersistentFlags().StringVar(&istioPath, "istioPath", "/istio/bin",
 		"Path to the folder containing the istioctl executable file")
+	cliCmd.PersistentFlags().BoolVar(&rollbackOnFailure, "rollbackOnFailure", false,
+		"Undo the finished commands if the install fails")
+	cliCmd.PersistentFlags().IntVar(&maxParallelism, "maxParallelism", 0,
+		"Maximum number of commands executed at the same time, 0 for no limit")
+	addTemplateOptions(cliCmd)
 
 
 	addRegistryOptions(cliCmd)
@@ -127,6 +143,14 @@ func init() {
 	rootCmd.AddCommand(cliCmd)
 }
 
+// Add parameters related to the selection of the workflow template.
+func addTemplateOptions(cliCmd *cobra.Command) {
+	cliCmd.PersistentFlags().StringVar(&templatesPath, "templatesPath", "",
+		"Directory with the workflow templates named <name>.<version>.json")
+	cliCmd.PersistentFlags().StringVar(&workflowTemplate, "template", "",
+		"Workflow template as name[:version], the latest install or uninstall template if not set")
+}
+
 // Add parameters related to the usage of registries.
 func addRegistryOptions(cliCmd *cobra.Command) {
 	cliCmd.PersistentFlags().StringVar(&environment.TargetEnvironment, "targetEnvironment", "PRODUCTION", "Target environment to be installed: PRODUCTION, STAGING, or DEVELOPMENT")
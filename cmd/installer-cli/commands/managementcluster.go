@@ -84,6 +84,11 @@ func LaunchManagementInstall() {
 		environment,
 		networkingMode,
 		istioPath)
+	inst.RollbackOnFailure = rollbackOnFailure
+	inst.MaxParallelism = maxParallelism
+	inst.DryRun = dryRun
+	inst.TemplatesPath = templatesPath
+	inst.Template = workflowTemplate
 
 	if explainPlan {
 		inst.LoadCredentials()
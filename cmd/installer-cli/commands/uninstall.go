@@ -46,6 +46,9 @@ installer-cli uninstall nalej/appCluster.yaml --appCluster
 
 # Show the uninstall plan
 installer-cli uninstall nalej/mngtCluster.yaml --explainPlan
+
+# Print the changes of the uninstall without applying them
+installer-cli uninstall nalej/mngtCluster.yaml --dryRun
 `
 
 var uninstallClusterCmd = &cobra.Command{
@@ -63,8 +66,11 @@ var uninstallClusterCmd = &cobra.Command{
 func init() {
 	uninstallClusterCmd.Flags().BoolVar(&explainPlan, "explainPlan", false,
 		"Show install plan instead of performing the uninstall")
+	uninstallClusterCmd.Flags().BoolVar(&dryRun, "dryRun", false,
+		"Print the changes of the uninstall instead of applying them")
 	uninstallClusterCmd.Flags().BoolVar(&appCluster, "appCluster", false,
 		"Set to true if the target cluster is an application cluster.")
+	addTemplateOptions(uninstallClusterCmd)
 	rootCmd.AddCommand(uninstallClusterCmd)
 }
 
@@ -80,6 +86,9 @@ func LaunchUninstall(kubeConfig string) {
 		"cli-cluster-request",
 		strings.ToUpper(targetPlatform),
 		appCluster)
+	inst.DryRun = dryRun
+	inst.TemplatesPath = templatesPath
+	inst.Template = workflowTemplate
 
 	if explainPlan {
 		inst.LoadCredentials()
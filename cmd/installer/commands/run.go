@@ -86,7 +86,18 @@ func init() {
 	config.NetworkingMode = entry
 
 	runCmd.PersistentFlags().StringVar(&config.IstioPath, "istioPath", "/istio/bin", "Path where the Istio project can be found")
-
+	runCmd.PersistentFlags().BoolVar(&config.RollbackOnFailure, "rollbackOnFailure", false,
+		"Undo the finished commands of an install that fails")
+	runCmd.PersistentFlags().IntVar(&config.MaxParallelism, "maxParallelism", 0,
+		"Maximum number of commands of a workflow executed at the same time, 0 for no limit")
+	runCmd.PersistentFlags().StringVar(&config.TemplatesPath, "templatesPath", "",
+		"Directory with the workflow templates named <name>.<version>.json, e.g. a mounted ConfigMap")
+	runCmd.PersistentFlags().StringVar(&config.InstallTemplate, "installTemplate", "install",
+		"Workflow template used to install a cluster as name[:version], the latest version if not set")
+	runCmd.PersistentFlags().StringVar(&config.UninstallTemplate, "uninstallTemplate", "uninstall",
+		"Workflow template used to uninstall a cluster as name[:version], the latest version if not set")
+	runCmd.PersistentFlags().StringVar(&config.TemplateRenderMode, "templateRenderMode", "json",
+		"Render mode of the workflow templates: json to escape the values, or raw to write them as they are")
 
 	rootCmd.AddCommand(runCmd)
 }
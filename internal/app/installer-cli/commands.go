@@ -0,0 +1,90 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the listing of the commands available to the workflows.
+
+package installer_cli
+
+import (
+	"encoding/json"
+	"github.com/nalej/derrors"
+	// The command packages register their commands when they are loaded.
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands"
+	wEntities "github.com/nalej/installer/internal/pkg/workflow/entities"
+	"strings"
+)
+
+// CommandList structure with the documentation of the commands available to the workflows.
+type CommandList struct {
+	// Commands with the definitions of the commands.
+	Commands []wEntities.CommandDefinition `json:"commands"`
+	// CommonParameters with the parameters accepted by all the commands.
+	CommonParameters []wEntities.CommandParameter `json:"commonParameters"`
+}
+
+// ListCommands retrieves the commands registered in the DefaultRegistry.
+//   params:
+//     names The names of the commands to be listed. All the commands are listed if empty.
+//   returns:
+//     The list of commands.
+//     An error if one of the names is not registered.
+func ListCommands(names []string) (*CommandList, derrors.Error) {
+	registered := wEntities.DefaultRegistry.List()
+	if len(names) == 0 {
+		return &CommandList{Commands: registered, CommonParameters: wEntities.CommonParameters}, nil
+	}
+	selected := make([]wEntities.CommandDefinition, 0)
+	for _, name := range names {
+		found := false
+		for _, definition := range registered {
+			if definition.Name == name {
+				selected = append(selected, definition)
+				found = true
+			}
+		}
+		if !found {
+			return nil, derrors.NewNotFoundError("command not registered").WithParams(name)
+		}
+	}
+	return &CommandList{Commands: selected, CommonParameters: wEntities.CommonParameters}, nil
+}
+
+// String returns the documentation of the commands followed by the common parameters.
+func (cl *CommandList) String() string {
+	var sb strings.Builder
+	for _, definition := range cl.Commands {
+		sb.WriteString(definition.Help())
+		sb.WriteString("\n")
+	}
+	common := wEntities.CommandDefinition{
+		Name:        "common parameters",
+		Type:        "all",
+		Description: "Parameters accepted by all the commands",
+		Parameters:  cl.CommonParameters,
+	}
+	sb.WriteString(common.Help())
+	return sb.String()
+}
+
+// ToJSON returns the JSON representation of the list.
+func (cl *CommandList) ToJSON() (string, derrors.Error) {
+	raw, err := json.MarshalIndent(cl, "", "  ")
+	if err != nil {
+		return "", derrors.AsError(err, "cannot marshal the list of commands")
+	}
+	return string(raw), nil
+}
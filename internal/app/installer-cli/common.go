@@ -26,7 +26,12 @@ import (
 	"github.com/nalej/installer/internal/pkg/templates"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/nalej/installer/internal/pkg/workflow"
+	wEntities "github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/nalej/installer/version"
 	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -38,6 +43,16 @@ type CLI struct {
 	Workflow *workflow.Workflow
 	// kubeConfigContent with the raw contents of the kubeConfig file to be used.
 	kubeConfigContent string
+	// RollbackOnFailure determines if the finished commands are undone when the workflow fails.
+	RollbackOnFailure bool
+	// MaxParallelism limits the number of commands executed at the same time. Zero means no limit.
+	MaxParallelism int
+	// DryRun determines if the changes are printed as a manifest instead of being applied.
+	DryRun bool
+	// TemplatesPath with the directory of the workflow templates that complement the built-in ones.
+	TemplatesPath string
+	// Template with the name[:version] of the workflow template. Empty to use the latest install or uninstall one.
+	Template string
 }
 
 // NewCLI builds a new CLI command wrapper to interact with the underlying installer logic.
@@ -137,16 +152,28 @@ func (c *CLI) LoadCredentials() {
 	c.exitOnError(c.Params.LoadCredentials())
 	c.exitOnError(c.Params.Validate())
 	p := workflow.NewParser()
-	workflowTemplate := ""
+	catalog := templates.NewCatalog()
+	if c.TemplatesPath != "" {
+		c.exitOnError(catalog.LoadDirectory(utils.GetPath(c.TemplatesPath)))
+	}
+	ref := templates.ParseRef(c.Template)
 	workflowName := ""
 	if c.Params.InstallRequest != nil {
 		workflowName = "installCluster"
-		workflowTemplate = templates.InstallManagementCluster
+		if ref.Name == "" {
+			ref.Name = templates.InstallTemplate
+		}
 	} else if c.Params.UninstallRequest != nil {
 		workflowName = "uninstallCluster"
-		workflowTemplate = templates.UninstallCluster
+		if ref.Name == "" {
+			ref.Name = templates.UninstallTemplate
+		}
 	}
-	workflow, err := p.ParseWorkflow("cli-install", workflowTemplate, workflowName, c.Params)
+	workflowTemplate, err := catalog.Get(ref)
+	c.exitOnError(err)
+	p.SetFragments(catalog.Fragments())
+	log.Info().Str("template", workflowTemplate.Ref().String()).Str("source", workflowTemplate.Source).Msg("workflow template")
+	workflow, err := p.ParseWorkflow("cli-install", workflowTemplate.Content, workflowName, c.Params)
 	c.exitOnError(err)
 	c.Workflow = workflow
 }
@@ -158,9 +185,23 @@ func (c *CLI) exitOnError(err derrors.Error) {
 	}
 }
 
-// logListener receives messages produced by the running workflow.
-func (c *CLI) logListener(msg string) {
-	log.Info().Msg(msg)
+// eventListener receives the events produced by the running workflow.
+func (c *CLI) eventListener(event wEntities.ExecutionEvent) {
+	entry := event.String()
+	if entry == "" {
+		return
+	}
+	logEvent := log.Info()
+	if event.Phase == wEntities.FailedPhase {
+		logEvent = log.Warn()
+	}
+	if event.ParentCommandID != "" {
+		logEvent = logEvent.Str("parent", event.ParentCommandID)
+	}
+	if event.Finished() && !event.Started.IsZero() {
+		logEvent = logEvent.Str("duration", event.Duration.String())
+	}
+	logEvent.Msg(entry)
 }
 
 // Execute the install/uninstall process.
@@ -170,7 +211,19 @@ func (c *CLI) Execute() {
 	execHandler := workflow.GetExecutorHandler()
 	exec, err := execHandler.Add(c.Workflow, wr.Callback)
 	c.exitOnError(err)
-	exec.SetLogListener(c.logListener)
+	exec.SetEventListener(c.eventListener)
+	exec.SetRollbackOnFailure(c.RollbackOnFailure)
+	exec.SetMaxParallelism(c.MaxParallelism)
+	if c.Params.InstallRequest != nil {
+		ownership := wEntities.NewOwnership(version.AppVersion, c.Params.InstallRequest.RequestId, c.Params.InstallRequest.ClusterId)
+		exec.SetOwnership(ownership)
+		exec.SetInventory(wEntities.NewInventory(c.Workflow.Name, c.Workflow.Description, ownership, c.Params.InventoryParameters()))
+	}
+	var manifest *wEntities.Manifest
+	if c.DryRun {
+		manifest = wEntities.NewManifest()
+		exec.SetDryRun(manifest)
+	}
 	start := time.Now()
 	exec, err = execHandler.Execute(c.Workflow.WorkflowID)
 	c.exitOnError(err)
@@ -189,6 +242,15 @@ func (c *CLI) Execute() {
 			operation = "Uninstalling management cluster"
 		}
 	}
+	// Cancel the workflow on interrupt so that running commands and spawned processes are stopped.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		fmt.Println("Canceling operation")
+		execHandler.Stop(c.Workflow.WorkflowID)
+	}()
 	for !wr.Called {
 		time.Sleep(time.Second * 15)
 		if checks%4 == 0 {
@@ -198,6 +260,16 @@ func (c *CLI) Execute() {
 	}
 	elapsed := time.Since(start)
 	fmt.Println("Operation took ", elapsed)
+	if manifest != nil {
+		fmt.Println(manifest.String())
+	}
+	if wr.State == workflow.CanceledState {
+		log.Fatal().Msg(fmt.Sprintf("%s canceled", operation))
+	}
+	if wr.State == workflow.RolledBackState {
+		fmt.Println("Operation failed and has been rolled back due to ", wr.Error.Error())
+		log.Fatal().Str("error", wr.Error.DebugReport()).Msg(fmt.Sprintf("%s rolled back", operation))
+	}
 	if wr.Error != nil {
 		fmt.Println("Operation failed due to ", wr.Error.Error())
 		log.Fatal().Str("error", wr.Error.DebugReport()).Msg(fmt.Sprintf("%s failed", operation))
@@ -79,8 +79,38 @@ const WorkflowAlreadyExists = "workflow already exists"
 // WorkflowExecutionFailed error to indicate that the execution of the workflow failed.
 const WorkflowExecutionFailed = "workflow execution failed"
 
+// WorkflowDeadlineExceeded error to indicate that the workflow did not finish before its timeout.
+const WorkflowDeadlineExceeded = "workflow deadline exceeded"
+
+// WorkflowRolledBack error to indicate that the workflow failed and the finished commands have been compensated.
+const WorkflowRolledBack = "workflow execution failed and has been rolled back"
+
+// WorkflowRollbackFailed error to indicate that the compensation of a failed workflow did not succeed.
+const WorkflowRollbackFailed = "workflow rollback failed"
+
+// DuplicatedNodeID error to indicate that two commands of a workflow have the same identifier.
+const DuplicatedNodeID = "duplicated command id in workflow"
+
+// UnknownDependency error to indicate that a command depends on an identifier that is not defined in the workflow.
+const UnknownDependency = "command depends on an unknown command id"
+
+// DependencyCycle error to indicate that the dependencies of the workflow commands contain a cycle.
+const DependencyCycle = "workflow dependencies contain a cycle"
+
+// InvalidMaxParallelism error to indicate that the maximum number of concurrent commands is not valid.
+const InvalidMaxParallelism = "invalid max parallelism"
+
 // Commands
 
+// InvalidRetryPolicy error to indicate that the retry policy of a command is not valid.
+const InvalidRetryPolicy = "invalid retry policy"
+
+// InvalidTimeout error to indicate that the timeout of a command or workflow is not a valid duration.
+const InvalidTimeout = "invalid timeout"
+
+// RetriesExhausted error to indicate that a condition was not satisfied after all the attempts.
+const RetriesExhausted = "maximum number of retries reached"
+
 // UnsupportedCommandType error to indicate that the selected command type is not supported and cannot be executed.
 const UnsupportedCommandType = "unsupported command type"
 
@@ -99,6 +129,12 @@ const DuplicatedIDCommand = "command id is duplicated"
 // NotExistCommand to indicate that the target command does not exists.
 const NotExistCommand = "command id does not exist"
 
+// CommandCanceled to indicate that the execution of a command was aborted.
+const CommandCanceled = "command execution canceled"
+
+// CommandDeadlineExceeded to indicate that the command did not finish on time.
+const CommandDeadlineExceeded = "command deadline exceeded"
+
 // InvalidCommandParameters to indicate that the command was expecting a set of parameters that are not present.
 const InvalidCommandParameters = "missing/invalid command parameters"
 
@@ -127,3 +163,89 @@ const InvalidNumMaster = "invalid number of master nodes, expecting 1 or 3"
 const InvalidYAML = "invalid YAML file"
 
 const InvalidNumberOfTokens = "invalid number of teleport tokens returned"
+
+// Operations
+
+// OperationInterrupted error to indicate that the operation was running when the installer was stopped.
+const OperationInterrupted = "operation interrupted by a restart of the installer"
+
+// CannotPersistOperation error to indicate that the operation could not be written to the operation store.
+const CannotPersistOperation = "cannot persist operation"
+
+// InvalidCheckpoint error to indicate that a checkpoint does not match the commands of the workflow.
+const InvalidCheckpoint = "checkpoint does not match the workflow"
+
+// OperationCannotBeResumed error to indicate that the operation is not in a state that allows resuming it.
+const OperationCannotBeResumed = "operation cannot be resumed"
+
+// ResumeWithoutCredentials error to indicate that an install restored from the store is resumed without its credentials.
+const ResumeWithoutCredentials = "expecting KubeConfigRaw or PrivateKey to resume the install"
+
+// DryRunCanceled error to indicate that a dry-run execution was aborted before finishing.
+const DryRunCanceled = "dry-run execution canceled"
+
+// InvalidApplyMode error to indicate that a command defines an unsupported apply mode.
+const InvalidApplyMode = "invalid apply mode"
+
+// CRDNotEstablished error to indicate that a custom resource definition has not been established in time.
+const CRDNotEstablished = "custom resource definition not established"
+
+// WorkloadFailed error to indicate that a workload has failed and will not become ready.
+const WorkloadFailed = "workload failed"
+
+// WorkloadsNotReady error to indicate that some workloads are not ready before the timeout.
+const WorkloadsNotReady = "workloads not ready"
+
+// InvalidWaitFor error to indicate that a waitFor command does not define the resources or the condition.
+const InvalidWaitFor = "invalid waitFor command"
+
+// WaitForTimeout error to indicate that the resources did not satisfy the condition before the timeout.
+const WaitForTimeout = "condition not satisfied before the timeout"
+
+// EmptyManifest error to indicate that an applyManifest command does not define any object.
+const EmptyManifest = "manifest without objects"
+
+// InvalidDeleteResources error to indicate that a deleteResources command does not select the resources properly.
+const InvalidDeleteResources = "invalid deleteResources command"
+
+// ResourcesNotDeleted error to indicate that the deleted resources still exist after the timeout.
+const ResourcesNotDeleted = "resources not deleted before the timeout"
+
+// InvalidInventory error to indicate that the inventory of an install cannot be read or written.
+const InvalidInventory = "invalid installation inventory"
+
+// InvalidCommandDefinition error to indicate that a command is registered without a name or a JSON constructor.
+const InvalidCommandDefinition = "invalid command definition"
+
+// DuplicatedCommand error to indicate that a command with the same type and name is already registered.
+const DuplicatedCommand = "command already registered"
+
+// InvalidPlugin error to indicate that a plugin command does not define a valid plugin name.
+const InvalidPlugin = "invalid plugin command"
+
+// PluginNotFound error to indicate that the executable of a plugin is not found in the plugins directory.
+const PluginNotFound = "plugin not found"
+
+// PluginWithoutResult error to indicate that a plugin finished without returning a result.
+const PluginWithoutResult = "plugin finished without a result"
+
+// PluginOutputUnreadable error to indicate that the output of a plugin cannot be read, as a line is too long.
+const PluginOutputUnreadable = "cannot read the output of the plugin"
+
+// InvalidTemplate error to indicate that a workflow template of the catalog is not named or versioned properly.
+const InvalidTemplate = "invalid workflow template"
+
+// TemplateNotFound error to indicate that the requested workflow template is not available in the catalog.
+const TemplateNotFound = "workflow template not found"
+
+// DuplicatedTemplate error to indicate that a workflow template with the same name and version is already loaded.
+const DuplicatedTemplate = "workflow template already loaded"
+
+// InvalidWorkflowJSON error to indicate that a rendered workflow template is not valid JSON.
+const InvalidWorkflowJSON = "rendered workflow is not valid JSON"
+
+// InvalidRenderMode error to indicate that the render mode of the workflow templates is not supported.
+const InvalidRenderMode = "invalid template render mode"
+
+// NestedUndo error to indicate that a command nested in a group, parallel or try command defines an undo block.
+const NestedUndo = "undo blocks are only supported in the top level commands of a workflow"
@@ -21,6 +21,7 @@ import (
 	"github.com/nalej/derrors"
 	"github.com/nalej/installer/internal/pkg/entities"
 	"github.com/nalej/installer/internal/pkg/utils"
+	"github.com/nalej/installer/internal/pkg/workflow"
 	"github.com/nalej/installer/version"
 	"github.com/rs/zerolog/log"
 	"os"
@@ -44,6 +45,19 @@ type Config struct {
 	ClusterCertIssuerCACertPath string
 	NetworkingMode        entities.NetworkingMode
 	IstioPath             string
+	// RollbackOnFailure determines if the finished commands of a failed install are undone.
+	RollbackOnFailure bool
+	// MaxParallelism limits the number of commands of a workflow executed at the same time. Zero means no limit.
+	MaxParallelism int
+	// TemplatesPath with the directory of the workflow templates that complement the built-in ones. Empty to only
+	// use the built-in templates.
+	TemplatesPath string
+	// InstallTemplate with the name[:version] of the template used by the install requests.
+	InstallTemplate string
+	// UninstallTemplate with the name[:version] of the template used by the uninstall requests.
+	UninstallTemplate string
+	// TemplateRenderMode determines how the values are written in the templates: json to escape them, or raw.
+	TemplateRenderMode string
 }
 
 func NewConfiguration(
@@ -105,6 +119,13 @@ func (conf *Config) Validate() derrors.Error {
 		return derrors.NewInvalidArgumentError("tempPath").CausedBy(err)
 	}
 
+	if conf.TemplatesPath != "" {
+		conf.TemplatesPath = utils.GetPath(conf.TemplatesPath)
+		if err := conf.CheckPath(conf.TemplatesPath); err != nil {
+			return derrors.NewInvalidArgumentError("templatesPath").CausedBy(err)
+		}
+	}
+
 	if err := conf.Environment.Validate(); err != nil {
 		return err
 	}
@@ -133,6 +154,12 @@ func (conf *Config) Validate() derrors.Error {
 	if conf.NetworkingMode == entities.NetworkingModeIstio && conf.IstioPath == "" {
 		return derrors.NewInvalidArgumentError("IstioPath must be set if Istio networking mode is chosen")
 	}
+	if conf.MaxParallelism < 0 {
+		return derrors.NewInvalidArgumentError("maxParallelism cannot be negative")
+	}
+	if _, err := workflow.RenderModeFromString(conf.TemplateRenderMode); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -151,6 +178,10 @@ func (conf *Config) Print() {
 	log.Info().Str("path", conf.ClusterCertIssuerCACertPath).Msg("cluster cert issuer ca cert path")
 	log.Info().Interface("networkingMode", conf.NetworkingMode).Msg("networking mode")
 	log.Info().Str("path", conf.IstioPath).Msg("istio path")
+	log.Info().Bool("set", conf.RollbackOnFailure).Msg("Rollback on failure")
+	log.Info().Int("commands", conf.MaxParallelism).Msg("Max parallelism")
+	log.Info().Str("path", conf.TemplatesPath).Str("install", conf.InstallTemplate).
+		Str("uninstall", conf.UninstallTemplate).Str("renderMode", conf.TemplateRenderMode).Msg("Workflow templates")
 
 	conf.Environment.Print()
 
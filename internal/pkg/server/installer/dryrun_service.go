@@ -0,0 +1,101 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// The installer protos do not define a method to preview an install. This file declares the DryRun service by hand
+// reusing the messages of grpc-installer-go and grpc-common-go, so any gRPC client can consume it with the full
+// method name /installer.DryRun/DryRunInstall.
+
+package installer
+
+import (
+	"context"
+	"github.com/nalej/grpc-common-go"
+	"github.com/nalej/grpc-installer-go"
+	"google.golang.org/grpc"
+)
+
+// DryRunServiceName with the name of the dry-run service.
+const DryRunServiceName = "installer.DryRun"
+
+// DryRunInstallMethod with the full name of the method that previews the changes of an install.
+const DryRunInstallMethod = "/" + DryRunServiceName + "/DryRunInstall"
+
+// DryRunServer is the server API for the DryRun service.
+type DryRunServer interface {
+	// DryRunInstall executes an install in dry-run mode. The Info field of the response contains the manifest
+	// with the changes that would be applied.
+	DryRunInstall(context.Context, *grpc_installer_go.InstallRequest) (*grpc_common_go.OpResponse, error)
+}
+
+func dryRunInstallHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := new(grpc_installer_go.InstallRequest)
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DryRunServer).DryRunInstall(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DryRunInstallMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DryRunServer).DryRunInstall(ctx, req.(*grpc_installer_go.InstallRequest))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+var dryRunServiceDesc = grpc.ServiceDesc{
+	ServiceName: DryRunServiceName,
+	HandlerType: (*DryRunServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DryRunInstall",
+			Handler:    dryRunInstallHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// RegisterDryRunServer registers the DryRun service in a gRPC server.
+func RegisterDryRunServer(s *grpc.Server, srv DryRunServer) {
+	s.RegisterService(&dryRunServiceDesc, srv)
+}
+
+// DryRunClient is the client API for the DryRun service.
+type DryRunClient interface {
+	// DryRunInstall previews the changes of an install.
+	DryRunInstall(ctx context.Context, in *grpc_installer_go.InstallRequest, opts ...grpc.CallOption) (*grpc_common_go.OpResponse, error)
+}
+
+type dryRunClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewDryRunClient creates a client of the DryRun service.
+func NewDryRunClient(cc *grpc.ClientConn) DryRunClient {
+	return &dryRunClient{cc}
+}
+
+func (dc *dryRunClient) DryRunInstall(ctx context.Context, in *grpc_installer_go.InstallRequest, opts ...grpc.CallOption) (*grpc_common_go.OpResponse, error) {
+	response := new(grpc_common_go.OpResponse)
+	err := dc.cc.Invoke(ctx, DryRunInstallMethod, in, response, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -28,6 +28,10 @@ import (
 
 const InstallOperation = "Install cluster"
 const UninstallOperation = "Uninstall cluster"
+const DryRunInstallOperation = "Dry-run install cluster"
+
+// RolledBackInfo is reported for failed operations whose finished commands have been undone.
+const RolledBackInfo = "rolled back"
 
 // Operation structure representing an managed operation with its workflow and associated status.
 type Operation struct {
@@ -41,37 +45,101 @@ type Operation struct {
 	Workflow       *workflow.Workflow
 	error          derrors.Error
 	workflowState  workflow.WorkflowState
+	// Updated contains the timestamp of the last status change.
+	Updated int64
+	// Resumable is set when the operation was interrupted by a restart of the installer.
+	Resumable   bool
+	checkpoints []workflow.Checkpoint
+	// TemplateName with the name of the workflow template used by the operation.
+	TemplateName string
+	// TemplateVersion with the version of the workflow template used by the operation.
+	TemplateVersion string
 }
 
 // NewOperation creates a new Operation
 func NewOperation(organizationID string, requestID string, operationName string) *Operation {
 	log.Debug().Str("organizationID", organizationID).Str("requestID", requestID).Str("operationName", operationName).Msg("creating operation")
+	now := time.Now().Unix()
 	return &Operation{
 		OrganizationID: organizationID,
 		RequestID:      requestID,
+		OperationName:  operationName,
 		status:         grpc_common_go.OpStatus_INIT,
-		Created:        time.Now().Unix(),
+		Created:        now,
 		workflowState:  workflow.InitState,
+		Updated:        now,
+	}
+}
+
+// NewOperationFromRecord restores an operation from its stored representation.
+func NewOperationFromRecord(record OperationRecord) *Operation {
+	var err derrors.Error
+	if record.Error != "" {
+		err = derrors.NewGenericError(record.Error)
+	}
+	return &Operation{
+		OrganizationID:  record.OrganizationID,
+		RequestID:       record.RequestID,
+		OperationName:   record.OperationName,
+		status:          record.Status,
+		Created:         record.Created,
+		error:           err,
+		workflowState:   record.WorkflowState,
+		Updated:         record.Updated,
+		Resumable:       record.Resumable,
+		checkpoints:     record.Checkpoints,
+		TemplateName:    record.TemplateName,
+		TemplateVersion: record.TemplateVersion,
 	}
 }
 
 func (is *Operation) Clone() *Operation {
 	return &Operation{
-		OrganizationID: is.OrganizationID,
-		RequestID:      is.RequestID,
-		OperationName:  is.OperationName,
-		status:         is.status,
-		Created:        is.Created,
-		Params:         is.Params,
-		Workflow:       is.Workflow,
-		error:          is.error,
-		workflowState:  is.workflowState,
+		OrganizationID:  is.OrganizationID,
+		RequestID:       is.RequestID,
+		OperationName:   is.OperationName,
+		status:          is.status,
+		Created:         is.Created,
+		Params:          is.Params,
+		Workflow:        is.Workflow,
+		error:           is.error,
+		workflowState:   is.workflowState,
+		Updated:         is.Updated,
+		Resumable:       is.Resumable,
+		checkpoints:     is.checkpoints,
+		TemplateName:    is.TemplateName,
+		TemplateVersion: is.TemplateVersion,
+	}
+}
+
+// ToRecord transforms the operation into its serializable representation.
+func (is *Operation) ToRecord() OperationRecord {
+	is.Lock()
+	defer is.Unlock()
+	var e string
+	if is.error != nil {
+		e = is.error.Error()
+	}
+	return OperationRecord{
+		OrganizationID:  is.OrganizationID,
+		RequestID:       is.RequestID,
+		OperationName:   is.OperationName,
+		Status:          is.status,
+		Created:         is.Created,
+		Updated:         is.Updated,
+		Error:           e,
+		WorkflowState:   is.workflowState,
+		Resumable:       is.Resumable,
+		Checkpoints:     is.checkpoints,
+		TemplateName:    is.TemplateName,
+		TemplateVersion: is.TemplateVersion,
 	}
 }
 
 func (is *Operation) UpdateStatus(newStatus grpc_common_go.OpStatus) {
 	is.Lock()
 	is.status = newStatus
+	is.Updated = time.Now().Unix()
 	is.Unlock()
 }
 
@@ -88,6 +156,40 @@ func (is *Operation) UpdateError(error derrors.Error) {
 	is.Unlock()
 }
 
+// AddCheckpoint records a finished command of the operation workflow.
+func (is *Operation) AddCheckpoint(checkpoint workflow.Checkpoint) {
+	is.Lock()
+	is.checkpoints = append(is.checkpoints, checkpoint)
+	is.Unlock()
+}
+
+// GetCheckpoints retrieves a copy of the finished commands of the operation workflow.
+func (is *Operation) GetCheckpoints() []workflow.Checkpoint {
+	is.Lock()
+	defer is.Unlock()
+	result := make([]workflow.Checkpoint, len(is.checkpoints))
+	copy(result, is.checkpoints)
+	return result
+}
+
+// ClearCheckpoints removes the finished commands of the operation workflow.
+func (is *Operation) ClearCheckpoints() {
+	is.Lock()
+	is.checkpoints = make([]workflow.Checkpoint, 0)
+	is.Unlock()
+}
+
+// PrepareResume clears the failure information so the operation can be launched again.
+func (is *Operation) PrepareResume() {
+	is.Lock()
+	is.error = nil
+	is.Resumable = false
+	is.status = grpc_common_go.OpStatus_SCHEDULED
+	is.workflowState = workflow.RegisteredState
+	is.Updated = time.Now().Unix()
+	is.Unlock()
+}
+
 func (is *Operation) UpdateWorkflowState(state workflow.WorkflowState) {
 	is.Lock()
 	is.workflowState = state
@@ -103,6 +205,10 @@ func (is *Operation) ToGRPCOpResponse() *grpc_common_go.OpResponse {
 	if is.error != nil {
 		e = is.error.Error()
 	}
+	var info string
+	if is.workflowState == workflow.RolledBackState {
+		info = RolledBackInfo
+	}
 	is.Unlock()
 
 	return &grpc_common_go.OpResponse{
@@ -112,7 +218,7 @@ func (is *Operation) ToGRPCOpResponse() *grpc_common_go.OpResponse {
 		ElapsedTime:    elapsed,
 		Timestamp:      time.Now().Unix(),
 		Status:         rStatus,
-		Info:           "",
+		Info:           info,
 		Error:          e,
 	}
 }
@@ -23,18 +23,39 @@ import (
 	"github.com/nalej/grpc-installer-go"
 	"github.com/nalej/grpc-utils/pkg/conversions"
 	"github.com/nalej/installer/internal/pkg/entities"
+	"github.com/nalej/installer/internal/pkg/templates"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/metadata"
+	"time"
 )
 
+// TemplateMetadataKey is the key of the gRPC metadata that selects the workflow template of a request with the
+// name[:version] format.
+const TemplateMetadataKey = "installer-template"
+
 type Handler struct {
-	Manager Manager
+	Manager *Manager
 }
 
-func NewHandler(manager Manager) *Handler {
+func NewHandler(manager *Manager) *Handler {
 	return &Handler{manager}
 }
 
-// InstallCluster triggers the installation of a new application cluster.
+// templateFromContext retrieves the workflow template selected in the metadata of a request, if any.
+func templateFromContext(ctx context.Context) templates.Ref {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return templates.Ref{}
+	}
+	values := md.Get(TemplateMetadataKey)
+	if len(values) == 0 {
+		return templates.Ref{}
+	}
+	return templates.ParseRef(values[0])
+}
+
+// InstallCluster triggers the installation of a new application cluster. The workflow template can be selected
+// with the TemplateMetadataKey metadata of the request.
 func (h *Handler) InstallCluster(ctx context.Context, installRequest *grpc_installer_go.InstallRequest) (*grpc_common_go.OpResponse, error) {
 	log.Debug().Str("organizationID", installRequest.OrganizationId).Str("installID", installRequest.RequestId).Msg("install cluster")
 	err := entities.ValidInstallRequest(installRequest)
@@ -42,7 +63,7 @@ func (h *Handler) InstallCluster(ctx context.Context, installRequest *grpc_insta
 		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
 		return nil, conversions.ToGRPCError(err)
 	}
-	status, err := h.Manager.InstallCluster(*installRequest)
+	status, err := h.Manager.InstallCluster(*installRequest, templateFromContext(ctx))
 	if err != nil {
 		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
 		return nil, conversions.ToGRPCError(err)
@@ -51,6 +72,32 @@ func (h *Handler) InstallCluster(ctx context.Context, installRequest *grpc_insta
 	return status.ToGRPCOpResponse(), nil
 }
 
+// DryRunInstall executes the install of a cluster in dry-run mode without applying any change. The Info field of
+// the response contains the manifest with the objects that would be created, updated or deleted.
+func (h *Handler) DryRunInstall(ctx context.Context, installRequest *grpc_installer_go.InstallRequest) (*grpc_common_go.OpResponse, error) {
+	log.Debug().Str("organizationID", installRequest.OrganizationId).Str("installID", installRequest.RequestId).Msg("dry-run install cluster")
+	err := entities.ValidInstallRequest(installRequest)
+	if err != nil {
+		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
+		return nil, conversions.ToGRPCError(err)
+	}
+	started := time.Now()
+	manifest, err := h.Manager.DryRunInstall(ctx, *installRequest, templateFromContext(ctx))
+	if err != nil {
+		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
+		return nil, conversions.ToGRPCError(err)
+	}
+	return &grpc_common_go.OpResponse{
+		OrganizationId: installRequest.OrganizationId,
+		RequestId:      installRequest.RequestId,
+		OperationName:  DryRunInstallOperation,
+		ElapsedTime:    int64(time.Since(started).Seconds()),
+		Timestamp:      time.Now().Unix(),
+		Status:         grpc_common_go.OpStatus_SUCCESS,
+		Info:           manifest.String(),
+	}, nil
+}
+
 // UninstallCluster proceeds to remove all Nalej created elements in that cluster.
 func (h *Handler) UninstallCluster(ctx context.Context, request *grpc_installer_go.UninstallClusterRequest) (*grpc_common_go.OpResponse, error) {
 	log.Debug().Str("organizationID", request.OrganizationId).Str("requestID", request.RequestId).Msg("uninstall cluster")
@@ -59,7 +106,7 @@ func (h *Handler) UninstallCluster(ctx context.Context, request *grpc_installer_
 		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
 		return nil, conversions.ToGRPCError(err)
 	}
-	response, err := h.Manager.UninstallCluster(*request)
+	response, err := h.Manager.UninstallCluster(*request, templateFromContext(ctx))
 	if err != nil {
 		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
 		return nil, conversions.ToGRPCError(err)
@@ -81,6 +128,51 @@ func (h *Handler) CheckProgress(ctx context.Context, requestID *grpc_common_go.R
 	return status.ToGRPCOpResponse(), nil
 }
 
+// WatchProgress streams the updates of an operation until it finishes. Each update contains the status of the
+// operation, and the Info field reports the current command, the total of commands, the workflow state and the
+// last log entry.
+func (h *Handler) WatchProgress(requestID *grpc_common_go.RequestId, stream ProgressStream) error {
+	err := entities.ValidRequestID(requestID)
+	if err != nil {
+		return conversions.ToGRPCError(err)
+	}
+	events, cancel, err := h.Manager.WatchProgress(requestID.RequestId)
+	if err != nil {
+		return conversions.ToGRPCError(err)
+	}
+	defer cancel()
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Debug().Str("requestID", requestID.RequestId).Msg("progress watcher disconnected")
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if sErr := stream.Send(event.ToGRPCOpResponse()); sErr != nil {
+				return sErr
+			}
+		}
+	}
+}
+
+// ResumeInstall launches again a failed install starting from the first command that did not finish. Only the
+// request identifier and the credentials of the request are used.
+func (h *Handler) ResumeInstall(ctx context.Context, credentials *grpc_installer_go.InstallRequest) (*grpc_common_go.OpResponse, error) {
+	err := entities.ValidRequestID(&grpc_common_go.RequestId{RequestId: credentials.RequestId})
+	if err != nil {
+		return nil, conversions.ToGRPCError(err)
+	}
+	status, err := h.Manager.ResumeInstall(*credentials)
+	if err != nil {
+		log.Warn().Str("trace", err.DebugReport()).Msg(err.Error())
+		return nil, conversions.ToGRPCError(err)
+	}
+	log.Debug().Str("requestID", credentials.RequestId).Msg("install resumed")
+	return status.ToGRPCOpResponse(), nil
+}
+
 // RemoveInstall cancels and ongoing install or removes the information of an already processed install.
 func (h *Handler) RemoveInstall(ctx context.Context, requestID *grpc_common_go.RequestId) (*grpc_common_go.Success, error) {
 	err := entities.ValidRequestID(requestID)
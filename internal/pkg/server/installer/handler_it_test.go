@@ -151,7 +151,7 @@ var _ = ginkgo.Describe("Installer", func() {
 
 		server = grpc.NewServer()
 
-		manager := NewManager(config)
+		manager := NewManager(config, NewMemoryOperationStore())
 		handler := NewHandler(manager)
 		grpc_installer_go.RegisterInstallerServer(server, handler)
 
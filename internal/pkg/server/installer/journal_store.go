@@ -0,0 +1,220 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package installer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// JournalFileName is the name of the file that contains the operation journal inside the temporal path.
+const JournalFileName = "operations.journal"
+
+const journalSaveAction = "save"
+const journalRemoveAction = "remove"
+
+// journalEntry represents a line of the operation journal.
+type journalEntry struct {
+	Action    string           `json:"action"`
+	RequestID string           `json:"request_id"`
+	Record    *OperationRecord `json:"record,omitempty"`
+}
+
+// JournalOperationStore is an OperationStore that appends every change to a JSON journal on disk. The journal
+// is replayed and compacted when the store is opened.
+type JournalOperationStore struct {
+	sync.Mutex
+	path    string
+	file    *os.File
+	records map[string]OperationRecord
+}
+
+// NewJournalOperationStore opens or creates the operation journal on the given directory.
+func NewJournalOperationStore(basePath string) (*JournalOperationStore, derrors.Error) {
+	if err := os.MkdirAll(basePath, 0700); err != nil {
+		return nil, derrors.NewInternalError(errors.IOError, err).WithParams(basePath)
+	}
+	store := &JournalOperationStore{
+		path:    filepath.Join(basePath, JournalFileName),
+		records: make(map[string]OperationRecord, 0),
+	}
+	if err := store.replay(); err != nil {
+		return nil, err
+	}
+	if err := store.compact(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// replay loads the content of the journal in memory.
+func (jos *JournalOperationStore) replay() derrors.Error {
+	file, err := os.Open(jos.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(jos.path)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := &journalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			// A partial write may happen if the process is killed while appending. Ignore the entry.
+			log.Warn().Str("path", jos.path).Int("line", line).Msg("ignoring malformed journal entry")
+			continue
+		}
+		switch entry.Action {
+		case journalSaveAction:
+			if entry.Record != nil {
+				jos.records[entry.RequestID] = *entry.Record
+			}
+		case journalRemoveAction:
+			delete(jos.records, entry.RequestID)
+		default:
+			log.Warn().Str("action", entry.Action).Int("line", line).Msg("unknown journal action")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(jos.path)
+	}
+	return nil
+}
+
+// compact rewrites the journal with a single entry per operation and leaves it open for appending.
+func (jos *JournalOperationStore) compact() derrors.Error {
+	tmpPath := jos.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(tmpPath)
+	}
+	for _, record := range sortedRecords(jos.records) {
+		r := record
+		if wErr := writeEntry(tmp, journalEntry{Action: journalSaveAction, RequestID: r.RequestID, Record: &r}); wErr != nil {
+			tmp.Close()
+			return wErr
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return derrors.NewInternalError(errors.IOError, err).WithParams(tmpPath)
+	}
+	tmp.Close()
+	if err := os.Rename(tmpPath, jos.path); err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(jos.path)
+	}
+	file, err := os.OpenFile(jos.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(jos.path)
+	}
+	jos.file = file
+	return nil
+}
+
+// writeEntry serializes an entry as a single line.
+func writeEntry(file *os.File, entry journalEntry) derrors.Error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return derrors.NewInternalError(errors.MarshalError, err)
+	}
+	raw = append(raw, '\n')
+	if _, err := file.Write(raw); err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(file.Name())
+	}
+	return nil
+}
+
+// append adds a new entry to the journal and flushes it to disk.
+func (jos *JournalOperationStore) append(entry journalEntry) derrors.Error {
+	if jos.file == nil {
+		return derrors.NewInternalError("operation journal is closed").WithParams(jos.path)
+	}
+	if err := writeEntry(jos.file, entry); err != nil {
+		return err
+	}
+	if err := jos.file.Sync(); err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(jos.path)
+	}
+	return nil
+}
+
+func (jos *JournalOperationStore) Save(record OperationRecord) derrors.Error {
+	jos.Lock()
+	defer jos.Unlock()
+	if err := jos.append(journalEntry{Action: journalSaveAction, RequestID: record.RequestID, Record: &record}); err != nil {
+		return err
+	}
+	jos.records[record.RequestID] = record
+	return nil
+}
+
+func (jos *JournalOperationStore) Get(requestID string) (*OperationRecord, derrors.Error) {
+	jos.Lock()
+	defer jos.Unlock()
+	record, exists := jos.records[requestID]
+	if !exists {
+		return nil, derrors.NewNotFoundError("operation").WithParams(requestID)
+	}
+	return &record, nil
+}
+
+func (jos *JournalOperationStore) List() ([]OperationRecord, derrors.Error) {
+	jos.Lock()
+	defer jos.Unlock()
+	return sortedRecords(jos.records), nil
+}
+
+func (jos *JournalOperationStore) Remove(requestID string) derrors.Error {
+	jos.Lock()
+	defer jos.Unlock()
+	if _, exists := jos.records[requestID]; !exists {
+		return derrors.NewNotFoundError("operation").WithParams(requestID)
+	}
+	if err := jos.append(journalEntry{Action: journalRemoveAction, RequestID: requestID}); err != nil {
+		return err
+	}
+	delete(jos.records, requestID)
+	return nil
+}
+
+// Close the underlying journal file.
+func (jos *JournalOperationStore) Close() derrors.Error {
+	jos.Lock()
+	defer jos.Unlock()
+	if jos.file == nil {
+		return nil
+	}
+	err := jos.file.Close()
+	jos.file = nil
+	if err != nil {
+		return derrors.NewInternalError(errors.IOError, err).WithParams(jos.path)
+	}
+	return nil
+}
@@ -18,8 +18,12 @@
 package installer
 
 import (
+	"context"
+	"fmt"
 	"github.com/nalej/grpc-common-go"
 	"github.com/nalej/installer/internal/pkg/entities"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"sort"
 	"sync"
 
 	"github.com/nalej/derrors"
@@ -27,6 +31,8 @@ import (
 	"github.com/nalej/installer/internal/pkg/server/config"
 	"github.com/nalej/installer/internal/pkg/templates"
 	"github.com/nalej/installer/internal/pkg/workflow"
+	wEntities "github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/nalej/installer/version"
 	"github.com/rs/zerolog/log"
 )
 
@@ -48,11 +54,18 @@ type Manager struct {
 	UninstallRequests map[string]grpc_installer_go.UninstallClusterRequest
 	// Operations with the list of ongoing operations.
 	Operations map[string]*Operation
+	// Store where the operations are persisted.
+	Store OperationStore
+	// Progress with the broker that sends the updates of the operations to their watchers.
+	Progress *ProgressBroker
+	// Templates with the catalog of workflow templates.
+	Templates *templates.Catalog
 }
 
-// NewManager creates a new installer manager.
-func NewManager(config config.Config) Manager {
-	return Manager{
+// NewManager creates a new installer manager. Operations found in the store are reloaded, and those that
+// were running when the installer stopped are marked as failed.
+func NewManager(config config.Config, store OperationStore) *Manager {
+	manager := &Manager{
 		Config:            config,
 		Paths:             *workflow.NewPaths(config.ComponentsPath, config.BinaryPath, config.TempPath),
 		ExecHandler:       workflow.GetExecutorHandler(),
@@ -60,7 +73,145 @@ func NewManager(config config.Config) Manager {
 		InstallRequests:   make(map[string]grpc_installer_go.InstallRequest, 0),
 		UninstallRequests: make(map[string]grpc_installer_go.UninstallClusterRequest, 0),
 		Operations:        make(map[string]*Operation, 0),
+		Store:             store,
+		Progress:          NewProgressBroker(),
+		Templates:         templates.NewCatalog(),
 	}
+	manager.restoreOperations()
+	return manager
+}
+
+// restoreOperations loads the operations available in the store.
+func (m *Manager) restoreOperations() {
+	records, err := m.Store.List()
+	if err != nil {
+		log.Error().Str("trace", err.DebugReport()).Msg("cannot restore operations")
+		return
+	}
+	m.Lock()
+	defer m.Unlock()
+	for _, record := range records {
+		op := NewOperationFromRecord(record)
+		if record.InstallRequest != nil {
+			m.InstallRequests[record.RequestID] = *record.InstallRequest
+		}
+		if record.UninstallRequest != nil {
+			m.UninstallRequests[record.RequestID] = *record.UninstallRequest
+		}
+		m.Operations[record.RequestID] = op
+		if isFinalStatus(record.Status) {
+			continue
+		}
+		log.Warn().Str("requestID", record.RequestID).Str("operation", record.OperationName).
+			Interface("status", record.Status).Msg("operation interrupted by a restart")
+		op.UpdateError(derrors.NewAbortedError(errors.OperationInterrupted).WithParams(record.RequestID))
+		op.UpdateStatus(grpc_common_go.OpStatus_FAILED)
+		op.UpdateWorkflowState(workflow.ErrorState)
+		op.Resumable = record.InstallRequest != nil
+		m.unsafePersist(record.RequestID)
+	}
+	log.Info().Int("operations", len(records)).Msg("operations restored")
+}
+
+// LoadTemplates adds the templates of the configured directory to the catalog, checks that the templates
+// selected by the configuration are available, and configures the parser with the fragments of the catalog and
+// the render mode.
+func (m *Manager) LoadTemplates() derrors.Error {
+	if m.Config.TemplatesPath != "" {
+		if err := m.Templates.LoadDirectory(m.Config.TemplatesPath); err != nil {
+			return err
+		}
+	}
+	install, err := m.resolveTemplate(templates.Ref{}, m.Config.InstallTemplate, templates.InstallTemplate)
+	if err != nil {
+		return err
+	}
+	uninstall, err := m.resolveTemplate(templates.Ref{}, m.Config.UninstallTemplate, templates.UninstallTemplate)
+	if err != nil {
+		return err
+	}
+	m.Parser.SetFragments(m.Templates.Fragments())
+	mode, err := workflow.RenderModeFromString(m.Config.TemplateRenderMode)
+	if err != nil {
+		return err
+	}
+	m.Parser.SetRenderMode(mode)
+	for _, template := range m.Templates.List() {
+		log.Debug().Str("template", template.Ref().String()).Str("source", template.Source).Msg("workflow template available")
+	}
+	log.Info().Str("install", install.Ref().String()).Str("uninstall", uninstall.Ref().String()).Msg("default workflow templates")
+	return nil
+}
+
+// resolveTemplate retrieves the template of a new operation. The template requested for the operation takes
+// precedence over the one set in the configuration, and the default name is used if none of them sets it.
+func (m *Manager) resolveTemplate(requested templates.Ref, configured string, defaultName string) (*templates.Template, derrors.Error) {
+	ref := requested
+	if ref.Name == "" {
+		ref = templates.ParseRef(configured)
+		if ref.Name == "" {
+			ref.Name = defaultName
+		}
+	}
+	return m.Templates.Get(ref)
+}
+
+// operationTemplate retrieves the template recorded in an operation. Operations without a recorded template use
+// the template set in the configuration, and record it.
+func (m *Manager) operationTemplate(status *Operation, configured string, defaultName string) (*templates.Template, derrors.Error) {
+	if status.TemplateName != "" {
+		return m.Templates.Get(templates.Ref{Name: status.TemplateName, Version: status.TemplateVersion})
+	}
+	template, err := m.resolveTemplate(templates.Ref{}, configured, defaultName)
+	if err != nil {
+		return nil, err
+	}
+	m.Lock()
+	status.TemplateName = template.Name
+	status.TemplateVersion = template.Version
+	m.unsafePersist(status.RequestID)
+	m.Unlock()
+	return template, nil
+}
+
+// isFinalStatus checks if an operation status will not change anymore.
+func isFinalStatus(status grpc_common_go.OpStatus) bool {
+	return status == grpc_common_go.OpStatus_SUCCESS ||
+		status == grpc_common_go.OpStatus_FAILED ||
+		status == grpc_common_go.OpStatus_CANCELED
+}
+
+// unsafePersist writes the current state of an operation in the store without the credentials of its request. The
+// manager lock must be held.
+func (m *Manager) unsafePersist(requestID string) {
+	op, exists := m.Operations[requestID]
+	if !exists {
+		return
+	}
+	record := op.ToRecord()
+	if request, exists := m.InstallRequests[requestID]; exists {
+		record.InstallRequest = installRequestWithoutCredentials(request)
+	}
+	if request, exists := m.UninstallRequests[requestID]; exists {
+		record.UninstallRequest = uninstallRequestWithoutCredentials(request)
+	}
+	if err := m.Store.Save(record); err != nil {
+		log.Error().Str("requestID", requestID).Str("trace", err.DebugReport()).Msg(errors.CannotPersistOperation)
+	}
+}
+
+// ListOperations retrieves the operations known by the installer, including those restored from the store.
+func (m *Manager) ListOperations() []*Operation {
+	m.Lock()
+	defer m.Unlock()
+	result := make([]*Operation, 0, len(m.Operations))
+	for _, op := range m.Operations {
+		result = append(result, op.Clone())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created < result[j].Created
+	})
+	return result
 }
 
 func (m *Manager) unsafeExist(requestID string) bool {
@@ -68,24 +219,44 @@ func (m *Manager) unsafeExist(requestID string) bool {
 	return exists
 }
 
-func (m *Manager) unsafeInstallRegister(installRequest grpc_installer_go.InstallRequest) {
+func (m *Manager) unsafeInstallRegister(installRequest grpc_installer_go.InstallRequest, template *templates.Template) {
 	m.InstallRequests[installRequest.RequestId] = installRequest
-	m.Operations[installRequest.RequestId] = NewOperation(installRequest.OrganizationId, installRequest.RequestId, InstallOperation)
+	op := NewOperation(installRequest.OrganizationId, installRequest.RequestId, InstallOperation)
+	op.TemplateName = template.Name
+	op.TemplateVersion = template.Version
+	m.Operations[installRequest.RequestId] = op
+	m.unsafePersist(installRequest.RequestId)
 }
 
-func (m *Manager) unsafeUninstallRegister(request grpc_installer_go.UninstallClusterRequest) {
+func (m *Manager) unsafeUninstallRegister(request grpc_installer_go.UninstallClusterRequest, template *templates.Template) {
 	m.UninstallRequests[request.RequestId] = request
-	m.Operations[request.RequestId] = NewOperation(request.OrganizationId, request.RequestId, UninstallOperation)
+	op := NewOperation(request.OrganizationId, request.RequestId, UninstallOperation)
+	op.TemplateName = template.Name
+	op.TemplateVersion = template.Version
+	m.Operations[request.RequestId] = op
+	m.unsafePersist(request.RequestId)
 }
 
-func (m *Manager) InstallCluster(installRequest grpc_installer_go.InstallRequest) (*Operation, derrors.Error) {
+// InstallCluster registers and launches an install operation.
+//   params:
+//     installRequest The install request.
+//     template The name and the optional version of the workflow template. If the name is empty, the template set in
+//       the configuration is used.
+//   returns:
+//     The registered operation.
+//     An error if the request already exists or the template is not available.
+func (m *Manager) InstallCluster(installRequest grpc_installer_go.InstallRequest, template templates.Ref) (*Operation, derrors.Error) {
+	workflowTemplate, err := m.resolveTemplate(template, m.Config.InstallTemplate, templates.InstallTemplate)
+	if err != nil {
+		return nil, err
+	}
 	var result *Operation
 	m.Lock()
 	if m.unsafeExist(installRequest.RequestId) {
 		m.Unlock()
 		return nil, derrors.NewAlreadyExistsError("requestID").WithParams(installRequest.RequestId)
 	}
-	m.unsafeInstallRegister(installRequest)
+	m.unsafeInstallRegister(installRequest, workflowTemplate)
 	status, _ := m.Operations[installRequest.RequestId]
 	result = status.Clone()
 	m.Unlock()
@@ -98,9 +269,60 @@ func (m *Manager) markOperationAsFailed(requestID string, error derrors.Error) {
 	status, _ := m.Operations[requestID]
 	status.UpdateError(error)
 	status.UpdateStatus(grpc_common_go.OpStatus_FAILED)
+	m.unsafePersist(requestID)
+	m.unsafePublish(requestID, "")
 	m.Unlock()
 }
 
+// markOperationAsStarted records that the workflow of an operation is about to be executed.
+func (m *Manager) markOperationAsStarted(requestID string) {
+	m.WorkflowCallback(requestID, nil, workflow.InProgressState)
+}
+
+// unsafePublish sends the current status of an operation to its watchers, and finishes the watches once the
+// operation reaches a final status. The manager lock must be held.
+func (m *Manager) unsafePublish(requestID string, msg string) {
+	op, exists := m.Operations[requestID]
+	if !exists {
+		return
+	}
+	event := m.unsafeProgressEvent(op, msg)
+	if isFinalStatus(event.Status) {
+		m.Progress.Finish(event)
+		return
+	}
+	m.Progress.Publish(event)
+}
+
+// unsafeProgressEvent creates an event with the current status of an operation. The manager lock must be held.
+func (m *Manager) unsafeProgressEvent(op *Operation, msg string) ProgressEvent {
+	current, total := 0, 0
+	if op.Workflow != nil {
+		total = len(op.Workflow.Commands)
+		if exec, err := m.ExecHandler.Get(op.RequestID); err == nil {
+			current, total = exec.CurrentCommand()
+			current++
+		}
+	}
+	return NewProgressEvent(op, current, total, msg)
+}
+
+// WatchProgress subscribes to the updates of an operation. The first event contains the current status of the
+// operation, and the channel is closed once the operation finishes.
+func (m *Manager) WatchProgress(requestID string) (<-chan ProgressEvent, func(), derrors.Error) {
+	m.Lock()
+	defer m.Unlock()
+	op, exists := m.Operations[requestID]
+	if !exists {
+		return nil, nil, derrors.NewNotFoundError("requestID").WithParams(requestID)
+	}
+	events, cancel := m.Progress.Subscribe(requestID, m.unsafeProgressEvent(op, ""))
+	if isFinalStatus(*op.GetState()) {
+		cancel()
+	}
+	return events, cancel, nil
+}
+
 func (m *Manager) launchInstall(requestID string) {
 	m.Lock()
 	request, exitsRequest := m.InstallRequests[requestID]
@@ -112,10 +334,44 @@ func (m *Manager) launchInstall(requestID string) {
 		return
 	}
 
+	exec, err := m.prepareInstall(requestID, request, status)
+	if err != nil {
+		m.markOperationAsFailed(requestID, err)
+		return
+	}
+	m.markOperationAsStarted(requestID)
+	exec.Exec()
+}
+
+// prepareInstall creates the parameters and the workflow of an install request, and registers its executor.
+func (m *Manager) prepareInstall(requestID string, request grpc_installer_go.InstallRequest, status *Operation) (*workflow.Executor, derrors.Error) {
+	err := m.buildInstallWorkflow(requestID, request, status)
+	if err != nil {
+		return nil, err
+	}
+
+	exec, err := m.ExecHandler.Add(status.Workflow, m.WorkflowCallback)
+	if err != nil {
+		log.Error().Str("err", err.DebugReport()).Msg("cannot register workflow")
+		return nil, err
+	}
+	exec.SetEventListener(m.eventListener(requestID))
+	exec.SetCheckpointListener(m.checkpointListener(requestID))
+	exec.SetRollbackOnFailure(m.Config.RollbackOnFailure)
+	exec.SetMaxParallelism(m.Config.MaxParallelism)
+	ownership := wEntities.NewOwnership(version.AppVersion, requestID, request.ClusterId)
+	exec.SetOwnership(ownership)
+	exec.SetInventory(wEntities.NewInventory(status.Workflow.Name, status.Workflow.Description, ownership,
+		status.Params.InventoryParameters()))
+	return exec, nil
+}
+
+// buildInstallWorkflow creates the parameters and the workflow of an install request in the given operation.
+func (m *Manager) buildInstallWorkflow(requestID string, request grpc_installer_go.InstallRequest, status *Operation) derrors.Error {
 	// The network configuration is taken from the running parameters of the installer service
 	networkingConfig := workflow.NetworkConfig{
-		NetworkingMode: entities.NetworkingModeToString[m.Config.NetworkingMode],
-		IstioPath: m.Config.IstioPath,
+		NetworkingMode:     entities.NetworkingModeToString[m.Config.NetworkingMode],
+		IstioPath:          m.Config.IstioPath,
 		ZTPlanetSecretPath: "",
 	}
 
@@ -132,30 +388,170 @@ func (m *Manager) launchInstall(requestID string) {
 	err := status.Params.LoadCredentials()
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("cannot load credentials")
-		m.markOperationAsFailed(requestID, err)
+		return err
 	}
 	err = status.Params.Validate()
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("invalid parameters")
-		m.markOperationAsFailed(requestID, err)
+		return err
 	}
 
 	// Create Workflow
-	workflow, err := m.Parser.ParseWorkflow(requestID, templates.InstallManagementCluster, requestID, *status.Params)
+	template, err := m.operationTemplate(status, m.Config.InstallTemplate, templates.InstallTemplate)
+	if err != nil {
+		log.Error().Str("err", err.DebugReport()).Msg("cannot retrieve workflow template")
+		return err
+	}
+	log.Info().Str("requestID", requestID).Str("template", template.Ref().String()).Msg("building install workflow")
+	wf, err := m.Parser.ParseWorkflow(requestID, template.Content, requestID, *status.Params)
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("cannot parse workflow")
-		m.markOperationAsFailed(requestID, err)
+		return err
 	}
-	status.Workflow = workflow
+	status.Workflow = wf
+	return nil
+}
 
-	// Launch install process
-	exec, err := m.ExecHandler.Add(status.Workflow, m.WorkflowCallback)
+// DryRunInstall executes the workflow of an install request in dry-run mode. The request is not registered as an
+// operation, and the manifest with the changes the install would apply is returned once the workflow finishes.
+func (m *Manager) DryRunInstall(ctx context.Context, request grpc_installer_go.InstallRequest, template templates.Ref) (*wEntities.Manifest, derrors.Error) {
+	workflowTemplate, err := m.resolveTemplate(template, m.Config.InstallTemplate, templates.InstallTemplate)
 	if err != nil {
-		log.Error().Str("err", err.DebugReport()).Msg("cannot parse workflow")
-		m.markOperationAsFailed(requestID, err)
+		return nil, err
 	}
-	exec.SetLogListener(m.logListener)
+	status := NewOperation(request.OrganizationId, request.RequestId, InstallOperation)
+	status.TemplateName = workflowTemplate.Name
+	status.TemplateVersion = workflowTemplate.Version
+	err = m.buildInstallWorkflow(request.RequestId, request, status)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan derrors.Error, 1)
+	exec := workflow.NewWorkflowExecutor(status.Workflow, func(workflowID string, error derrors.Error, state workflow.WorkflowState) {
+		if error == nil && state == workflow.CanceledState {
+			error = derrors.NewCanceledError(errors.DryRunCanceled).WithParams(workflowID)
+		}
+		// The executor may notify the final state more than once.
+		select {
+		case done <- error:
+		default:
+		}
+	})
+	exec.SetMaxParallelism(m.Config.MaxParallelism)
+	ownership := wEntities.NewOwnership(version.AppVersion, request.RequestId, request.ClusterId)
+	exec.SetOwnership(ownership)
+	exec.SetInventory(wEntities.NewInventory(status.Workflow.Name, status.Workflow.Description, ownership,
+		status.Params.InventoryParameters()))
+	manifest := wEntities.NewManifest()
+	exec.SetDryRun(manifest)
 	exec.Exec()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		exec.Stop()
+		err = <-done
+	}
+	if err != nil {
+		log.Warn().Str("requestID", request.RequestId).Str("trace", err.DebugReport()).Msg("dry-run install failed")
+		return nil, err
+	}
+	log.Debug().Str("requestID", request.RequestId).Int("entries", len(manifest.Entries())).Msg("dry-run install finished")
+	return manifest, nil
+}
+
+// checkpointListener creates a function that records the finished commands of an operation in the store.
+func (m *Manager) checkpointListener(requestID string) func(checkpoint workflow.Checkpoint) {
+	return func(checkpoint workflow.Checkpoint) {
+		m.Lock()
+		defer m.Unlock()
+		op, exists := m.Operations[requestID]
+		if !exists {
+			return
+		}
+		op.AddCheckpoint(checkpoint)
+		m.unsafePersist(requestID)
+	}
+}
+
+// ResumeInstall launches again a failed install operation starting from the first command that did not finish.
+//   params:
+//     credentials The request identifier of the install with its kubeconfig or private key. The credentials are not
+//       stored, so they are required to resume an install restored after a restart. The other fields are ignored.
+//   returns:
+//     The resumed operation.
+//     An error if the operation cannot be resumed or there are no credentials for it.
+func (m *Manager) ResumeInstall(credentials grpc_installer_go.InstallRequest) (*Operation, derrors.Error) {
+	requestID := credentials.RequestId
+	m.Lock()
+	op, exists := m.Operations[requestID]
+	if !exists {
+		m.Unlock()
+		return nil, derrors.NewNotFoundError("requestID").WithParams(requestID)
+	}
+	request, existsRequest := m.InstallRequests[requestID]
+	if op.OperationName != InstallOperation || !existsRequest {
+		m.Unlock()
+		return nil, derrors.NewInvalidArgumentError(errors.OperationCannotBeResumed).WithParams(requestID, op.OperationName)
+	}
+	state := op.GetState()
+	if *state != grpc_common_go.OpStatus_FAILED {
+		m.Unlock()
+		return nil, derrors.NewFailedPreconditionError(errors.OperationCannotBeResumed).WithParams(requestID, state.String())
+	}
+	if credentials.KubeConfigRaw != "" || credentials.PrivateKey != "" {
+		request.KubeConfigRaw = credentials.KubeConfigRaw
+		request.PrivateKey = credentials.PrivateKey
+	}
+	if request.KubeConfigRaw == "" && request.PrivateKey == "" {
+		m.Unlock()
+		return nil, derrors.NewInvalidArgumentError(errors.ResumeWithoutCredentials).WithParams(requestID)
+	}
+	m.InstallRequests[requestID] = request
+	op.PrepareResume()
+	m.unsafePersist(requestID)
+	result := op.Clone()
+	m.Unlock()
+	go m.resumeInstall(requestID)
+	return result, nil
+}
+
+func (m *Manager) resumeInstall(requestID string) {
+	m.Lock()
+	request, exitsRequest := m.InstallRequests[requestID]
+	status, existStatus := m.Operations[requestID]
+	m.Unlock()
+
+	if !exitsRequest || !existStatus {
+		log.Error().Str("requestID", requestID).Msg("cannot resume the install process")
+		return
+	}
+
+	// If the workflow was executed by this instance, the executor keeps its checkpoints.
+	if status.Workflow != nil {
+		exec, err := m.ExecHandler.Get(requestID)
+		if err == nil {
+			m.markOperationAsStarted(requestID)
+			exec.Resume()
+			return
+		}
+	}
+
+	// Otherwise the workflow is parsed again and the stored checkpoints are applied.
+	exec, err := m.prepareInstall(requestID, request, status)
+	if err != nil {
+		m.markOperationAsFailed(requestID, err)
+		return
+	}
+	err = exec.RestoreCheckpoints(status.GetCheckpoints())
+	if err != nil {
+		log.Error().Str("err", err.DebugReport()).Msg("cannot restore checkpoints")
+		m.markOperationAsFailed(requestID, err)
+		return
+	}
+	m.markOperationAsStarted(requestID)
+	exec.Resume()
 }
 
 func (m *Manager) GetProgress(requestID string) (*Operation, derrors.Error) {
@@ -180,8 +576,12 @@ func (m *Manager) WorkflowCallback(
 	status, exist := m.Operations[workflowID]
 	if !exist {
 		log.Warn().Str("workflowID", workflowID).Msg("received callback for unregistered workflow")
+		return
 	}
+	defer m.unsafePersist(workflowID)
+	defer m.unsafePublish(workflowID, "")
 	if error != nil {
+		status.UpdateError(error)
 		status.UpdateStatus(grpc_common_go.OpStatus_FAILED)
 	}
 	status.UpdateWorkflowState(state)
@@ -200,14 +600,30 @@ func (m *Manager) WorkflowCallback(
 		return
 	case workflow.ErrorState:
 		status.UpdateStatus(grpc_common_go.OpStatus_FAILED)
+	case workflow.CanceledState:
+		status.UpdateStatus(grpc_common_go.OpStatus_CANCELED)
+	case workflow.RolledBackState:
+		// The commands have been undone, so a resume must start from the beginning.
+		status.ClearCheckpoints()
+		status.UpdateStatus(grpc_common_go.OpStatus_FAILED)
 	default:
 		log.Warn().Interface("state", state).Msg("State not recognized")
 	}
 }
 
-func (m *Manager) logListener(msg string) {
-	// TODO store the information on the install status
-	log.Info().Msg(msg)
+// eventListener creates a function that sends the execution events of an operation to its watchers.
+func (m *Manager) eventListener(requestID string) func(event wEntities.ExecutionEvent) {
+	return func(event wEntities.ExecutionEvent) {
+		msg := event.String()
+		if msg != "" {
+			log.Info().Str("requestID", requestID).Msg(msg)
+		} else {
+			msg = fmt.Sprintf("%s %s", event.CommandName, event.Phase)
+		}
+		m.Lock()
+		defer m.Unlock()
+		m.unsafePublish(requestID, msg)
+	}
 }
 
 func (m *Manager) RemoveInstall(requestID string) derrors.Error {
@@ -215,6 +631,7 @@ func (m *Manager) RemoveInstall(requestID string) derrors.Error {
 	// Determine the type of operation
 	op, existsOp := m.Operations[requestID]
 	if !existsOp {
+		m.Unlock()
 		return derrors.NewNotFoundError("request is not managed by the installer").WithParams(requestID)
 	}
 
@@ -228,32 +645,51 @@ func (m *Manager) RemoveInstall(requestID string) derrors.Error {
 		_, exitsRequest := m.UninstallRequests[requestID]
 		if exitsRequest {
 			log.Debug().Str("requestID", requestID).Msg("Removing uninstall request")
-			delete(m.InstallRequests, requestID)
+			delete(m.UninstallRequests, requestID)
 		}
 	}
 	m.Unlock()
 
 	if existsOp {
-		err := m.ExecHandler.Stop(requestID)
-		if err != nil {
-			return err
+		// Operations restored from the store do not have an associated executor.
+		if op.Workflow != nil {
+			err := m.ExecHandler.Stop(requestID)
+			if err != nil {
+				return err
+			}
 		}
 		m.Lock()
 		delete(m.Operations, requestID)
 		m.Unlock()
+		m.Progress.Close(requestID)
+		if err := m.Store.Remove(requestID); err != nil {
+			log.Warn().Str("requestID", requestID).Str("trace", err.DebugReport()).Msg("cannot remove operation from the store")
+		}
 	}
 
 	return nil
 }
 
-func (m *Manager) UninstallCluster(request grpc_installer_go.UninstallClusterRequest) (*Operation, derrors.Error) {
+// UninstallCluster registers and launches an uninstall operation.
+//   params:
+//     request The uninstall request.
+//     template The name and the optional version of the workflow template. If the name is empty, the template set in
+//       the configuration is used.
+//   returns:
+//     The registered operation.
+//     An error if the request already exists or the template is not available.
+func (m *Manager) UninstallCluster(request grpc_installer_go.UninstallClusterRequest, template templates.Ref) (*Operation, derrors.Error) {
+	workflowTemplate, err := m.resolveTemplate(template, m.Config.UninstallTemplate, templates.UninstallTemplate)
+	if err != nil {
+		return nil, err
+	}
 	var result *Operation
 	m.Lock()
 	if m.unsafeExist(request.RequestId) {
 		m.Unlock()
 		return nil, derrors.NewAlreadyExistsError("requestID").WithParams(request.RequestId)
 	}
-	m.unsafeUninstallRegister(request)
+	m.unsafeUninstallRegister(request, workflowTemplate)
 	status, _ := m.Operations[request.RequestId]
 	result = status.Clone()
 	m.Unlock()
@@ -279,18 +715,28 @@ func (m *Manager) launchUninstall(requestID string) {
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("cannot load credentials")
 		m.markOperationAsFailed(requestID, err)
+		return
 	}
 	err = status.Params.Validate()
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("invalid parameters")
 		m.markOperationAsFailed(requestID, err)
+		return
 	}
 
 	// Create Workflow
-	workflow, err := m.Parser.ParseWorkflow(requestID, templates.UninstallCluster, requestID, *status.Params)
+	template, err := m.operationTemplate(status, m.Config.UninstallTemplate, templates.UninstallTemplate)
+	if err != nil {
+		log.Error().Str("err", err.DebugReport()).Msg("cannot retrieve workflow template")
+		m.markOperationAsFailed(requestID, err)
+		return
+	}
+	log.Info().Str("requestID", requestID).Str("template", template.Ref().String()).Msg("building uninstall workflow")
+	workflow, err := m.Parser.ParseWorkflow(requestID, template.Content, requestID, *status.Params)
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("cannot parse workflow")
 		m.markOperationAsFailed(requestID, err)
+		return
 	}
 	status.Workflow = workflow
 
@@ -299,7 +745,10 @@ func (m *Manager) launchUninstall(requestID string) {
 	if err != nil {
 		log.Error().Str("err", err.DebugReport()).Msg("cannot parse workflow")
 		m.markOperationAsFailed(requestID, err)
+		return
 	}
-	exec.SetLogListener(m.logListener)
+	exec.SetEventListener(m.eventListener(requestID))
+	exec.SetCheckpointListener(m.checkpointListener(requestID))
+	m.markOperationAsStarted(requestID)
 	exec.Exec()
 }
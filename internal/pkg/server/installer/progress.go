@@ -0,0 +1,199 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package installer
+
+import (
+	"fmt"
+	"github.com/nalej/grpc-common-go"
+	"github.com/nalej/installer/internal/pkg/workflow"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// ProgressBufferSize with the number of events that can be queued for a subscriber before new events are dropped.
+// The final event of an operation is never dropped.
+const ProgressBufferSize = 256
+
+// ProgressEvent structure with an update of the execution of an operation.
+type ProgressEvent struct {
+	OrganizationID string
+	RequestID      string
+	OperationName  string
+	Status         grpc_common_go.OpStatus
+	WorkflowState  workflow.WorkflowState
+	// CurrentCommand with the index of the command being executed, starting at 1. Zero if the workflow has not
+	// been created yet.
+	CurrentCommand int
+	// NumCommands with the total of commands of the workflow.
+	NumCommands int
+	// Msg with the log line that triggered the event. Empty on state transitions.
+	Msg       string
+	Error     string
+	Created   int64
+	Timestamp int64
+}
+
+// Info returns the progress information as it is reported on the Info field of an OpResponse.
+func (pe *ProgressEvent) Info() string {
+	if pe.Msg == "" {
+		return fmt.Sprintf("[%d/%d] %s", pe.CurrentCommand, pe.NumCommands, pe.WorkflowState)
+	}
+	return fmt.Sprintf("[%d/%d] %s: %s", pe.CurrentCommand, pe.NumCommands, pe.WorkflowState, pe.Msg)
+}
+
+// ToGRPCOpResponse transforms the event in common OpResponse.
+func (pe *ProgressEvent) ToGRPCOpResponse() *grpc_common_go.OpResponse {
+	return &grpc_common_go.OpResponse{
+		OrganizationId: pe.OrganizationID,
+		RequestId:      pe.RequestID,
+		OperationName:  pe.OperationName,
+		ElapsedTime:    pe.Timestamp - pe.Created,
+		Timestamp:      pe.Timestamp,
+		Status:         pe.Status,
+		Info:           pe.Info(),
+		Error:          pe.Error,
+	}
+}
+
+// ProgressBroker structure that fans out the progress events of the operations to their subscribers.
+type ProgressBroker struct {
+	sync.Mutex
+	// subscribers by request identifier and subscription identifier.
+	subscribers map[string]map[int]chan ProgressEvent
+	// nextID with the identifier of the next subscription.
+	nextID int
+}
+
+// NewProgressBroker creates a new ProgressBroker.
+func NewProgressBroker() *ProgressBroker {
+	return &ProgressBroker{
+		subscribers: make(map[string]map[int]chan ProgressEvent, 0),
+	}
+}
+
+// Subscribe registers a new subscriber for the events of an operation.
+//
+//	params:
+//	  requestID The request identifier of the operation.
+//	  snapshot The event with the current status of the operation, which is the first one received.
+//	returns:
+//	  The channel where the events are received. It is closed once the operation finishes.
+//	  A function to cancel the subscription.
+func (pb *ProgressBroker) Subscribe(requestID string, snapshot ProgressEvent) (<-chan ProgressEvent, func()) {
+	pb.Lock()
+	defer pb.Unlock()
+	events := make(chan ProgressEvent, ProgressBufferSize)
+	events <- snapshot
+	id := pb.nextID
+	pb.nextID++
+	if _, exists := pb.subscribers[requestID]; !exists {
+		pb.subscribers[requestID] = make(map[int]chan ProgressEvent, 0)
+	}
+	pb.subscribers[requestID][id] = events
+	return events, func() {
+		pb.unsubscribe(requestID, id)
+	}
+}
+
+// unsubscribe removes a subscription and closes its channel.
+func (pb *ProgressBroker) unsubscribe(requestID string, id int) {
+	pb.Lock()
+	defer pb.Unlock()
+	subscriptions, exists := pb.subscribers[requestID]
+	if !exists {
+		return
+	}
+	if events, exists := subscriptions[id]; exists {
+		close(events)
+		delete(subscriptions, id)
+	}
+	if len(subscriptions) == 0 {
+		delete(pb.subscribers, requestID)
+	}
+}
+
+// Publish sends an event to the subscribers of the operation. Slow subscribers do not block the execution of the
+// workflow, so the event is dropped for those whose buffer is full.
+func (pb *ProgressBroker) Publish(event ProgressEvent) {
+	pb.Lock()
+	defer pb.Unlock()
+	for id, events := range pb.subscribers[event.RequestID] {
+		select {
+		case events <- event:
+		default:
+			log.Warn().Str("requestID", event.RequestID).Int("subscription", id).Msg("progress event dropped")
+		}
+	}
+}
+
+// Finish sends the final event of an operation to its subscribers and closes their subscriptions. The oldest
+// queued event is dropped for the subscribers whose buffer is full, so the last event received always contains
+// the final status of the operation.
+func (pb *ProgressBroker) Finish(event ProgressEvent) {
+	pb.Lock()
+	defer pb.Unlock()
+	for id, events := range pb.subscribers[event.RequestID] {
+		select {
+		case events <- event:
+		default:
+			// The broker is the only sender, so removing a queued event makes room for the final one.
+			select {
+			case <-events:
+				log.Warn().Str("requestID", event.RequestID).Int("subscription", id).Msg("progress event dropped")
+			default:
+			}
+			events <- event
+		}
+		close(events)
+	}
+	delete(pb.subscribers, event.RequestID)
+}
+
+// Close finishes the subscriptions of an operation without sending a final event.
+func (pb *ProgressBroker) Close(requestID string) {
+	pb.Lock()
+	defer pb.Unlock()
+	for _, events := range pb.subscribers[requestID] {
+		close(events)
+	}
+	delete(pb.subscribers, requestID)
+}
+
+// NewProgressEvent creates a ProgressEvent with the current status of an operation.
+func NewProgressEvent(op *Operation, currentCommand int, numCommands int, msg string) ProgressEvent {
+	op.Lock()
+	defer op.Unlock()
+	var e string
+	if op.error != nil {
+		e = op.error.Error()
+	}
+	return ProgressEvent{
+		OrganizationID: op.OrganizationID,
+		RequestID:      op.RequestID,
+		OperationName:  op.OperationName,
+		Status:         op.status,
+		WorkflowState:  op.workflowState,
+		CurrentCommand: currentCommand,
+		NumCommands:    numCommands,
+		Msg:            msg,
+		Error:          e,
+		Created:        op.Created,
+		Timestamp:      time.Now().Unix(),
+	}
+}
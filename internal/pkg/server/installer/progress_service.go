@@ -0,0 +1,128 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// The installer protos do not define a streaming method to follow the progress of an operation. This file
+// declares the Progress service by hand reusing the messages of grpc-common-go, so any gRPC client can consume it
+// with the full method name /installer.Progress/WatchProgress.
+
+package installer
+
+import (
+	"context"
+	"github.com/nalej/grpc-common-go"
+	"google.golang.org/grpc"
+)
+
+// ProgressServiceName with the name of the progress service.
+const ProgressServiceName = "installer.Progress"
+
+// WatchProgressMethod with the full name of the method that streams the progress of an operation.
+const WatchProgressMethod = "/" + ProgressServiceName + "/WatchProgress"
+
+// ProgressServer is the server API for the Progress service.
+type ProgressServer interface {
+	// WatchProgress streams the updates of an operation until it finishes.
+	WatchProgress(*grpc_common_go.RequestId, ProgressStream) error
+}
+
+// ProgressStream is the server side of the WatchProgress stream.
+type ProgressStream interface {
+	Send(*grpc_common_go.OpResponse) error
+	grpc.ServerStream
+}
+
+type progressStream struct {
+	grpc.ServerStream
+}
+
+func (ps *progressStream) Send(response *grpc_common_go.OpResponse) error {
+	return ps.ServerStream.SendMsg(response)
+}
+
+func watchProgressHandler(srv interface{}, stream grpc.ServerStream) error {
+	request := new(grpc_common_go.RequestId)
+	if err := stream.RecvMsg(request); err != nil {
+		return err
+	}
+	return srv.(ProgressServer).WatchProgress(request, &progressStream{stream})
+}
+
+var progressServiceDesc = grpc.ServiceDesc{
+	ServiceName: ProgressServiceName,
+	HandlerType: (*ProgressServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchProgress",
+			Handler:       watchProgressHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+// RegisterProgressServer registers the Progress service in a gRPC server.
+func RegisterProgressServer(s *grpc.Server, srv ProgressServer) {
+	s.RegisterService(&progressServiceDesc, srv)
+}
+
+// ProgressClient is the client API for the Progress service.
+type ProgressClient interface {
+	// WatchProgress opens a stream with the updates of an operation.
+	WatchProgress(ctx context.Context, in *grpc_common_go.RequestId, opts ...grpc.CallOption) (ProgressClientStream, error)
+}
+
+// ProgressClientStream is the client side of the WatchProgress stream.
+type ProgressClientStream interface {
+	Recv() (*grpc_common_go.OpResponse, error)
+	grpc.ClientStream
+}
+
+type progressClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewProgressClient creates a client of the Progress service.
+func NewProgressClient(cc *grpc.ClientConn) ProgressClient {
+	return &progressClient{cc}
+}
+
+func (pc *progressClient) WatchProgress(ctx context.Context, in *grpc_common_go.RequestId, opts ...grpc.CallOption) (ProgressClientStream, error) {
+	stream, err := pc.cc.NewStream(ctx, &progressServiceDesc.Streams[0], WatchProgressMethod, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &progressClientStream{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type progressClientStream struct {
+	grpc.ClientStream
+}
+
+func (x *progressClientStream) Recv() (*grpc_common_go.OpResponse, error) {
+	response := new(grpc_common_go.OpResponse)
+	if err := x.ClientStream.RecvMsg(response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
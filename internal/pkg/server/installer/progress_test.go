@@ -0,0 +1,121 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package installer
+
+import (
+	"github.com/nalej/grpc-common-go"
+	cfg "github.com/nalej/installer/internal/pkg/server/config"
+	"github.com/nalej/installer/internal/pkg/workflow"
+	wEntities "github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// receiveAll reads the events of a subscription until the channel is closed.
+func receiveAll(events <-chan ProgressEvent) []ProgressEvent {
+	result := make([]ProgressEvent, 0)
+	for event := range events {
+		result = append(result, event)
+	}
+	return result
+}
+
+var _ = ginkgo.Describe("Progress", func() {
+
+	ginkgo.Context("with a broker", func() {
+		ginkgo.It("should send the events to all the subscribers of an operation", func() {
+			broker := NewProgressBroker()
+			first, _ := broker.Subscribe("r1", ProgressEvent{RequestID: "r1", Msg: "snapshot"})
+			second, _ := broker.Subscribe("r1", ProgressEvent{RequestID: "r1", Msg: "snapshot"})
+			other, cancelOther := broker.Subscribe("r2", ProgressEvent{RequestID: "r2", Msg: "snapshot"})
+			broker.Publish(ProgressEvent{RequestID: "r1", Msg: "update"})
+			broker.Close("r1")
+			cancelOther()
+
+			for _, events := range []<-chan ProgressEvent{first, second} {
+				received := receiveAll(events)
+				gomega.Expect(len(received)).To(gomega.Equal(2))
+				gomega.Expect(received[0].Msg).To(gomega.Equal("snapshot"))
+				gomega.Expect(received[1].Msg).To(gomega.Equal("update"))
+			}
+			gomega.Expect(len(receiveAll(other))).To(gomega.Equal(1))
+		})
+
+		ginkgo.It("should not block on slow subscribers", func() {
+			broker := NewProgressBroker()
+			events, cancel := broker.Subscribe("r1", ProgressEvent{RequestID: "r1"})
+			for i := 0; i < ProgressBufferSize*2; i++ {
+				broker.Publish(ProgressEvent{RequestID: "r1"})
+			}
+			cancel()
+			cancel()
+			gomega.Expect(len(receiveAll(events))).To(gomega.Equal(ProgressBufferSize))
+		})
+
+		ginkgo.It("should deliver the final event to slow subscribers", func() {
+			broker := NewProgressBroker()
+			events, cancel := broker.Subscribe("r1", ProgressEvent{RequestID: "r1"})
+			defer cancel()
+			for i := 0; i < ProgressBufferSize*2; i++ {
+				broker.Publish(ProgressEvent{RequestID: "r1", Status: grpc_common_go.OpStatus_INPROGRESS})
+			}
+			broker.Finish(ProgressEvent{RequestID: "r1", Status: grpc_common_go.OpStatus_FAILED})
+			received := receiveAll(events)
+			gomega.Expect(len(received)).To(gomega.Equal(ProgressBufferSize))
+			gomega.Expect(received[len(received)-1].Status).To(gomega.Equal(grpc_common_go.OpStatus_FAILED))
+		})
+	})
+
+	ginkgo.Context("with a manager", func() {
+		ginkgo.It("should stream the state transitions and the log entries of an operation", func() {
+			manager := NewManager(cfg.Config{}, NewMemoryOperationStore())
+			manager.Operations["r1"] = NewOperation("org", "r1", InstallOperation)
+
+			events, cancel, err := manager.WatchProgress("r1")
+			gomega.Expect(err).To(gomega.Succeed())
+			defer cancel()
+			manager.markOperationAsStarted("r1")
+			manager.eventListener("r1")(wEntities.ExecutionEvent{Phase: wEntities.OutputPhase, Msg: "Executing: command"})
+			manager.WorkflowCallback("r1", nil, workflow.FinishedState)
+
+			received := receiveAll(events)
+			gomega.Expect(len(received)).To(gomega.Equal(4))
+			gomega.Expect(received[0].Status).To(gomega.Equal(grpc_common_go.OpStatus_INIT))
+			gomega.Expect(received[1].Status).To(gomega.Equal(grpc_common_go.OpStatus_INPROGRESS))
+			gomega.Expect(received[2].Msg).To(gomega.Equal("Executing: command"))
+			gomega.Expect(received[2].ToGRPCOpResponse().Info).To(gomega.ContainSubstring("Executing: command"))
+			gomega.Expect(received[3].Status).To(gomega.Equal(grpc_common_go.OpStatus_SUCCESS))
+			gomega.Expect(received[3].WorkflowState).To(gomega.Equal(workflow.FinishedState))
+		})
+
+		ginkgo.It("should finish the watch of a finished operation after the current status", func() {
+			manager := NewManager(cfg.Config{}, NewMemoryOperationStore())
+			manager.Operations["r1"] = NewOperation("org", "r1", InstallOperation)
+			manager.WorkflowCallback("r1", nil, workflow.FinishedState)
+
+			events, _, err := manager.WatchProgress("r1")
+			gomega.Expect(err).To(gomega.Succeed())
+			received := receiveAll(events)
+			gomega.Expect(len(received)).To(gomega.Equal(1))
+			gomega.Expect(received[0].Status).To(gomega.Equal(grpc_common_go.OpStatus_SUCCESS))
+
+			_, _, err = manager.WatchProgress("unknown")
+			gomega.Expect(err).ToNot(gomega.Succeed())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// The installer protos do not define a method to resume a failed install. This file declares the Resume service by
+// hand reusing the messages of grpc-installer-go and grpc-common-go, so any gRPC client can consume it with the full
+// method name /installer.Resume/ResumeInstall.
+
+package installer
+
+import (
+	"context"
+	"github.com/nalej/grpc-common-go"
+	"github.com/nalej/grpc-installer-go"
+	"google.golang.org/grpc"
+)
+
+// ResumeServiceName with the name of the resume service.
+const ResumeServiceName = "installer.Resume"
+
+// ResumeInstallMethod with the full name of the method that resumes a failed install.
+const ResumeInstallMethod = "/" + ResumeServiceName + "/ResumeInstall"
+
+// ResumeServer is the server API for the Resume service.
+type ResumeServer interface {
+	// ResumeInstall launches again a failed install starting from the first command that did not finish. The request
+	// contains the request identifier of the install and its credentials, the other fields are ignored.
+	ResumeInstall(context.Context, *grpc_installer_go.InstallRequest) (*grpc_common_go.OpResponse, error)
+}
+
+func resumeInstallHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := new(grpc_installer_go.InstallRequest)
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ResumeServer).ResumeInstall(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResumeInstallMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ResumeServer).ResumeInstall(ctx, req.(*grpc_installer_go.InstallRequest))
+	}
+	return interceptor(ctx, request, info, handler)
+}
+
+var resumeServiceDesc = grpc.ServiceDesc{
+	ServiceName: ResumeServiceName,
+	HandlerType: (*ResumeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ResumeInstall",
+			Handler:    resumeInstallHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// RegisterResumeServer registers the Resume service in a gRPC server.
+func RegisterResumeServer(s *grpc.Server, srv ResumeServer) {
+	s.RegisterService(&resumeServiceDesc, srv)
+}
+
+// ResumeClient is the client API for the Resume service.
+type ResumeClient interface {
+	// ResumeInstall resumes a failed install.
+	ResumeInstall(ctx context.Context, in *grpc_installer_go.InstallRequest, opts ...grpc.CallOption) (*grpc_common_go.OpResponse, error)
+}
+
+type resumeClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewResumeClient creates a client of the Resume service.
+func NewResumeClient(cc *grpc.ClientConn) ResumeClient {
+	return &resumeClient{cc}
+}
+
+func (rc *resumeClient) ResumeInstall(ctx context.Context, in *grpc_installer_go.InstallRequest, opts ...grpc.CallOption) (*grpc_common_go.OpResponse, error) {
+	response := new(grpc_common_go.OpResponse)
+	err := rc.cc.Invoke(ctx, ResumeInstallMethod, in, response, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package installer
+
+import (
+	"context"
+	"github.com/nalej/grpc-common-go"
+	"github.com/nalej/grpc-installer-go"
+	"github.com/nalej/grpc-utils/pkg/test"
+	cfg "github.com/nalej/installer/internal/pkg/server/config"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+)
+
+var _ = ginkgo.Describe("Resume service", func() {
+
+	var tempDir string
+	var listener *bufconn.Listener
+	var server *grpc.Server
+	var conn *grpc.ClientConn
+	var client ResumeClient
+
+	ginkgo.BeforeEach(func() {
+		td, err := ioutil.TempDir("", "resume-service")
+		gomega.Expect(err).To(gomega.Succeed())
+		tempDir = td
+
+		store := NewMemoryOperationStore()
+		gomega.Expect(store.Save(sampleRecord("running", grpc_common_go.OpStatus_INPROGRESS))).To(gomega.Succeed())
+		gomega.Expect(store.Save(sampleRecord("done", grpc_common_go.OpStatus_SUCCESS))).To(gomega.Succeed())
+
+		listener = test.GetDefaultListener()
+		server = grpc.NewServer()
+		RegisterResumeServer(server, NewHandler(NewManager(cfg.Config{TempPath: tempDir}, store)))
+		test.LaunchServer(server, listener)
+
+		l := listener
+		conn, err = grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+			return l.Dial()
+		}))
+		gomega.Expect(err).To(gomega.Succeed())
+		client = NewResumeClient(conn)
+	})
+
+	ginkgo.AfterEach(func() {
+		conn.Close()
+		server.Stop()
+		listener.Close()
+		os.RemoveAll(tempDir)
+	})
+
+	ginkgo.It("should resume an interrupted install", func() {
+		response, err := client.ResumeInstall(context.Background(), &grpc_installer_go.InstallRequest{
+			RequestId:     "running",
+			KubeConfigRaw: "kubeconfig",
+		})
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(response.RequestId).To(gomega.Equal("running"))
+		gomega.Expect(response.OperationName).To(gomega.Equal(InstallOperation))
+	})
+
+	ginkgo.It("should reject operations that cannot be resumed", func() {
+		_, err := client.ResumeInstall(context.Background(), &grpc_installer_go.InstallRequest{RequestId: "done", KubeConfigRaw: "kubeconfig"})
+		gomega.Expect(err).ToNot(gomega.Succeed())
+		_, err = client.ResumeInstall(context.Background(), &grpc_installer_go.InstallRequest{RequestId: "unknown", KubeConfigRaw: "kubeconfig"})
+		gomega.Expect(err).ToNot(gomega.Succeed())
+		_, err = client.ResumeInstall(context.Background(), &grpc_installer_go.InstallRequest{})
+		gomega.Expect(err).ToNot(gomega.Succeed())
+	})
+
+	ginkgo.It("should require the credentials of an install restored from the store", func() {
+		_, err := client.ResumeInstall(context.Background(), &grpc_installer_go.InstallRequest{RequestId: "running"})
+		gomega.Expect(err).ToNot(gomega.Succeed())
+	})
+})
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package installer
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/nalej/derrors"
+	"github.com/nalej/grpc-common-go"
+	"github.com/nalej/grpc-installer-go"
+	"github.com/nalej/installer/internal/pkg/workflow"
+)
+
+// OperationRecord is the serializable representation of an operation managed by the installer.
+type OperationRecord struct {
+	OrganizationID string                  `json:"organization_id"`
+	RequestID      string                  `json:"request_id"`
+	OperationName  string                  `json:"operation_name"`
+	Status         grpc_common_go.OpStatus `json:"status"`
+	Created        int64                   `json:"created"`
+	Updated        int64                   `json:"updated"`
+	Error          string                  `json:"error,omitempty"`
+	WorkflowState  workflow.WorkflowState  `json:"workflow_state"`
+	// Resumable is set when the operation was interrupted and can be launched again.
+	Resumable bool `json:"resumable"`
+	// Checkpoints with the commands of the workflow that have been executed.
+	Checkpoints []workflow.Checkpoint `json:"checkpoints,omitempty"`
+	// TemplateName with the name of the workflow template of the operation.
+	TemplateName string `json:"template_name,omitempty"`
+	// TemplateVersion with the version of the workflow template of the operation.
+	TemplateVersion string `json:"template_version,omitempty"`
+	// InstallRequest contains the original request for install operations without its credentials.
+	InstallRequest *grpc_installer_go.InstallRequest `json:"install_request,omitempty"`
+	// UninstallRequest contains the original request for uninstall operations without its credentials.
+	UninstallRequest *grpc_installer_go.UninstallClusterRequest `json:"uninstall_request,omitempty"`
+}
+
+// OperationStore defines the interface to persist the operations managed by the installer so that they
+// survive a restart of the component.
+type OperationStore interface {
+	// Save creates or replaces the record of an operation.
+	Save(record OperationRecord) derrors.Error
+	// Get retrieves the record of an operation.
+	Get(requestID string) (*OperationRecord, derrors.Error)
+	// List retrieves all the stored records sorted by creation time.
+	List() ([]OperationRecord, derrors.Error)
+	// Remove deletes the record of an operation.
+	Remove(requestID string) derrors.Error
+}
+
+// MemoryOperationStore is an OperationStore that only keeps the records in memory.
+type MemoryOperationStore struct {
+	sync.Mutex
+	records map[string]OperationRecord
+}
+
+// NewMemoryOperationStore creates a new in-memory store.
+func NewMemoryOperationStore() *MemoryOperationStore {
+	return &MemoryOperationStore{
+		records: make(map[string]OperationRecord, 0),
+	}
+}
+
+func (mos *MemoryOperationStore) Save(record OperationRecord) derrors.Error {
+	mos.Lock()
+	defer mos.Unlock()
+	mos.records[record.RequestID] = record
+	return nil
+}
+
+func (mos *MemoryOperationStore) Get(requestID string) (*OperationRecord, derrors.Error) {
+	mos.Lock()
+	defer mos.Unlock()
+	record, exists := mos.records[requestID]
+	if !exists {
+		return nil, derrors.NewNotFoundError("operation").WithParams(requestID)
+	}
+	return &record, nil
+}
+
+func (mos *MemoryOperationStore) List() ([]OperationRecord, derrors.Error) {
+	mos.Lock()
+	defer mos.Unlock()
+	return sortedRecords(mos.records), nil
+}
+
+func (mos *MemoryOperationStore) Remove(requestID string) derrors.Error {
+	mos.Lock()
+	defer mos.Unlock()
+	if _, exists := mos.records[requestID]; !exists {
+		return derrors.NewNotFoundError("operation").WithParams(requestID)
+	}
+	delete(mos.records, requestID)
+	return nil
+}
+
+// installRequestWithoutCredentials copies an install request removing the kubeconfig and the private key, so they
+// are never written to the store. The credentials are supplied again when the install is resumed.
+func installRequestWithoutCredentials(request grpc_installer_go.InstallRequest) *grpc_installer_go.InstallRequest {
+	request.KubeConfigRaw = ""
+	request.PrivateKey = ""
+	return &request
+}
+
+// uninstallRequestWithoutCredentials copies an uninstall request removing the kubeconfig.
+func uninstallRequestWithoutCredentials(request grpc_installer_go.UninstallClusterRequest) *grpc_installer_go.UninstallClusterRequest {
+	request.KubeConfigRaw = ""
+	return &request
+}
+
+// sortedRecords returns the records of a map sorted by creation time.
+func sortedRecords(records map[string]OperationRecord) []OperationRecord {
+	result := make([]OperationRecord, 0, len(records))
+	for _, r := range records {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Created == result[j].Created {
+			return result[i].RequestID < result[j].RequestID
+		}
+		return result[i].Created < result[j].Created
+	})
+	return result
+}
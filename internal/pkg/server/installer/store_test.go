@@ -0,0 +1,177 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package installer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nalej/grpc-common-go"
+	"github.com/nalej/grpc-installer-go"
+	cfg "github.com/nalej/installer/internal/pkg/server/config"
+	"github.com/nalej/installer/internal/pkg/templates"
+	"github.com/nalej/installer/internal/pkg/workflow"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+func sampleRecord(requestID string, status grpc_common_go.OpStatus) OperationRecord {
+	return OperationRecord{
+		OrganizationID: "org",
+		RequestID:      requestID,
+		OperationName:  InstallOperation,
+		Status:         status,
+		Created:        1,
+		WorkflowState:  workflow.InProgressState,
+		InstallRequest: &grpc_installer_go.InstallRequest{
+			OrganizationId: "org",
+			RequestId:      requestID,
+		},
+	}
+}
+
+var _ = ginkgo.Describe("Operation store", func() {
+
+	var tempDir string
+
+	ginkgo.BeforeEach(func() {
+		td, err := ioutil.TempDir("", "operation-store")
+		gomega.Expect(err).To(gomega.Succeed())
+		tempDir = td
+	})
+
+	ginkgo.AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	ginkgo.Context("with a journal", func() {
+		ginkgo.It("should reload the stored operations", func() {
+			store, err := NewJournalOperationStore(tempDir)
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(store.Save(sampleRecord("r1", grpc_common_go.OpStatus_INPROGRESS))).To(gomega.Succeed())
+			gomega.Expect(store.Save(sampleRecord("r2", grpc_common_go.OpStatus_INPROGRESS))).To(gomega.Succeed())
+			gomega.Expect(store.Save(sampleRecord("r1", grpc_common_go.OpStatus_SUCCESS))).To(gomega.Succeed())
+			gomega.Expect(store.Remove("r2")).To(gomega.Succeed())
+			gomega.Expect(store.Close()).To(gomega.Succeed())
+
+			reloaded, err := NewJournalOperationStore(tempDir)
+			gomega.Expect(err).To(gomega.Succeed())
+			defer reloaded.Close()
+			records, err := reloaded.List()
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(len(records)).To(gomega.Equal(1))
+			gomega.Expect(records[0].RequestID).To(gomega.Equal("r1"))
+			gomega.Expect(records[0].Status).To(gomega.Equal(grpc_common_go.OpStatus_SUCCESS))
+			gomega.Expect(records[0].InstallRequest).ToNot(gomega.BeNil())
+		})
+
+		ginkgo.It("should skip partially written entries", func() {
+			store, err := NewJournalOperationStore(tempDir)
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(store.Save(sampleRecord("r1", grpc_common_go.OpStatus_SUCCESS))).To(gomega.Succeed())
+			gomega.Expect(store.Close()).To(gomega.Succeed())
+
+			journal, oErr := os.OpenFile(filepath.Join(tempDir, JournalFileName), os.O_APPEND|os.O_WRONLY, 0600)
+			gomega.Expect(oErr).To(gomega.Succeed())
+			_, wErr := journal.WriteString(`{"action":"save","request_id":"r2","rec`)
+			gomega.Expect(wErr).To(gomega.Succeed())
+			journal.Close()
+
+			reloaded, err := NewJournalOperationStore(tempDir)
+			gomega.Expect(err).To(gomega.Succeed())
+			defer reloaded.Close()
+			_, err = reloaded.Get("r1")
+			gomega.Expect(err).To(gomega.Succeed())
+			_, err = reloaded.Get("r2")
+			gomega.Expect(err).ToNot(gomega.Succeed())
+		})
+	})
+
+	ginkgo.Context("when the manager starts", func() {
+		ginkgo.It("should mark interrupted operations as failed and resumable", func() {
+			store := NewMemoryOperationStore()
+			running := sampleRecord("running", grpc_common_go.OpStatus_INPROGRESS)
+			running.InstallRequest.KubeConfigRaw = "kubeconfig"
+			gomega.Expect(store.Save(running)).To(gomega.Succeed())
+			gomega.Expect(store.Save(sampleRecord("done", grpc_common_go.OpStatus_SUCCESS))).To(gomega.Succeed())
+
+			manager := NewManager(cfg.Config{TempPath: tempDir}, store)
+			gomega.Expect(len(manager.ListOperations())).To(gomega.Equal(2))
+
+			restored, err := manager.GetProgress("running")
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(*restored.GetState()).To(gomega.Equal(grpc_common_go.OpStatus_FAILED))
+			gomega.Expect(restored.Resumable).To(gomega.BeTrue())
+			gomega.Expect(restored.ToGRPCOpResponse().Error).ToNot(gomega.BeEmpty())
+
+			done, err := manager.GetProgress("done")
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(*done.GetState()).To(gomega.Equal(grpc_common_go.OpStatus_SUCCESS))
+			gomega.Expect(done.Resumable).To(gomega.BeFalse())
+
+			_, err = manager.ResumeInstall(grpc_installer_go.InstallRequest{RequestId: "done"})
+			gomega.Expect(err).ToNot(gomega.Succeed())
+			_, err = manager.ResumeInstall(grpc_installer_go.InstallRequest{RequestId: "unknown"})
+			gomega.Expect(err).ToNot(gomega.Succeed())
+
+			stored, err := store.Get("running")
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(stored.Status).To(gomega.Equal(grpc_common_go.OpStatus_FAILED))
+			gomega.Expect(stored.Resumable).To(gomega.BeTrue())
+			gomega.Expect(stored.InstallRequest).ToNot(gomega.BeNil())
+			gomega.Expect(stored.InstallRequest.KubeConfigRaw).To(gomega.BeEmpty())
+		})
+
+		ginkgo.It("should restore the template of the operations", func() {
+			templatesDir := filepath.Join(tempDir, "templates")
+			gomega.Expect(os.Mkdir(templatesDir, 0755)).To(gomega.Succeed())
+			gomega.Expect(ioutil.WriteFile(filepath.Join(templatesDir, "install.1.0.0.json"),
+				[]byte(`{"description": "test", "commands": []}`), 0644)).To(gomega.Succeed())
+			store := NewMemoryOperationStore()
+			record := sampleRecord("running", grpc_common_go.OpStatus_INPROGRESS)
+			record.TemplateName = templates.InstallTemplate
+			record.TemplateVersion = "1.0.0"
+			gomega.Expect(store.Save(record)).To(gomega.Succeed())
+
+			manager := NewManager(cfg.Config{TempPath: tempDir, TemplatesPath: templatesDir}, store)
+			gomega.Expect(manager.LoadTemplates()).To(gomega.Succeed())
+			running, err := manager.GetProgress("running")
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(running.TemplateName).To(gomega.Equal(templates.InstallTemplate))
+			gomega.Expect(running.TemplateVersion).To(gomega.Equal("1.0.0"))
+			template, err := manager.operationTemplate(running, "", templates.InstallTemplate)
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(template.Source).To(gomega.Equal(filepath.Join(templatesDir, "install.1.0.0.json")))
+
+			stored, err := store.Get("running")
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(stored.TemplateVersion).To(gomega.Equal("1.0.0"))
+
+			_, err = manager.InstallCluster(grpc_installer_go.InstallRequest{RequestId: "new"}, templates.ParseRef("install:2.0.0"))
+			gomega.Expect(err).ToNot(gomega.Succeed())
+			_, err = manager.GetProgress("new")
+			gomega.Expect(err).ToNot(gomega.Succeed())
+		})
+
+		ginkgo.It("should fail if the configured template is not available", func() {
+			manager := NewManager(cfg.Config{TempPath: tempDir, InstallTemplate: "install:1.0.0"}, NewMemoryOperationStore())
+			gomega.Expect(manager.LoadTemplates()).ToNot(gomega.Succeed())
+		})
+	})
+})
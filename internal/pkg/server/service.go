@@ -55,11 +55,23 @@ func (s *Service) Run() error {
 		log.Fatal().Errs("failed to listen: %v", []error{err})
 	}
 
-	installerManager := installer.NewManager(s.Configuration)
+	store, sErr := installer.NewJournalOperationStore(s.Configuration.TempPath)
+	if sErr != nil {
+		log.Error().Str("error", sErr.DebugReport()).Msg("cannot open the operation store")
+		return sErr
+	}
+	installerManager := installer.NewManager(s.Configuration, store)
+	if tErr := installerManager.LoadTemplates(); tErr != nil {
+		log.Error().Str("error", tErr.DebugReport()).Msg("cannot load the workflow templates")
+		return tErr
+	}
 	installerHandler := installer.NewHandler(installerManager)
 
 	grpcServer := grpc.NewServer()
 	grpc_installer_go.RegisterInstallerServer(grpcServer, installerHandler)
+	installer.RegisterProgressServer(grpcServer, installerHandler)
+	installer.RegisterDryRunServer(grpcServer, installerHandler)
+	installer.RegisterResumeServer(grpcServer, installerHandler)
 
 	// Register reflection service on gRPC server.
 	reflection.Register(grpcServer)
@@ -0,0 +1,355 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the catalog of workflow templates
+//
+// The catalog contains the built-in templates of this file package, and the templates loaded from a directory. The
+// files of the directory are named <name>.<version>.json, e.g. install.1.2.0.json, so a ConfigMap with those keys
+// can be mounted as the templates directory. Files named <name>.<version>.tpl contain fragments that the
+// workflows include by name with the template action or the include function.
+
+package templates
+
+import (
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InstallTemplate is the name of the template used to install a cluster.
+const InstallTemplate = "install"
+
+// UninstallTemplate is the name of the template used to uninstall a cluster.
+const UninstallTemplate = "uninstall"
+
+// BuiltinVersion is the version of the templates compiled in the installer.
+const BuiltinVersion = "builtin"
+
+// BuiltinSource is the source of the templates compiled in the installer.
+const BuiltinSource = "builtin"
+
+// TemplateExtension is the extension of the template files.
+const TemplateExtension = ".json"
+
+// FragmentExtension is the extension of the fragment files.
+const FragmentExtension = ".tpl"
+
+// Ref structure with the name and the optional version that select a template.
+type Ref struct {
+	// Name of the template.
+	Name string
+	// Version of the template. An empty version selects the latest one.
+	Version string
+}
+
+// ParseRef parses a template reference with the name[:version] format.
+func ParseRef(ref string) Ref {
+	parts := strings.SplitN(strings.TrimSpace(ref), ":", 2)
+	if len(parts) == 1 {
+		return Ref{Name: parts[0]}
+	}
+	return Ref{Name: parts[0], Version: parts[1]}
+}
+
+func (r Ref) String() string {
+	if r.Version == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s:%s", r.Name, r.Version)
+}
+
+// Template structure with the content of a named and versioned workflow template.
+type Template struct {
+	// Name of the template.
+	Name string `json:"name"`
+	// Version of the template.
+	Version string `json:"version"`
+	// Source from which the template was loaded.
+	Source string `json:"source"`
+	// Fragment determines if the template is a fragment included by the workflows instead of a workflow.
+	Fragment bool `json:"fragment"`
+	// Content of the template.
+	Content string `json:"-"`
+}
+
+// NewTemplate creates a new Template.
+func NewTemplate(name string, version string, source string, content string) *Template {
+	return &Template{
+		Name:    name,
+		Version: version,
+		Source:  source,
+		Content: content,
+	}
+}
+
+// NewFragment creates a new Template with a fragment.
+func NewFragment(name string, version string, source string, content string) *Template {
+	fragment := NewTemplate(name, version, source, content)
+	fragment.Fragment = true
+	return fragment
+}
+
+// Ref returns the reference that selects this template.
+func (t *Template) Ref() Ref {
+	return Ref{Name: t.Name, Version: t.Version}
+}
+
+// Validate checks that the template is named and versioned properly.
+func (t *Template) Validate() derrors.Error {
+	if t.Name == "" || strings.ContainsAny(t.Name, ".:/\\") {
+		return derrors.NewInvalidArgumentError(errors.InvalidTemplate).WithParams(t.Name, t.Source)
+	}
+	if t.Version == "" || strings.ContainsAny(t.Version, ":/\\") {
+		return derrors.NewInvalidArgumentError(errors.InvalidTemplate).WithParams(t.Ref().String(), t.Source)
+	}
+	if strings.TrimSpace(t.Content) == "" {
+		return derrors.NewInvalidArgumentError(errors.InvalidTemplate).WithParams(t.Ref().String(), t.Source)
+	}
+	return nil
+}
+
+// Catalog structure with the available templates indexed by name and version.
+type Catalog struct {
+	sync.RWMutex
+	templates map[string]map[string]*Template
+}
+
+// NewCatalog creates a new Catalog with the built-in templates.
+func NewCatalog() *Catalog {
+	catalog := &Catalog{
+		templates: make(map[string]map[string]*Template, 0),
+	}
+	catalog.add(NewTemplate(InstallTemplate, BuiltinVersion, BuiltinSource, InstallManagementCluster))
+	catalog.add(NewTemplate(UninstallTemplate, BuiltinVersion, BuiltinSource, UninstallCluster))
+	return catalog
+}
+
+// Add a new template to the catalog.
+//
+//	params:
+//	  template The template to be added.
+//	returns:
+//	  An error if the template is not valid or the catalog already contains that version.
+func (c *Catalog) Add(template *Template) derrors.Error {
+	if err := template.Validate(); err != nil {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	if _, exists := c.templates[template.Name][template.Version]; exists {
+		return derrors.NewInvalidArgumentError(errors.DuplicatedTemplate).WithParams(template.Ref().String(), template.Source)
+	}
+	// All the versions of a name are either workflows or fragments.
+	for _, other := range c.templates[template.Name] {
+		if other.Fragment != template.Fragment {
+			return derrors.NewInvalidArgumentError(errors.InvalidTemplate).WithParams(template.Ref().String(), template.Source)
+		}
+	}
+	c.add(template)
+	return nil
+}
+
+func (c *Catalog) add(template *Template) {
+	if _, exists := c.templates[template.Name]; !exists {
+		c.templates[template.Name] = make(map[string]*Template, 0)
+	}
+	c.templates[template.Name][template.Version] = template
+}
+
+// LoadFiles adds the templates contained in a set of files to the catalog.
+//
+//	params:
+//	  files The content of the files indexed by file name, e.g. the data of a ConfigMap.
+//	  source The source of the files.
+//	returns:
+//	  An error if a template file is not named properly or the template cannot be added.
+func (c *Catalog) LoadFiles(files map[string]string, source string) derrors.Error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, fileName := range names {
+		extension := path.Ext(fileName)
+		if extension != TemplateExtension && extension != FragmentExtension {
+			log.Debug().Str("file", fileName).Str("source", source).Msg("ignoring file without the template extension")
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSuffix(fileName, extension), ".", 2)
+		if len(parts) != 2 {
+			return derrors.NewInvalidArgumentError(errors.InvalidTemplate).WithParams(fileName, source)
+		}
+		template := NewTemplate(parts[0], parts[1], path.Join(source, fileName), files[fileName])
+		template.Fragment = extension == FragmentExtension
+		if err := c.Add(template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadDirectory adds the templates of a directory to the catalog. Hidden files are ignored, so a mounted ConfigMap
+// can be used as the directory.
+//
+//	params:
+//	  dir The directory that contains the template files.
+//	returns:
+//	  An error if the directory cannot be read, or a template cannot be added.
+func (c *Catalog) LoadDirectory(dir string) derrors.Error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return derrors.AsError(err, errors.IOError)
+	}
+	files := make(map[string]string, 0)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		filePath := path.Join(dir, entry.Name())
+		// The files of a mounted ConfigMap are symbolic links.
+		info, err := os.Stat(filePath)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return derrors.AsError(err, errors.IOError)
+		}
+		files[entry.Name()] = string(content)
+	}
+	return c.LoadFiles(files, dir)
+}
+
+// Get retrieves a template from the catalog.
+//
+//	params:
+//	  ref The name and the version of the template. If the version is empty, the latest loaded version is
+//	    returned, or the built-in one if no other version has been loaded.
+//	returns:
+//	  The template.
+//	  An error if the template is not found or it is a fragment.
+func (c *Catalog) Get(ref Ref) (*Template, derrors.Error) {
+	c.RLock()
+	defer c.RUnlock()
+	versions, exists := c.templates[ref.Name]
+	if !exists || len(versions) == 0 || isFragment(versions) {
+		return nil, derrors.NewNotFoundError(errors.TemplateNotFound).WithParams(ref.String())
+	}
+	if ref.Version != "" {
+		template, exists := versions[ref.Version]
+		if !exists {
+			return nil, derrors.NewNotFoundError(errors.TemplateNotFound).WithParams(ref.String())
+		}
+		return template, nil
+	}
+	var latest *Template
+	for _, template := range versions {
+		if latest == nil || isNewer(template.Version, latest.Version) {
+			latest = template
+		}
+	}
+	return latest, nil
+}
+
+// Fragments retrieves the content of the latest version of each fragment of the catalog.
+//
+//	returns:
+//	  The content of the fragments indexed by name.
+func (c *Catalog) Fragments() map[string]string {
+	c.RLock()
+	defer c.RUnlock()
+	result := make(map[string]string, 0)
+	for name, versions := range c.templates {
+		var latest *Template
+		for _, template := range versions {
+			if template.Fragment && (latest == nil || isNewer(template.Version, latest.Version)) {
+				latest = template
+			}
+		}
+		if latest != nil {
+			result[name] = latest.Content
+		}
+	}
+	return result
+}
+
+// List retrieves the templates of the catalog sorted by name and version.
+func (c *Catalog) List() []Template {
+	c.RLock()
+	defer c.RUnlock()
+	result := make([]Template, 0)
+	for _, versions := range c.templates {
+		for _, template := range versions {
+			result = append(result, *template)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return isNewer(result[j].Version, result[i].Version)
+	})
+	return result
+}
+
+// isFragment checks if the versions of a template are fragments.
+func isFragment(versions map[string]*Template) bool {
+	for _, template := range versions {
+		return template.Fragment
+	}
+	return false
+}
+
+// isNewer checks if a version is newer than another one. The built-in version is older than any other version,
+// and the numeric parts of the versions are compared as numbers, e.g. 1.10.0 is newer than v1.9.
+func isNewer(version string, other string) bool {
+	if version == other || version == BuiltinVersion {
+		return false
+	}
+	if other == BuiltinVersion {
+		return true
+	}
+	parts := strings.FieldsFunc(strings.TrimPrefix(version, "v"), isVersionSeparator)
+	otherParts := strings.FieldsFunc(strings.TrimPrefix(other, "v"), isVersionSeparator)
+	for i := 0; i < len(parts) && i < len(otherParts); i++ {
+		if parts[i] == otherParts[i] {
+			continue
+		}
+		number, err := strconv.Atoi(parts[i])
+		otherNumber, otherErr := strconv.Atoi(otherParts[i])
+		if err == nil && otherErr == nil {
+			return number > otherNumber
+		}
+		return parts[i] > otherParts[i]
+	}
+	if len(parts) != len(otherParts) {
+		return len(parts) > len(otherParts)
+	}
+	return version > other
+}
+
+func isVersionSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '+'
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package templates
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const testTemplate = `{"description": "Test template", "commands": [{"type":"sync", "name": "logger", "msg": "{{$.AppCluster}}"}]}`
+
+var _ = ginkgo.Describe("Catalog", func() {
+
+	ginkgo.It("should parse template references", func() {
+		gomega.Expect(ParseRef("install")).To(gomega.Equal(Ref{Name: "install"}))
+		gomega.Expect(ParseRef("install:1.2.0")).To(gomega.Equal(Ref{Name: "install", Version: "1.2.0"}))
+		gomega.Expect(ParseRef("install:1.2.0").String()).To(gomega.Equal("install:1.2.0"))
+	})
+
+	ginkgo.It("should compare versions", func() {
+		gomega.Expect(isNewer("1.10.0", "1.9.0")).To(gomega.BeTrue())
+		gomega.Expect(isNewer("v2", "1.9.0")).To(gomega.BeTrue())
+		gomega.Expect(isNewer("1.0.0", BuiltinVersion)).To(gomega.BeTrue())
+		gomega.Expect(isNewer(BuiltinVersion, "0.0.1")).To(gomega.BeFalse())
+		gomega.Expect(isNewer("1.0.0", "1.0.0")).To(gomega.BeFalse())
+	})
+
+	ginkgo.Context("with the built-in templates", func() {
+		ginkgo.It("should return them as the latest version", func() {
+			catalog := NewCatalog()
+			install, err := catalog.Get(Ref{Name: InstallTemplate})
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(install.Version).To(gomega.Equal(BuiltinVersion))
+			gomega.Expect(install.Content).To(gomega.Equal(InstallManagementCluster))
+			uninstall, err := catalog.Get(Ref{Name: UninstallTemplate, Version: BuiltinVersion})
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(uninstall.Content).To(gomega.Equal(UninstallCluster))
+		})
+		ginkgo.It("should fail on unknown templates", func() {
+			catalog := NewCatalog()
+			_, err := catalog.Get(Ref{Name: "upgrade"})
+			gomega.Expect(err).ToNot(gomega.Succeed())
+			_, err = catalog.Get(Ref{Name: InstallTemplate, Version: "1.0.0"})
+			gomega.Expect(err).ToNot(gomega.Succeed())
+		})
+	})
+
+	ginkgo.Context("loading files", func() {
+		ginkgo.It("should select the latest version by default", func() {
+			catalog := NewCatalog()
+			err := catalog.LoadFiles(map[string]string{
+				"install.1.9.0.json":  testTemplate,
+				"install.1.10.0.json": testTemplate,
+				"README.md":           "ignored",
+			}, "test")
+			gomega.Expect(err).To(gomega.Succeed())
+			latest, err := catalog.Get(Ref{Name: InstallTemplate})
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(latest.Version).To(gomega.Equal("1.10.0"))
+			gomega.Expect(latest.Source).To(gomega.Equal("test/install.1.10.0.json"))
+			previous, err := catalog.Get(ParseRef("install:1.9.0"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(previous.Version).To(gomega.Equal("1.9.0"))
+			builtin, err := catalog.Get(ParseRef("install:builtin"))
+			gomega.Expect(err).To(gomega.Succeed())
+			gomega.Expect(builtin.Source).To(gomega.Equal(BuiltinSource))
+
+			list := catalog.List()
+			gomega.Expect(len(list)).To(gomega.Equal(4))
+			gomega.Expect(list[0].Ref()).To(gomega.Equal(Ref{Name: InstallTemplate, Version: BuiltinVersion}))
+			gomega.Expect(list[2].Ref()).To(gomega.Equal(Ref{Name: InstallTemplate, Version: "1.10.0"}))
+		})
+		ginkgo.It("should reject invalid or duplicated templates", func() {
+			catalog := NewCatalog()
+			gomega.Expect(catalog.LoadFiles(map[string]string{"install.json": testTemplate}, "test")).ToNot(gomega.Succeed())
+			gomega.Expect(catalog.LoadFiles(map[string]string{"install.1.0.json": " "}, "test")).ToNot(gomega.Succeed())
+			gomega.Expect(catalog.LoadFiles(map[string]string{"install.builtin.json": testTemplate}, "test")).ToNot(gomega.Succeed())
+		})
+		ginkgo.It("should provide the latest version of the fragments", func() {
+			catalog := NewCatalog()
+			err := catalog.LoadFiles(map[string]string{
+				"install.1.0.0.json": `{"description": "Test", "commands": [{{template "message" $}}]}`,
+				"message.1.0.0.tpl":  `{"type":"sync", "name": "logger", "msg": "old"}`,
+				"message.1.1.0.tpl":  `{"type":"sync", "name": "logger", "msg": "{{$.AppCluster}}"}`,
+			}, "test")
+			gomega.Expect(err).To(gomega.Succeed())
+			fragments := catalog.Fragments()
+			gomega.Expect(len(fragments)).To(gomega.Equal(1))
+			gomega.Expect(fragments["message"]).To(gomega.ContainSubstring("AppCluster"))
+			_, err = catalog.Get(Ref{Name: "message"})
+			gomega.Expect(err).ToNot(gomega.Succeed())
+			gomega.Expect(catalog.LoadFiles(map[string]string{"install.2.0.0.tpl": testTemplate}, "test")).ToNot(gomega.Succeed())
+
+			template, err := catalog.Get(Ref{Name: InstallTemplate})
+			gomega.Expect(err).To(gomega.Succeed())
+			parser := workflow.NewParser()
+			parser.SetFragments(fragments)
+			wf, pErr := parser.ParseWorkflow("test", template.Content, "test", *workflow.GetTestInstallParameters(1, true))
+			gomega.Expect(pErr).To(gomega.Succeed())
+			gomega.Expect(len(wf.Commands)).To(gomega.Equal(1))
+		})
+		ginkgo.It("should load the templates of a directory", func() {
+			dir, err := ioutil.TempDir("", "templates")
+			gomega.Expect(err).To(gomega.Succeed())
+			defer os.RemoveAll(dir)
+			// Layout of a mounted ConfigMap.
+			gomega.Expect(os.Mkdir(path.Join(dir, "..data"), 0755)).To(gomega.Succeed())
+			gomega.Expect(ioutil.WriteFile(path.Join(dir, "..data", "install.2.0.0.json"), []byte(testTemplate), 0644)).To(gomega.Succeed())
+			gomega.Expect(os.Symlink(path.Join("..data", "install.2.0.0.json"), path.Join(dir, "install.2.0.0.json"))).To(gomega.Succeed())
+
+			catalog := NewCatalog()
+			gomega.Expect(catalog.LoadDirectory(dir)).To(gomega.Succeed())
+			template, lErr := catalog.Get(Ref{Name: InstallTemplate})
+			gomega.Expect(lErr).To(gomega.Succeed())
+			gomega.Expect(template.Version).To(gomega.Equal("2.0.0"))
+
+			params := workflow.GetTestInstallParameters(1, true)
+			wf, pErr := workflow.NewParser().ParseWorkflow("test", template.Content, "test", *params)
+			gomega.Expect(pErr).To(gomega.Succeed())
+			gomega.Expect(len(wf.Commands)).To(gomega.Equal(1))
+		})
+	})
+
+})
@@ -17,7 +17,84 @@
 
 package templates
 
+// InstallManagementCluster template with the commands required to install a management or an application cluster.
 const InstallManagementCluster = `
+{{define "undoNalejNamespace"}}
+	"undo":{"type":"sync", "name":"deleteNalejNamespace",
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"fail_if_not_exists":false
+	}
+{{end}}
+
+{{define "staticIPService"}}
+	{"type":"sync", "name":"{{.name}}", "id":"{{.id}}", "dependsOn":["mngtConfig"],
+		"kubeConfigPath":"{{.root.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"platform_type":"{{.root.InstallRequest.TargetPlatform}}",
+		"use_static_ip":{{.root.InstallRequest.StaticIpAddresses.UseStaticIp}},
+		"static_ip_address":"{{.address}}"
+	}
+{{end}}
+
+{{define "appClusterConfig"}}
+	{"type":"sync", "name":"createClusterConfig",
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"organization_id":"{{$.InstallRequest.OrganizationId}}",
+		"cluster_id":"{{$.InstallRequest.ClusterId}}",
+		"management_public_host":"{{$.ManagementClusterHost}}",
+		"management_public_port":"{{$.ManagementClusterPort}}",
+		"cluster_public_hostname":"{{$.InstallRequest.Hostname}}",
+		"dns_public_host":"{{$.DNSClusterHost}}",
+		"dns_public_port":"{{$.DNSClusterPort}}",
+		"platform_type":"{{$.InstallRequest.TargetPlatform}}",
+		{{template "undoNalejNamespace" $}}
+	},
+	{"type":"sync", "name":"addClusterUser",
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"organization_id":"{{$.InstallRequest.OrganizationId}}",
+		"cluster_id":"{{$.InstallRequest.ClusterId}}",
+		"user_manager_address":"user-manager.nalej:8920"
+	},
+	{"type":"sync", "name":"createOpaqueSecret",
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"secret_name":"authx-secret",
+		"secret_key":"secret",
+		"load_from_path":false,
+		"secret_value":"{{$.AuthSecret}}"
+	},
+	{"type":"sync", "name":"createOpaqueSecret",
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"secret_name":"ca-certificate",
+		"secret_key":"ca.crt",
+		"load_from_path":true,
+		"secret_value_from_path":"{{$.CACertPath}}"
+	},
+{{end}}
+
+{{define "managementClusterConfig"}}
+	{"type":"sync", "name":"createManagementConfig", "id":"mngtConfig",
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"public_host":"{{$.ManagementClusterHost}}",
+		"public_port":"{{$.ManagementClusterPort}}",
+		"dns_host":"{{$.DNSClusterHost}}",
+		"dns_port":"{{$.DNSClusterPort}}",
+		"platform_type":"{{$.InstallRequest.TargetPlatform}}",
+		"environment":"{{$.TargetEnvironment}}",
+		{{template "undoNalejNamespace" $}}
+	},
+	{{template "staticIPService" dict "root" $ "name" "installMngtDNS" "id" "mngtDNS" "address" $.InstallRequest.StaticIpAddresses.Dns}},
+	{"type":"sync", "name":"createCACert", "id":"caCert", "dependsOn":["mngtConfig"],
+		"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+		"applyMode":"update",
+		"public_host":"{{$.ManagementClusterHost}}"
+	},
+{{end}}
+
 {
 	"description": "Install management cluster",
 	"commands": [
@@ -46,60 +123,14 @@ const InstallManagementCluster = `
             },
         {{end}}
 		{{if $.AppCluster }}
-			{"type":"sync", "name":"createClusterConfig",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"organization_id":"{{$.InstallRequest.OrganizationId}}",
-				"cluster_id":"{{$.InstallRequest.ClusterId}}",
-				"management_public_host":"{{$.ManagementClusterHost}}",
-				"management_public_port":"{{$.ManagementClusterPort}}",
-				"cluster_public_hostname":"{{$.InstallRequest.Hostname}}",
-				"dns_public_host":"{{$.DNSClusterHost}}",
-				"dns_public_port":"{{$.DNSClusterPort}}",
-				"platform_type":"{{$.InstallRequest.TargetPlatform}}"
-			},
-			{"type":"sync", "name":"addClusterUser",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"organization_id":"{{$.InstallRequest.OrganizationId}}",
-				"cluster_id":"{{$.InstallRequest.ClusterId}}",
-				"user_manager_address":"user-manager.nalej:8920"
-			},
-			{"type":"sync", "name":"createOpaqueSecret",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"secret_name":"authx-secret",
-				"secret_key":"secret",
-				"load_from_path":false,
-				"secret_value":"{{$.AuthSecret}}"
-			},
-			{"type":"sync", "name":"createOpaqueSecret",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"secret_name":"ca-certificate",
-				"secret_key":"ca.crt",
-				"load_from_path":true,
-				"secret_value_from_path":"{{$.CACertPath}}"
-			},
+			{{template "appClusterConfig" $}}
 		{{else}}
-			{"type":"sync", "name":"createManagementConfig",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"public_host":"{{$.ManagementClusterHost}}",
-				"public_port":"{{$.ManagementClusterPort}}",
-				"dns_host":"{{$.DNSClusterHost}}",
-				"dns_port":"{{$.DNSClusterPort}}",
-				"platform_type":"{{$.InstallRequest.TargetPlatform}}",
-				"environment":"{{$.TargetEnvironment}}"
-			},
-			{"type":"sync", "name":"installMngtDNS",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"platform_type":"{{$.InstallRequest.TargetPlatform}}",
-				"use_static_ip":{{$.InstallRequest.StaticIpAddresses.UseStaticIp}},
-				"static_ip_address":"{{$.InstallRequest.StaticIpAddresses.Dns}}"
-			},
-			{"type":"sync", "name":"createCACert",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"public_host":"{{$.ManagementClusterHost}}"
-			},
+			{{template "managementClusterConfig" $}}
 		{{end}}
 		{"type":"sync", "name":"installIngress",
+				{{if not $.AppCluster }}"id":"ingress", "dependsOn":["mngtDNS", "caCert"],{{end}}
 				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+				"applyMode":"update",
 				"platform_type":"{{$.InstallRequest.TargetPlatform}}",
 				"management_public_host":"{{$.InstallRequest.Hostname}}",
 				"on_management_cluster":{{ not $.AppCluster}},
@@ -108,32 +139,47 @@ const InstallManagementCluster = `
                 "network_mode":"{{$.NetworkConfig.NetworkingMode}}"
 		},
 		{{if not $.AppCluster }}
-			{"type":"sync", "name":"installExtDNS",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"platform_type":"{{$.InstallRequest.TargetPlatform}}",
-				"use_static_ip":{{$.InstallRequest.StaticIpAddresses.UseStaticIp}},
-				"static_ip_address":"{{$.InstallRequest.StaticIpAddresses.CorednsExt}}"
-			},
-			{"type":"sync", "name":"installVpnServerLB",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"platform_type":"{{$.InstallRequest.TargetPlatform}}",
-				"use_static_ip":{{$.InstallRequest.StaticIpAddresses.UseStaticIp}},
-				"static_ip_address":"{{$.InstallRequest.StaticIpAddresses.VpnServer}}"
-			},
+			{{template "staticIPService" dict "root" $ "name" "installExtDNS" "id" "extDNS" "address" $.InstallRequest.StaticIpAddresses.CorednsExt}},
+			{{template "staticIPService" dict "root" $ "name" "installVpnServerLB" "id" "vpnLB" "address" $.InstallRequest.StaticIpAddresses.VpnServer}},
 		{{end}}
 		{"type":"sync", "name": "launchComponents",
+			{{if not $.AppCluster }}"dependsOn":["ingress", "extDNS", "vpnLB"],{{end}}
 			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+			"applyMode":"update",
 			"namespaces":["nalej", "ingress-nginx"],
 			"componentsDir":"{{$.Paths.ComponentsPath}}",
 			"platform_type":"{{$.InstallRequest.TargetPlatform}}",
 			"environment":"{{$.TargetEnvironment}}"
+		},
+		{"type":"sync", "name":"writeInventory",
+			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}"
 		}
 	]
 }
 `
 
-// UninstallCluster template with the commands required to uninstall the Nalej platform
+// UninstallCluster template with the commands required to uninstall the Nalej platform. The cluster roles and
+// their bindings can be replaced with the clusterRoles and clusterRoleBindings lists of the parameters, an empty
+// list skips their deletion.
 const UninstallCluster = `
+{{define "deleteResources"}}
+	{"type":"sync", "name":"deleteResources",
+		"kubeConfigPath":"{{.root.Credentials.KubeConfigPath}}",
+		{{with .group}}"group":"{{.}}", {{end}}{{with .version}}"version":"{{.}}", {{end}}{{with .resource}}"resource":"{{.}}", {{end}}{{with .kind}}"kind":"{{.}}", {{end}}
+		{{with .namespace}}"namespace":"{{.}}",{{end}}
+		"names":[{{joinStringArray .names}}],
+		{{with .propagation}}"propagation_policy":"{{.}}",{{end}}
+		"fail_if_not_exists":false
+	}
+{{end}}
+
+{{$clusterRoles := list "system:nginx-ingress"}}
+{{$clusterRoleBindings := list "system:nginx-ingress"}}
+{{if not $.AppCluster }}
+	{{$clusterRoles = list "system:nginx-ingress" "kube-state-metrics" "node-exporter" "prometheus" "filebeat"}}
+	{{$clusterRoleBindings = list "system:nginx-ingress" "deployment-manager" "kube-state-metrics" "node-exporter" "prometheus" "filebeat"}}
+{{end}}
+
 {
 	"description": "Uninstall management cluster",
 	"commands": [
@@ -143,6 +189,10 @@ const UninstallCluster = `
 			"minVersion":"1.11"
 		},
 		{"type":"sync", "name": "logger", "msg": "Uninstalling components"},
+		{"type":"sync", "name":"deleteOwnedResources",
+			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
+			"propagation_policy":"Background"
+		},
 		{"type":"sync", "name":"deleteServiceAccount",
 			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
 			"namespace":"kube-system",
@@ -158,131 +208,14 @@ const UninstallCluster = `
 			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
 			"fail_if_not_exists":false
 		},
-		{"type":"sync", "name":"deleteClusterRoleBinding",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"role_binding_name":"system:nginx-ingress",
-			"fail_if_not_exists":false
-		},
-
-		{{if not $.AppCluster }}
-			{"type":"sync", "name":"deleteClusterRoleBinding",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_binding_name":"deployment-manager",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRoleBinding",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_binding_name":"kube-state-metrics",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRoleBinding",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_binding_name":"node-exporter",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRoleBinding",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_binding_name":"prometheus",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRoleBinding",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_binding_name":"filebeat",
-				"fail_if_not_exists":false
-			},
-		{{end}}
-		{"type":"sync", "name":"deleteClusterRole",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"role_name":"system:nginx-ingress",
-			"fail_if_not_exists":false
-		},
-		{{if not $.AppCluster }}
-			{"type":"sync", "name":"deleteClusterRole",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_name":"kube-state-metrics",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRole",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_name":"node-exporter",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRole",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_name":"prometheus",
-				"fail_if_not_exists":false
-			},
-			{"type":"sync", "name":"deleteClusterRole",
-				"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-				"role_name":"filebeat",
-				"fail_if_not_exists":false
-			},
-		{{end}}
-		{"type":"sync", "name":"deleteRole",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"role_name":"system::nginx-ingress-role",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteRoleBinding",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"role_name":"system::nginx-ingress-role-binding",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteConfigMap",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"config_map_name":"ingress-controller-leader-nginx",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteConfigMap",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"config_map_name":"nginx-load-balancer-conf",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteConfigMap",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"config_map_name":"tcp-services",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteConfigMap",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"config_map_name":"udp-services",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteService",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"service_name":"default-http-backend",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteService",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"service_name":"nginx-ingress-controller",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteDeployment",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"deployment_name":"default-http-backend",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deleteDeployment",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"namespace":"kube-system",
-			"deployment_name":"nginx-ingress-controller",
-			"fail_if_not_exists":false
-		},
-		{"type":"sync", "name":"deletePodSecurityPolicy",
-			"kubeConfigPath":"{{$.Credentials.KubeConfigPath}}",
-			"policy_name":"node-exporter",
-			"fail_if_not_exists":false
-		}
+		{{with $.List "clusterRoleBindings" $clusterRoleBindings}}{{template "deleteResources" dict "root" $ "group" "rbac.authorization.k8s.io" "version" "v1" "resource" "clusterrolebindings" "names" .}},{{end}}
+		{{with $.List "clusterRoles" $clusterRoles}}{{template "deleteResources" dict "root" $ "group" "rbac.authorization.k8s.io" "version" "v1" "resource" "clusterroles" "names" .}},{{end}}
+		{{template "deleteResources" dict "root" $ "group" "rbac.authorization.k8s.io" "version" "v1" "resource" "roles" "namespace" "kube-system" "names" (list "system::nginx-ingress-role")}},
+		{{template "deleteResources" dict "root" $ "group" "rbac.authorization.k8s.io" "version" "v1" "resource" "rolebindings" "namespace" "kube-system" "names" (list "system::nginx-ingress-role-binding")}},
+		{{template "deleteResources" dict "root" $ "version" "v1" "resource" "configmaps" "namespace" "kube-system" "names" (list "ingress-controller-leader-nginx" "nginx-load-balancer-conf" "tcp-services" "udp-services")}},
+		{{template "deleteResources" dict "root" $ "version" "v1" "resource" "services" "namespace" "kube-system" "names" (list "default-http-backend" "nginx-ingress-controller")}},
+		{{template "deleteResources" dict "root" $ "group" "apps" "version" "v1" "resource" "deployments" "namespace" "kube-system" "names" (list "default-http-backend" "nginx-ingress-controller") "propagation" "Foreground"}},
+		{{template "deleteResources" dict "root" $ "group" "policy" "kind" "PodSecurityPolicy" "names" (list "node-exporter")}}
 	]
 }
 `
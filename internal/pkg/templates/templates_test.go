@@ -20,6 +20,7 @@ package templates
 import (
 	"github.com/nalej/grpc-installer-go"
 	"github.com/nalej/installer/internal/pkg/workflow"
+	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
 )
@@ -83,5 +84,34 @@ var _ = ginkgo.Describe("Templates", func() {
 			gomega.Expect(err).To(gomega.Succeed())
 			gomega.Expect(workflow).ShouldNot(gomega.BeNil())
 		})
+		ginkgo.It("should delete the cluster roles of the parameters", func() {
+			params := workflow.GetTestUninstallParameters(false)
+			params.Lists = map[string][]string{"clusterRoles": {"custom-role"}}
+			workflow, err := parser.ParseWorkflow("test", UninstallCluster, "UninstallManagement", *params)
+			gomega.Expect(err).To(gomega.Succeed())
+			deleted := make(map[string][]string, 0)
+			for _, cmd := range workflow.Commands {
+				if toDelete, ok := cmd.(*k8s.DeleteResources); ok {
+					deleted[toDelete.Resource] = toDelete.Names
+				}
+			}
+			gomega.Expect(deleted["clusterroles"]).To(gomega.Equal([]string{"custom-role"}))
+			gomega.Expect(deleted["clusterrolebindings"]).To(gomega.ContainElement("deployment-manager"))
+			gomega.Expect(deleted["configmaps"]).To(gomega.HaveLen(4))
+		})
+		ginkgo.It("should skip the cluster roles if the parameters have an empty list", func() {
+			params := workflow.GetTestUninstallParameters(false)
+			params.Lists = map[string][]string{"clusterRoles": {}}
+			workflow, err := parser.ParseWorkflow("test", UninstallCluster, "UninstallManagement", *params)
+			gomega.Expect(err).To(gomega.Succeed())
+			deleted := make(map[string][]string, 0)
+			for _, cmd := range workflow.Commands {
+				if toDelete, ok := cmd.(*k8s.DeleteResources); ok {
+					deleted[toDelete.Resource] = toDelete.Names
+				}
+			}
+			gomega.Expect(deleted).ToNot(gomega.HaveKey("clusterroles"))
+			gomega.Expect(deleted["clusterrolebindings"]).To(gomega.ContainElement("deployment-manager"))
+		})
 	})
 })
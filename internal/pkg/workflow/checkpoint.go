@@ -0,0 +1,47 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package workflow
+
+import (
+	"time"
+)
+
+// Checkpoint records a top level command of a workflow that has been successfully executed.
+type Checkpoint struct {
+	// Index of the command in the workflow.
+	Index int `json:"index"`
+	// CommandID with the identifier of the command.
+	CommandID string `json:"commandId"`
+	// Name of the command, used to check that a restored checkpoint matches the workflow.
+	Name string `json:"name"`
+	// Output produced by the command.
+	Output string `json:"output,omitempty"`
+	// Timestamp when the command finished.
+	Timestamp int64 `json:"timestamp"`
+}
+
+// NewCheckpoint creates a checkpoint for a finished command.
+func NewCheckpoint(index int, commandID string, name string, output string) *Checkpoint {
+	return &Checkpoint{
+		Index:     index,
+		CommandID: commandID,
+		Name:      name,
+		Output:    output,
+		Timestamp: time.Now().Unix(),
+	}
+}
@@ -23,6 +23,7 @@
 package async
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -57,7 +58,7 @@ func NewFailFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 // Run the current command.
 //   returns:
 //     An error if the command execution fails
-func (f *Fail) Run(workflowID string) derrors.Error {
+func (f *Fail) Run(ctx context.Context, workflowID string) derrors.Error {
 	go f.LogAndFail(workflowID)
 	return nil
 }
@@ -23,6 +23,7 @@
 package async
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"strconv"
@@ -59,17 +60,22 @@ func NewSleepFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 // Run the current command.
 //   returns:
 //     An error if the command execution fails
-func (s *Sleep) Run(workflowID string) derrors.Error {
-	go s.sleepAndNotify(workflowID)
+func (s *Sleep) Run(ctx context.Context, workflowID string) derrors.Error {
+	go s.sleepAndNotify(ctx, workflowID)
 	return nil
 }
 
-func (s *Sleep) sleepAndNotify(workflowID string) {
+func (s *Sleep) sleepAndNotify(ctx context.Context, workflowID string) {
 	t, _ := strconv.Atoi(s.Time)
 	d := time.Duration(t)
 	cmdHandler := handler.GetCommandHandler()
 	cmdHandler.AddLogEntry(s.CommandID, "Asynchronous sleep command")
-	time.Sleep(time.Second * d)
+	select {
+	case <-ctx.Done():
+		cmdHandler.FinishCommand(s.CommandID, nil, entities.ContextError(ctx))
+		return
+	case <-time.After(time.Second * d):
+	}
 	result := entities.NewCommandResult(true, "Slept for "+s.Time, nil)
 	cmdHandler.FinishCommand(s.CommandID, result, nil)
 }
@@ -16,6 +16,9 @@
  */
 
 // This file contains the command parsing facilities to avoid import cycles.
+//
+// The commands are resolved through the registry of commands. The packages of the installer commands are imported
+// so they register their commands before any workflow is parsed.
 
 package commands
 
@@ -23,23 +26,32 @@ import (
 	"encoding/json"
 	"github.com/nalej/derrors"
 	"github.com/nalej/installer/internal/pkg/errors"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/async"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/sync"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/istio"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s/ingress"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/rke"
-	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/zerotier"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/async"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync/istio"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s/ingress"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync/plugin"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync/rke"
+	_ "github.com/nalej/installer/internal/pkg/workflow/commands/sync/zerotier"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"time"
 )
 
 // CmdParser structure for the command parsing.
 type CmdParser struct {
+	// registry with the commands that can be parsed.
+	registry *entities.CommandRegistry
 }
 
-// NewCmdParser creates a new command parser.
+// NewCmdParser creates a new command parser with the commands of the DefaultRegistry.
 func NewCmdParser() *CmdParser {
-	return &CmdParser{}
+	return NewCmdParserWithRegistry(entities.DefaultRegistry)
+}
+
+// NewCmdParserWithRegistry creates a new command parser with the commands of a given registry.
+func NewCmdParserWithRegistry(registry *entities.CommandRegistry) *CmdParser {
+	return &CmdParser{registry: registry}
 }
 
 // ParseCommand extracts a command from a raw JSON message.
@@ -52,118 +64,83 @@ func (cp *CmdParser) ParseCommand(raw []byte) (*entities.Command, derrors.Error)
 	if err != nil {
 		return nil, err
 	}
+	policy, err := cp.parseRetryPolicy(gc, raw)
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		(*cmd).SetRetryPolicy(policy)
+	}
+	timeout, err := cp.parseTimeout(gc, raw)
+	if err != nil {
+		return nil, err
+	}
+	(*cmd).SetTimeout(timeout)
 	return cmd, nil
 }
 
-func (cp *CmdParser) parseCommand(generic entities.GenericCommand, raw []byte) (*entities.Command, derrors.Error) {
-	switch generic.CommandType {
-	case entities.SyncCommandType:
-		return cp.parseSyncCommand(generic, raw)
-	case entities.AsyncCommandType:
-		return cp.parseAsyncCommand(generic, raw)
-	default:
-		return nil, derrors.NewInvalidArgumentError(errors.UnsupportedCommandType).WithParams(generic)
+// timeoutFromJSON structure with the optional timeout of any command.
+type timeoutFromJSON struct {
+	Timeout string `json:"timeout"`
+}
+
+// parseTimeout extracts the maximum duration of a command. It returns zero if the command does not define a timeout.
+func (cp *CmdParser) parseTimeout(generic entities.GenericCommand, raw []byte) (time.Duration, derrors.Error) {
+	var tfj timeoutFromJSON
+	if err := json.Unmarshal(raw, &tfj); err != nil {
+		return 0, derrors.NewInvalidArgumentError(errors.InvalidTimeout, err).WithParams(generic.CommandName)
 	}
+	if tfj.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(tfj.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, derrors.NewInvalidArgumentError(errors.InvalidTimeout).WithParams(generic.CommandName, tfj.Timeout)
+	}
+	return timeout, nil
 }
 
-func (cp *CmdParser) parseSyncCommand(generic entities.GenericCommand, raw []byte) (*entities.Command, derrors.Error) {
-	switch generic.CommandName {
-	case entities.Exec:
-		return sync.NewExecFromJSON(raw)
-	case entities.SCP:
-		return sync.NewSCPFromJSON(raw)
-	case entities.SSH:
-		return sync.NewSSHFromJSON(raw)
-	case entities.Logger:
-		return sync.NewLoggerFromJSON(raw)
-	case entities.Sleep:
-		return sync.NewSleepFromJSON(raw)
-	case entities.Fail:
-		return sync.NewFailFromJSON(raw)
-	case entities.ParallelCmd:
-		return NewParallelFromJSON(raw)
-	case entities.GroupCmd:
-		return NewGroupFromJSON(raw)
-	case entities.TryCmd:
-		return NewTryFromJSON(raw)
-	case entities.ProcessCheck:
-		return sync.NewProcessCheckFromJSON(raw)
-	case entities.RKEInstall:
-		return rke.NewRKEInstallFromJSON(raw)
-	case entities.RKERemove:
-		return rke.NewRKERemoveFromJSON(raw)
-	case entities.CheckAsset:
-		return sync.NewCheckAssetFromJSON(raw)
-	case entities.LaunchComponents:
-		return k8s.NewLaunchComponentsFromJSON(raw)
-	case entities.CheckRequirements:
-		return k8s.NewCheckRequirementsFromJSON(raw)
-	case entities.CreateClusterConfig:
-		return k8s.NewCreateClusterConfigFromJSON(raw)
-	case entities.CreateManagementConfig:
-		return k8s.NewCreateManagementConfigFromJSON(raw)
-	case entities.UpdateCoreDNS:
-		return k8s.NewUpdateCoreDNSFromJSON(raw)
-	case entities.UpdateKubeDNS:
-		return k8s.NewUpdateKubeDNSFromJSON(raw)
-	case entities.CreateRegistrySecrets:
-		return k8s.NewCreateRegistrySecretsFromJSON(raw)
-	case entities.AddClusterUser:
-		return k8s.NewAddClusterUserFromJSON(raw)
-	case entities.InstallIngress:
-		return ingress.NewInstallIngressFromJSON(raw)
-	case entities.InstallMngtDNS:
-		return ingress.NewInstallMngtDNSFromJSON(raw)
-	case entities.InstallZtPlanetLB:
-		return ingress.NewInstallZtPlanetLBFromJSON(raw)
-	case entities.InstallVpnServerLB:
-		return ingress.NewInstallVpnServerLBFromJSON(raw)
-	case entities.CreateZTPlanetFiles:
-		return zerotier.NewCreateZTPlanetFilesFromJSON(raw)
-	case entities.CreateOpaqueSecret:
-		return k8s.NewCreateOpaqueSecretFromJSON(raw)
-	case entities.InstallExtDNS:
-		return ingress.NewInstallExtDNSFromJSON(raw)
-	case entities.CreateCACert:
-		return k8s.NewCreateCACertFromJSON(raw)
-	case entities.CreateTLSSecret:
-		return k8s.NewCreateTLSSecretFromJSON(raw)
-	case entities.DeleteNamespace:
-		return k8s.NewDeleteNamespaceFromJSON(raw)
-	case entities.DeleteNalejNamespace:
-		return k8s.NewDeleteNalejNamespaceFromJSON(raw)
-	case entities.DeleteServiceAccount:
-		return k8s.NewDeleteServiceAccountFromJSON(raw)
-	case entities.DeleteClusterRoleBinding:
-		return k8s.NewDeleteClusterRoleBindingFromJSON(raw)
-	case entities.DeleteClusterRole:
-		return k8s.NewDeleteClusterRoleFromJSON(raw)
-	case entities.DeleteRole:
-		return k8s.NewDeleteRoleFromJSON(raw)
-	case entities.DeleteRoleBinding:
-		return k8s.NewDeleteRoleBindingFromJSON(raw)
-	case entities.DeleteConfigMap:
-		return k8s.NewDeleteConfigMapFromJSON(raw)
-	case entities.DeleteService:
-		return k8s.NewDeleteServiceFromJSON(raw)
-	case entities.DeleteDeployment:
-		return k8s.NewDeleteDeploymentFromJSON(raw)
-	case entities.DeletePodSecurityPolicy:
-		return k8s.NewDeletePodSecurityPolicyFromJSON(raw)
-	case entities.InstallIstio:
-		return istio.NewInstallIstioFromJSON(raw)
-	default:
-		return nil, derrors.NewInvalidArgumentError(errors.UnsupportedCommand).WithParams(generic)
+// retryPolicyFromJSON structure with the optional retry fields of any command.
+type retryPolicyFromJSON struct {
+	Retries *int              `json:"retries"`
+	Backoff *entities.Backoff `json:"backoff"`
+	RetryOn []string          `json:"retryOn"`
+}
+
+// parseRetryPolicy extracts the retry policy of a command. It returns nil if the command does not define retries.
+func (cp *CmdParser) parseRetryPolicy(generic entities.GenericCommand, raw []byte) (*entities.RetryPolicy, derrors.Error) {
+	var rpfj retryPolicyFromJSON
+	if err := json.Unmarshal(raw, &rpfj); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.InvalidRetryPolicy, err)
+	}
+	if rpfj.Retries == nil {
+		if rpfj.Backoff != nil || len(rpfj.RetryOn) > 0 {
+			return nil, derrors.NewInvalidArgumentError(errors.InvalidRetryPolicy).WithParams(generic.CommandName, "retries")
+		}
+		return nil, nil
+	}
+	// Asynchronous commands report their result through the command handler, and cannot be repeated.
+	if generic.CommandType != entities.SyncCommandType {
+		return nil, derrors.NewInvalidArgumentError(errors.InvalidRetryPolicy).WithParams(generic.CommandName, generic.CommandType)
 	}
+	policy := entities.NewRetryPolicy(*rpfj.Retries, entities.NewConstantBackoff(0), rpfj.RetryOn...)
+	if rpfj.Backoff != nil {
+		policy.Backoff = *rpfj.Backoff
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
 }
 
-func (cp *CmdParser) parseAsyncCommand(generic entities.GenericCommand, raw []byte) (*entities.Command, derrors.Error) {
-	switch generic.CommandName {
-	case entities.Fail:
-		return async.NewFailFromJSON(raw)
-	case entities.Sleep:
-		return async.NewSleepFromJSON(raw)
-	default:
+// parseCommand creates a command using the constructor registered for its type and name.
+func (cp *CmdParser) parseCommand(generic entities.GenericCommand, raw []byte) (*entities.Command, derrors.Error) {
+	if generic.CommandType != entities.SyncCommandType && generic.CommandType != entities.AsyncCommandType {
+		return nil, derrors.NewInvalidArgumentError(errors.UnsupportedCommandType).WithParams(generic)
+	}
+	definition := cp.registry.Get(generic.CommandType, generic.CommandName)
+	if definition == nil {
 		return nil, derrors.NewInvalidArgumentError(errors.UnsupportedCommand).WithParams(generic)
 	}
+	return definition.FromJSON(raw)
 }
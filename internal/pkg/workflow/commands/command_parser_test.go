@@ -0,0 +1,155 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Command parser tests
+//
+
+package commands
+
+import (
+	"context"
+	"github.com/nalej/installer/internal/pkg/workflow/commands/sync"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"os"
+	"time"
+)
+
+var _ = ginkgo.Describe("Command parser", func() {
+
+	p := NewCmdParser()
+
+	ginkgo.It("must parse the retry policy of a command", func() {
+		raw := `{"type":"sync", "name": "logger", "msg": "retry", "retries": 3, "backoff": "5s", "retryOn": ["Unavailable"]}`
+		cmd, err := p.ParseCommand([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		policy := (*cmd).RetryPolicy()
+		gomega.Expect(policy).ToNot(gomega.BeNil())
+		gomega.Expect(policy.Retries).To(gomega.Equal(3))
+		gomega.Expect(policy.Backoff.Next(0)).To(gomega.Equal(time.Second * 5))
+		gomega.Expect(policy.RetryOn).To(gomega.ConsistOf("Unavailable"))
+	})
+
+	ginkgo.It("must parse commands without retries", func() {
+		cmd, err := p.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "no retry"}`))
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect((*cmd).RetryPolicy()).To(gomega.BeNil())
+	})
+
+	ginkgo.It("must reject invalid retry policies", func() {
+		_, err := p.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "m", "retries": -1}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		_, err = p.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "m", "retries": 1, "backoff": "never"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		_, err = p.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "m", "backoff": "1s"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		_, err = p.ParseCommand([]byte(`{"type":"async", "name": "sleep", "time": "1", "retries": 1}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+	ginkgo.It("must parse the timeout of a command", func() {
+		cmd, err := p.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "m", "timeout": "2m"}`))
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect((*cmd).Timeout()).To(gomega.Equal(time.Minute * 2))
+		_, err = p.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "m", "timeout": "soon"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+	ginkgo.It("must parse the waitFor command", func() {
+		raw := `{"type":"sync", "name": "waitFor", "kubeConfigPath": "/tmp/config", "group": "certmanager.k8s.io",
+			"version": "v1alpha1", "resource": "certificates", "namespace": "istio-system", "resourceName": "ingress-cert",
+			"condition": "Ready", "interval": "10s", "waitTimeout": "15m"}`
+		cmd, err := p.ParseCommand([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect((*cmd).Name()).To(gomega.Equal(entities.WaitFor))
+		_, err = p.ParseCommand([]byte(`{"type":"sync", "name": "waitFor", "version": "v1", "resource": "services",
+			"resourceName": "lb"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		_, err = p.ParseCommand([]byte(`{"type":"sync", "name": "waitFor", "version": "v1", "resource": "services",
+			"resourceName": "lb", "labelSelector": "app=lb", "path": "status.loadBalancer.ingress.0.ip"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+	ginkgo.It("must fail a nested command that reaches its timeout", func() {
+		raw := `{"type":"sync", "name": "group", "description": "timeout", "commands": [
+			{"type":"sync", "name": "exec", "cmd": "sleep", "args": ["30"], "timeout": "200ms"}
+		]}`
+		cmd, err := p.ParseCommand([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		start := time.Now()
+		_, err = entities.RunSyncCommand(context.Background(), *cmd, "TestNestedTimeout", nil)
+		gomega.Expect(entities.IsDeadlineExceeded(err)).To(gomega.BeTrue())
+		gomega.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second*5))
+	})
+
+	ginkgo.It("must fail an async command that reaches its timeout", func() {
+		raw := `{"type":"sync", "name": "group", "description": "timeout", "commands": [
+			{"type":"async", "name": "sleep", "time": "30", "timeout": "200ms"}
+		]}`
+		cmd, err := p.ParseCommand([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		start := time.Now()
+		_, err = entities.RunSyncCommand(context.Background(), *cmd, "TestAsyncTimeout", nil)
+		gomega.Expect(entities.IsDeadlineExceeded(err)).To(gomega.BeTrue())
+		gomega.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second*5))
+	})
+
+	ginkgo.It("must apply the retries of nested commands", func() {
+		marker := "/tmp/commandParserRetry"
+		os.Remove(marker)
+		defer os.Remove(marker)
+		raw := `{"type":"sync", "name": "group", "description": "retry", "commands": [
+			{"type":"sync", "name": "exec", "cmd": "sh", "args": ["-c", "test -f ` + marker + ` || (touch ` + marker + `; exit 1)"],
+			 "retries": 2, "backoff": "10ms"}
+		]}`
+		cmd, err := p.ParseCommand([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		result, err := entities.RunSyncCommand(context.Background(), *cmd, "TestNestedRetry", nil)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(result.Success).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("must resolve the commands through the registry", func() {
+		for _, definition := range entities.DefaultRegistry.List() {
+			gomega.Expect(definition.Description).ToNot(gomega.BeEmpty(), definition.Name)
+			for _, parameter := range definition.Parameters {
+				gomega.Expect(parameter.Description).ToNot(gomega.BeEmpty(), definition.Name+"."+parameter.Name)
+			}
+		}
+		gomega.Expect(entities.DefaultRegistry.Get(entities.SyncCommandType, entities.WaitFor)).ToNot(gomega.BeNil())
+		gomega.Expect(entities.DefaultRegistry.Get(entities.AsyncCommandType, entities.Sleep)).ToNot(gomega.BeNil())
+
+		_, err := p.ParseCommand([]byte(`{"type":"sync", "name": "unknown"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		_, err = p.ParseCommand([]byte(`{"type":"other", "name": "logger"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+
+		registry := entities.NewCommandRegistry()
+		registry.Register(entities.CommandDefinition{
+			Type:     entities.SyncCommandType,
+			Name:     "custom",
+			FromJSON: sync.NewLoggerFromJSON,
+		})
+		custom := NewCmdParserWithRegistry(registry)
+		cmd, err := custom.ParseCommand([]byte(`{"type":"sync", "name": "custom", "msg": "plugged"}`))
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect((*cmd).String()).To(gomega.ContainSubstring("plugged"))
+		_, err = custom.ParseCommand([]byte(`{"type":"sync", "name": "logger", "msg": "m"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+})
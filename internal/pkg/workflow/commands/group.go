@@ -25,6 +25,7 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -99,7 +100,7 @@ func NewGroupFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (g *Group) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (g *Group) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 
 	if len(g.Commands) == 0 {
 		return nil, derrors.NewInternalError(errors.CannotExecuteSyncCommand)
@@ -108,16 +109,23 @@ func (g *Group) Run(workflowID string) (*entities.CommandResult, derrors.Error)
 	g.commandHandler.AddLogEntry(g.CommandID, fmt.Sprintf("Starting sequential group execution of %d commands", len(g.Commands)))
 	log.Info().Str("groupCmdId", g.CommandID).Str("description", g.Description).Msg("Executing sequential group")
 	results := make([]entities.CommandResult, 0)
-	for _, nextCommand := range g.Commands {
-		result, err := g.executeCommand(workflowID, nextCommand)
+	for index, nextCommand := range g.Commands {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		started := entities.NewStartedEvent(workflowID, nextCommand, g.CommandID)
+		g.commandHandler.AddEvent(g.CommandID, *started)
+		result, err := g.executeCommand(ctx, workflowID, nextCommand)
+		g.commandHandler.AddEvent(g.CommandID,
+			*entities.NewFinishedEvent(workflowID, nextCommand, g.CommandID, started.Started, result, err))
 		if err != nil {
 			return nil, err
 		}
 		if result != nil {
 			log.Debug().Str("groupCmdId", g.CommandID).Bool("success", result.Success).Msg("Adding result to group")
 			results = append(results, *result)
-			g.commandHandler.AddLogEntry(g.CommandID, result.UserString())
 			if !result.Success {
+				g.skipCommands(workflowID, g.Commands[index+1:])
 				break
 			}
 		} else {
@@ -147,17 +155,25 @@ func (g *Group) Run(workflowID string) (*entities.CommandResult, derrors.Error)
 	return entities.NewCommandResult(overallSuccess, overallOutputString, overallError), nil
 }
 
-func (g *Group) executeCommand(workflowID string, cmd entities.Command) (*entities.CommandResult, derrors.Error) {
+// skipCommands reports the commands that are not executed after a failure.
+func (g *Group) skipCommands(workflowID string, skipped []entities.Command) {
+	for _, cmd := range skipped {
+		g.commandHandler.AddEvent(g.CommandID,
+			*entities.NewExecutionEvent(workflowID, cmd, g.CommandID, entities.SkippedPhase, "a previous command failed"))
+	}
+}
+
+func (g *Group) executeCommand(ctx context.Context, workflowID string, cmd entities.Command) (*entities.CommandResult, derrors.Error) {
 	err := g.commandHandler.AddCommand(cmd.ID(), g.commandCallback, g.logCallback)
 	if err != nil {
 		return nil, err
 	}
-	if cmd.Name() != entities.Logger {
-		g.commandHandler.AddLogEntry(g.CommandID, "Executing: "+cmd.String()+" with Id: "+cmd.ID())
-	}
+	g.commandHandler.AttachEventCallback(cmd.ID(), g.eventCallback)
 	if cmd.Type() == entities.SyncCommandType {
 		log.Debug().Str("groupCmdId", g.CommandID).Str("cmdID", cmd.ID()).Str("cmd", cmd.String()).Msg("SYNC")
-		result, err := cmd.(entities.SyncCommand).Run(workflowID)
+		result, err := entities.RunSyncCommand(ctx, cmd, workflowID, func(entry string) {
+			g.commandHandler.AddLogEntry(g.CommandID, entry)
+		})
 		if err != nil {
 			log.Warn().Str("id", cmd.ID()).Str("err", err.DebugReport()).Msg("error executing sync command on sequential group")
 			return nil, err
@@ -168,7 +184,9 @@ func (g *Group) executeCommand(workflowID string, cmd entities.Command) (*entiti
 	// Async command expected
 	log.Debug().Str("groupCmdId", g.CommandID).Str("cmdID", cmd.ID()).Str("cmd", cmd.String()).Msg("ASYNC")
 	g.asyncCmdID = cmd.ID()
-	err = cmd.(entities.AsyncCommand).Run(workflowID)
+	stopWatch := handler.WatchTimeout(g.commandHandler, cmd)
+	defer stopWatch()
+	err = cmd.(entities.AsyncCommand).Run(ctx, workflowID)
 	if err != nil {
 		log.Warn().Str("id", cmd.ID()).Str("err", err.DebugReport()).Msg("error executing async command on sequential group")
 		//If the execution return errors, the executor call to the commandHandler with the error.
@@ -217,6 +235,11 @@ func (g *Group) logCallback(cmdID string, logEntry string) {
 	g.commandHandler.AddLogEntry(g.CommandID, fmt.Sprintf("[%s] %s", g.Description, logEntry))
 }
 
+// eventCallback forwards the events of the nested commands to the parent of the group.
+func (g *Group) eventCallback(cmdID string, event entities.ExecutionEvent) {
+	g.commandHandler.AddEvent(g.CommandID, event)
+}
+
 // String obtains a string representation
 func (g *Group) String() string {
 	cmdNames := make([]string, 0)
@@ -243,3 +266,8 @@ func (g *Group) UserString() string {
 	}
 	return fmt.Sprintf("Sequential group: %s actions: %s", g.Description, strings.Join(cmdNames, ", "))
 }
+
+// SupportsDryRun returns true as the commands of the group are checked when they are executed.
+func (g *Group) SupportsDryRun() bool {
+	return true
+}
@@ -21,11 +21,13 @@
 package commands
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/workflow/commands/async"
 	"github.com/nalej/installer/internal/pkg/workflow/commands/sync"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
+	"time"
 )
 
 var _ = ginkgo.Describe("Group command", func() {
@@ -35,7 +37,7 @@ var _ = ginkgo.Describe("Group command", func() {
 		cmd3 := sync.NewLogger("cmd2")
 		g := NewGroup("basicSequence", []entities.Command{cmd1, cmd2, cmd3})
 		wID := "TestBasicSequence"
-		result, err := g.Run(wID)
+		result, err := g.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 	})
@@ -46,7 +48,7 @@ var _ = ginkgo.Describe("Group command", func() {
 		cmd3 := sync.NewLogger("cmd2")
 		g := NewGroup("basicSequence", []entities.Command{cmd1, cmd2, cmd3})
 		wID := "TestBasicSequence"
-		result, err := g.Run(wID)
+		result, err := g.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 	})
@@ -58,8 +60,25 @@ var _ = ginkgo.Describe("Group command", func() {
 		cmd4 := sync.NewLogger("should not appear")
 		g := NewGroup("basicSequence", []entities.Command{cmd1, cmd2, cmd3, cmd4})
 		wID := "TestBasicSequenceFail"
-		result, err := g.Run(wID)
+		result, err := g.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeFalse())
 	})
+
+	ginkgo.It("Must stop when the context is canceled", func() {
+		cmd1 := sync.NewSleep("10")
+		cmd2 := sync.NewLogger("should not appear")
+		g := NewGroup("canceledSequence", []entities.Command{cmd1, cmd2})
+		wID := "TestCanceledSequence"
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(time.Millisecond * 200)
+			cancel()
+		}()
+		start := time.Now()
+		result, err := g.Run(ctx, wID)
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		gomega.Expect(result).To(gomega.BeNil())
+		gomega.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second*5))
+	})
 })
@@ -24,12 +24,14 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/nalej/derrors"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
@@ -47,6 +49,10 @@ type Parallel struct {
 	commandResults  map[string]entities.CommandResult
 	executionErrors map[string]derrors.Error
 	finishChannel   chan string
+	// started contains the time when each command was launched.
+	started map[string]time.Time
+	// workflowID with the identifier of the workflow being executed.
+	workflowID string
 }
 
 // ParallelFromJSON structure with helper RawMessage to parse
@@ -81,7 +87,8 @@ func NewParallel(description string, maxParallelism int, cmds []entities.Command
 		Commands:           cmds, commandHandler: handler.GetCommandHandler(),
 		commandResults:  make(map[string]entities.CommandResult),
 		executionErrors: make(map[string]derrors.Error),
-		finishChannel:   make(chan string)}
+		finishChannel:   make(chan string),
+		started:         make(map[string]time.Time)}
 }
 
 // NewParallelFromJSON creates a new command from a raw json payload.
@@ -109,8 +116,9 @@ func min(a int, b int) int {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (p *Parallel) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (p *Parallel) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 
+	p.workflowID = workflowID
 	awaiting := len(p.Commands)
 	initialLaunch := len(p.Commands)
 	if p.MaxParallelism != 0 {
@@ -124,14 +132,15 @@ func (p *Parallel) Run(workflowID string) (*entities.CommandResult, derrors.Erro
 		toExecute := nextCommand
 		log.Debug().Str("Id", toExecute.ID()).Str("cmd", toExecute.String()).Msg("Launching goroutine for command execution")
 		go func() {
-			p.execOnBackground(workflowID, toExecute)
+			p.execOnBackground(ctx, workflowID, toExecute)
 		}()
 		launched++
 	}
 
 	log.Debug().Int("launched", launched).Int("total", awaiting).Msg("")
 	failed := false
-	for received := 0; received < awaiting && !failed; received++ {
+	// Wait only for the launched commands as no new commands are launched once the context is done.
+	for received := 0; received < launched && !failed; received++ {
 		log.Debug().Int("launched", launched).Int("finished", received).Msg("")
 		cmdID := <-p.finishChannel
 		log.Debug().Str("cmdID", cmdID).Msg("Command finished")
@@ -139,17 +148,24 @@ func (p *Parallel) Run(workflowID string) (*entities.CommandResult, derrors.Erro
 			log.Debug().Msg("command returned error, aborting.")
 			failed = true
 		} else {
-			if launched < awaiting {
+			if launched < awaiting && ctx.Err() == nil {
 				nextCommand := p.Commands[launched]
 				toExecute := nextCommand
 				log.Debug().Str("Id", toExecute.ID()).Str("cmd", toExecute.String()).Msg("Launching goroutine for command execution")
 				go func() {
-					p.execOnBackground(workflowID, toExecute)
+					p.execOnBackground(ctx, workflowID, toExecute)
 				}()
 				launched++
 			}
 		}
 	}
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+	for _, skipped := range p.Commands[launched:] {
+		p.commandHandler.AddEvent(p.CommandID, *entities.NewExecutionEvent(workflowID, skipped, p.CommandID,
+			entities.SkippedPhase, "a parallel command failed"))
+	}
 
 	return p.buildCommandResult()
 }
@@ -165,7 +181,7 @@ func (p *Parallel) executionWithErrors() bool {
 	return len(p.executionErrors) > 0 || !overallSuccess
 }
 
-func (p *Parallel) execOnBackground(workflowID string, cmd entities.Command) {
+func (p *Parallel) execOnBackground(ctx context.Context, workflowID string, cmd entities.Command) {
 	err := p.commandHandler.AddCommand(cmd.ID(), p.ParallelCallback, p.logCallback)
 	if err != nil {
 		log.Warn().Str("err", err.DebugReport()).Msg("error on exec")
@@ -174,11 +190,18 @@ func (p *Parallel) execOnBackground(workflowID string, cmd entities.Command) {
 		p.Unlock()
 		return
 	}
+	p.commandHandler.AttachEventCallback(cmd.ID(), p.eventCallback)
 
-	p.commandHandler.AddLogEntry(p.CommandID, "Executing on Parallel: "+cmd.ID())
+	started := entities.NewStartedEvent(workflowID, cmd, p.CommandID)
+	p.Lock()
+	p.started[cmd.ID()] = started.Started
+	p.Unlock()
+	p.commandHandler.AddEvent(p.CommandID, *started)
 	if cmd.Type() == entities.SyncCommandType {
 		log.Debug().Str("cmd", cmd.String()).Msg("SYNC")
-		result, err := cmd.(entities.SyncCommand).Run(workflowID)
+		result, err := entities.RunSyncCommand(ctx, cmd, workflowID, func(entry string) {
+			p.commandHandler.AddLogEntry(p.CommandID, entry)
+		})
 		err = p.commandHandler.FinishCommand(cmd.ID(), result, err)
 		if err != nil {
 			log.Warn().Str("id", cmd.ID()).Str("err", err.DebugReport()).Msg("error executing sync command on parallel group")
@@ -190,7 +213,9 @@ func (p *Parallel) execOnBackground(workflowID string, cmd entities.Command) {
 		}
 	} else {
 		log.Debug().Str("cmd", cmd.String()).Msg("ASYNC")
-		err := cmd.(entities.AsyncCommand).Run(workflowID)
+		// The watch is not stopped as reaching the timeout of a finished command has no effect.
+		handler.WatchTimeout(p.commandHandler, cmd)
+		err := cmd.(entities.AsyncCommand).Run(ctx, workflowID)
 		if err != nil {
 			//If the execution return errors, the executor call to the commandHandler with the error.
 			err = p.commandHandler.FinishCommand(cmd.ID(), nil, err)
@@ -211,9 +236,29 @@ func (p *Parallel) logCallback(cmdID string, logEntry string) {
 	p.commandHandler.AddLogEntry(p.CommandID, fmt.Sprintf("[%s] %s", p.Description, logEntry))
 }
 
+// eventCallback forwards the events of the nested commands to the parent of the parallel command.
+func (p *Parallel) eventCallback(cmdID string, event entities.ExecutionEvent) {
+	p.commandHandler.AddEvent(p.CommandID, event)
+}
+
+// reportFinished sends the event of a finished command to the parent of the parallel command.
+func (p *Parallel) reportFinished(cmdID string, result *entities.CommandResult, error derrors.Error) {
+	for _, cmd := range p.Commands {
+		if cmd.ID() == cmdID {
+			p.Lock()
+			started := p.started[cmdID]
+			p.Unlock()
+			p.commandHandler.AddEvent(p.CommandID,
+				*entities.NewFinishedEvent(p.workflowID, cmd, p.CommandID, started, result, error))
+			return
+		}
+	}
+}
+
 // ParallelCallback function to be called when one of the commands being executed in parallel finishes.
 func (p *Parallel) ParallelCallback(cmdID string, result *entities.CommandResult, error derrors.Error) {
 	log.Debug().Str("cmdID", cmdID).Msg("received callback from parallel command ")
+	p.reportFinished(cmdID, result, error)
 	if result != nil {
 		p.Lock()
 		p.commandResults[cmdID] = *result
@@ -285,3 +330,8 @@ func (p *Parallel) UserString() string {
 	}
 	return fmt.Sprintf("Parallel group: %s actions: %s", p.Description, strings.Join(cmdNames, ", "))
 }
+
+// SupportsDryRun returns true as the parallel commands are checked when they are executed.
+func (p *Parallel) SupportsDryRun() bool {
+	return true
+}
@@ -21,6 +21,7 @@
 package commands
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/workflow/commands/sync"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
@@ -35,7 +36,7 @@ var _ = ginkgo.Describe("Parallel command", func() {
 
 		p := NewParallel("test synchronous commands", 3, []entities.Command{cmd1, cmd2, cmd3})
 		wID := "testWorkflow"
-		result, err := p.Run(wID)
+		result, err := p.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 	})
@@ -66,7 +67,7 @@ var _ = ginkgo.Describe("Parallel command", func() {
 
 		p := NewParallel("test synchronous commands", 3, []entities.Command{cmd1, cmd2, cmd3})
 		wID := "testWorkflow"
-		result, err := p.Run(wID)
+		result, err := p.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeFalse())
 	})
@@ -85,7 +86,7 @@ var _ = ginkgo.Describe("Parallel command", func() {
 			[]entities.Command{cmd1, cmd2, cmd3, cmd4, cmd5, cmd6, cmd7, cmd8})
 
 		wID := "testWorkflow"
-		result, err := p.Run(wID)
+		result, err := p.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 	})
@@ -98,7 +99,7 @@ var _ = ginkgo.Describe("Parallel command", func() {
 			[]entities.Command{cmd1, cmd2})
 
 		wID := "testWorkflow"
-		result, err := p.Run(wID)
+		result, err := p.Run(context.Background(), wID)
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 	})
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the commands that contain other commands.
+
+package commands
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+func init() {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.GroupCmd,
+		Description: "Executes a list of commands in order",
+		FromJSON:    NewGroupFromJSON,
+		Parameters: entities.ParametersOf(&GroupFromJSON{}, map[string]string{
+			"description": "Description of the group",
+			"commands":    "Commands of the group",
+		}),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.ParallelCmd,
+		Description: "Executes a list of commands in parallel",
+		FromJSON:    NewParallelFromJSON,
+		Parameters: entities.ParametersOf(&ParallelFromJSON{}, map[string]string{
+			"description":    "Description of the commands",
+			"maxParallelism": "Maximum number of commands executed at the same time",
+			"commands":       "Commands to be executed",
+		}),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.TryCmd,
+		Description: "Executes a command and another one if the first fails",
+		FromJSON:    NewTryFromJSON,
+		Parameters: entities.ParametersOf(&TryFromJSON{}, map[string]string{
+			"description": "Description of the command",
+			"cmd":         "Command to be executed",
+			"onFail":      "Command executed if the first one fails",
+		}),
+	})
+}
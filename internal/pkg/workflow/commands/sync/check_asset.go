@@ -18,6 +18,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
@@ -53,7 +54,7 @@ func NewCheckAssetFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (ca *CheckAsset) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (ca *CheckAsset) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 	// Check if the file exists.
 	fileInfo, err := os.Stat(ca.Path)
 
@@ -83,3 +84,8 @@ func (ca *CheckAsset) PrettyPrint(indentation int) string {
 func (ca *CheckAsset) UserString() string {
 	return "Check asset " + ca.Path
 }
+
+// SupportsDryRun returns true as the command only reads the file system.
+func (ca *CheckAsset) SupportsDryRun() bool {
+	return true
+}
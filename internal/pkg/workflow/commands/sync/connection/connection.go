@@ -21,6 +21,7 @@
 package connection
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -29,11 +30,11 @@ import (
 type Connection interface {
 	// TODO: Add connect / disconnect to be able to run multiple commands over a single connection
 
-	// Execute a single command on a node capturing stdout
-	Execute(command string) ([]byte, error)
+	// Execute a single command on a node capturing stdout. The command is aborted when the context is done.
+	Execute(ctx context.Context, command string) ([]byte, error)
 
-	// Copy a single file to or from a node
-	Copy(lpath string, rpath string, remoteSource bool) error
+	// Copy a single file to or from a node. The transfer is aborted when the context is done.
+	Copy(ctx context.Context, lpath string, rpath string, remoteSource bool) error
 
 	// Get online status
 	IsOnline() (bool, error)
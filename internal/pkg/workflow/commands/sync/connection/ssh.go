@@ -19,6 +19,7 @@ package connection
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -135,14 +136,33 @@ func (conn *SSHConnection) OpenSession() (*ssh.Client, *ssh.Session, error) {
 	return client, session, nil
 }
 
-// Execute a given command.
-func (conn *SSHConnection) Execute(command string) ([]byte, error) {
+// closeOnDone closes the client when the context is done so that any pending operation on its sessions returns.
+// The returned function must be called to release the watcher once the operation finishes.
+func closeOnDone(ctx context.Context, client *ssh.Client) func() {
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("closing SSH connection as the context is done")
+			client.Close()
+		case <-finished:
+		}
+	}()
+	return func() {
+		close(finished)
+	}
+}
+
+// Execute a given command. The session is closed if the context is done before the command finishes.
+func (conn *SSHConnection) Execute(ctx context.Context, command string) ([]byte, error) {
 	client, session, err := conn.OpenSession()
 	if err != nil {
 		return nil, err
 	}
 	defer client.Close()
 	defer session.Close()
+	release := closeOnDone(ctx, client)
+	defer release()
 
 	var stderrBuffer bytes.Buffer
 	stderrReader, err := session.StderrPipe()
@@ -153,6 +173,9 @@ func (conn *SSHConnection) Execute(command string) ([]byte, error) {
 
 	log.Debug().Str("command", command).Msg("Executing command")
 	output, err := session.Output(command)
+	if ctx.Err() != nil {
+		return output, ctx.Err()
+	}
 	if err != nil {
 		err = fmt.Errorf("Error executing %s, error: %v.\nSTDOUT\n%sSTDERR\n%s",
 			command, err, output, stderrBuffer.Bytes())
@@ -161,14 +184,16 @@ func (conn *SSHConnection) Execute(command string) ([]byte, error) {
 	return output, err
 }
 
-// Copy a file to a remote host or viceversa.
-func (conn *SSHConnection) Copy(lpath, rpath string, remoteSource bool) error {
+// Copy a file to a remote host or viceversa. The transfer is aborted if the context is done.
+func (conn *SSHConnection) Copy(ctx context.Context, lpath, rpath string, remoteSource bool) error {
 	client, session, err := conn.OpenSession()
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 	defer session.Close()
+	release := closeOnDone(ctx, client)
+	defer release()
 
 	// rpath -> lpath
 	if remoteSource == true {
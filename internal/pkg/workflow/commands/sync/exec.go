@@ -23,6 +23,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"os/exec"
@@ -61,7 +62,7 @@ func NewExecFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (e *Exec) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (e *Exec) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 
 	// TODO Proper exit code manipulation
 	// It seems that a lot of people are struggling with this cause there is not an easy way to determine the exit
@@ -73,9 +74,13 @@ func (e *Exec) Run(_ string) (*entities.CommandResult, derrors.Error) {
 	// https://groups.google.com/forum/#!topic/golang-nuts/MI4TyIkQqqg
 	// https://groups.google.com/forum/#!msg/golang-nuts/dKbL1oOiCIY/OCfhH2rFp80J
 
-	cmd := exec.Command(e.Cmd, e.Args...)
+	// The process is killed if the context is done before it finishes.
+	cmd := exec.CommandContext(ctx, e.Cmd, e.Args...)
 	output, err := cmd.CombinedOutput()
 
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		return nil, derrors.NewInternalError(errors.CannotExecuteSyncCommand, err).WithParams(e.Cmd, e.Args)
 	}
@@ -23,6 +23,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -57,7 +58,7 @@ func NewFailFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (f *Fail) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (f *Fail) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 	return entities.NewErrCommand("fail command - "+workflowID, derrors.NewGenericError("forced failure")), nil
 }
 
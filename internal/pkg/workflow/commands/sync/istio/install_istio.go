@@ -22,6 +22,7 @@ package istio
 
 import (
     "bytes"
+    "context"
     "crypto/rand"
     "crypto/rsa"
     "crypto/x509"
@@ -63,6 +64,10 @@ const (
     IstioTimeSleep = time.Second * 5
     // Time before timeout
     IstioTimeout = time.Second * 300
+    // Time between checks of the ingress certificate
+    IstioCertificateTimeSleep = time.Second
+    // Time before timeout waiting for the ingress certificate
+    IstioCertificateTimeout = time.Minute * 5
     // Time validity for the Istio certificate
     IstioCertValidity = time.Hour * 24 * 365 * 2
 )
@@ -206,9 +211,9 @@ func NewInstallIstioFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 }
 
 
-func (i *InstallIstio) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (i *InstallIstio) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
     // Create namespace
-    connectErr := i.Connect()
+    connectErr := i.ConnectWithContext(ctx)
     if connectErr != nil {
         return nil, connectErr
     }
@@ -226,10 +231,10 @@ func (i *InstallIstio) Run(workflowID string) (*entities.CommandResult, derrors.
     // Run Istioctl installer
     if i.IsAppCluster {
         // Install Istio in the application cluster
-        err = i.installInSlave()
+        err = i.installInSlave(ctx)
     } else {
         // Install Istio in the master
-        err = i.installInMaster()
+        err = i.installInMaster(ctx)
         // Create gateway
         i.installGateway()
     }
@@ -240,55 +245,33 @@ func (i *InstallIstio) Run(workflowID string) (*entities.CommandResult, derrors.
 
     // Wait for the gateway to have a valid ip.
     // This operation may take quite a while. For the sake of installation speed we skip this check.
-    // i.waitForGatewayIP()
+    // i.waitForGatewayIP(ctx)
 
-    return entities.NewSuccessCommand([]byte("istio has been installed successfully")), nil
+    return i.ApplyResult("istio has been installed successfully"), nil
 }
 
 // waitForGatewayIP periodically checks the availability of the Istio gateway. The function terminates
 // if and only if the gateway is available and it has its own IP address.
-func (i *InstallIstio) waitForGatewayIP() derrors.Error {
+func (i *InstallIstio) waitForGatewayIP(ctx context.Context) derrors.Error {
 
     log.Info().Msg("wait for Istio ingress gateway service to be available")
-    ticker := time.NewTicker(IstioTimeSleep)
-    timeout := make(chan bool)
-    ip := make(chan string)
-
-    go func() {
-        for {
-            select {
-            case <- ticker.C:
-                svc, err := i.Client.CoreV1().Services(IstioNamespace).Get(IstioIngressGateway, metaV1.GetOptions{})
-                if err == nil {
-                    // check if we have a valid ip
-                    if len(svc.Status.LoadBalancer.Ingress) > 0 {
-                        svcIP := svc.Status.LoadBalancer.Ingress[0].IP
-                        if len(svcIP) != 0 {
-                            ip <- svcIP
-                            log.Info().Msgf("Istio gateway has the associated IP: %s", svcIP)
-                        }
-                    }
-                }
-            case <- ip:
-                return
-            case <- timeout:
-                log.Info().Msg("timeout reached when waiting for gateway service")
-                return
-            }
+    policy := entities.NewRetryPolicy(int(IstioTimeout/IstioTimeSleep), entities.NewConstantBackoff(IstioTimeSleep))
+    err := policy.Poll(ctx, func() (bool, derrors.Error) {
+        svc, err := i.Client.CoreV1().Services(IstioNamespace).Get(IstioIngressGateway, metaV1.GetOptions{})
+        if err != nil {
+            return false, derrors.NewUnavailableError("cannot retrieve gateway service", err)
         }
-    }()
-
-    // wait until the Istio gateway service has an assigned IP
-    for {
-        select {
-        case <- time.After(IstioTimeout):
-            timeout <- true
-            return derrors.NewDeadlineExceededError("timeout reached when waiting for gateway service")
-        case <- ip:
-            return nil
+        // check if we have a valid ip
+        if len(svc.Status.LoadBalancer.Ingress) > 0 && len(svc.Status.LoadBalancer.Ingress[0].IP) != 0 {
+            log.Info().Msgf("Istio gateway has the associated IP: %s", svc.Status.LoadBalancer.Ingress[0].IP)
+            return true, nil
         }
+        return false, nil
+    })
+    if err != nil {
+        log.Info().Str("err", err.Error()).Msg("gateway service is not available")
+        return err
     }
-
     return nil
 }
 
@@ -477,45 +460,55 @@ func (i *InstallIstio) createClusterCA(RootCert *x509.Certificate, RootKey *rsa.
 }
 
 
-func (i* InstallIstio) waitCertificate() derrors.Error {
+func (i* InstallIstio) waitCertificate(ctx context.Context) derrors.Error {
     // wait until the certificate is ready. Otherwise the ingressgateway will not update correctly the ca secret
     log.Info().Msg("wait until the letsencrypt certificate is up and ready...")
-    ticker := time.NewTicker(1000 * time.Millisecond)
-    tickerInfo := time.NewTicker(time.Minute)
-    timeout := time.After(5*time.Minute)
-
-    for {
-        select {
-        case <-ticker.C:
-            // Check if the certificate is ready
-            issued, err := i.Kubernetes.MatchCRDStatus(
-                IstioNamespace, "certmanager.k8s.io",
-                "v1alpha1",
-                "certificates", "ingress-cert",
-                []string{"status", "conditions", "0", "status"}, "True")
-
-            if err != nil {
-                log.Error().Err(err).Msg("error when retrieving information about the istio certificate")
-                return err
-            }
-            if *issued {
-                log.Info().Msg("the certificate was correctly issued.")
-                ticker.Stop()
-                tickerInfo.Stop()
-                return nil
-            }
-        case <-tickerInfo.C:
+    // Only the checks of a certificate that is not issued yet are retried, an error retrieving it fails immediately.
+    policy := entities.NewRetryPolicy(int(IstioCertificateTimeout/IstioCertificateTimeSleep),
+        entities.NewConstantBackoff(IstioCertificateTimeSleep), entities.RetryOnFailure)
+    checks := 0
+    err := policy.Poll(ctx, func() (bool, derrors.Error) {
+        checks++
+        // Check if the certificate is ready
+        issued, err := i.Kubernetes.MatchCRDStatus(
+            IstioNamespace, "certmanager.k8s.io",
+            "v1alpha1",
+            "certificates", "ingress-cert",
+            []string{"status", "conditions", "0", "status"}, "True")
+        if err != nil {
+            log.Error().Str("err", err.Error()).Msg("error when retrieving information about the istio certificate")
+            return false, err
+        }
+        if !*issued && checks%int(time.Minute/IstioCertificateTimeSleep) == 0 {
             log.Info().Msg("...waiting for the certificate to be issued")
-        case <- timeout:
-            log.Error().Msg("exceeded time waiting for Istio certificate to be up and ready")
-            return derrors.NewInternalError("exceeded time waiting for Istio certificate to be up and ready")
         }
+        return *issued, nil
+    })
+    if err != nil {
+        log.Error().Str("err", err.Error()).Msg("exceeded time waiting for Istio certificate to be up and ready")
+        return err
     }
+    log.Info().Msg("the certificate was correctly issued.")
     return nil
 }
 
 
-func (i *InstallIstio) installInMaster() derrors.Error {
+// istioMasterArgs returns the arguments of istioctl to install the master cluster.
+func istioMasterArgs(kubeConfigPath string, staticIpAddress string, configFile string) []string {
+    return []string{
+        "manifest",
+        "apply",
+        fmt.Sprintf("--kubeconfig=%s", kubeConfigPath),
+        "--set", "values.gateways.istio-ingressgateway.sds.enabled=true",
+        "--set", "values.global.k8sIngress.enabled=true",
+        "--set", "values.global.k8sIngress.enableHttps=true",
+        "--set", "values.global.k8sIngress.gatewayName=ingressgateway",
+        "--set", fmt.Sprintf("values.gateways.istio-ingressgateway.loadBalancerIP=%s", staticIpAddress),
+        "-f", configFile,
+    }
+}
+
+func (i *InstallIstio) installInMaster(ctx context.Context) derrors.Error {
 
     // install the certificate
     log.Info().Msg("install Istio gateway certificate")
@@ -527,8 +520,22 @@ func (i *InstallIstio) installInMaster() derrors.Error {
     if err != nil {
         return err
     }
+    if i.DryRun() {
+        // the certificate is not issued in dry-run mode
+        i.RecordExec(fmt.Sprintf("%s/istioctl %s", i.IstioPath, strings.Join(istioMasterArgs(i.KubeConfigPath, i.StaticIpAddress, "<config>"), " ")))
+        i.RecordEntry(entities.ManifestEntry{
+            Action:     entities.PatchAction,
+            APIVersion: "networking.istio.io/v1alpha3",
+            Kind:       "Gateway",
+            Namespace:  IstioNamespace,
+            Name:       "istio-autogenerated-k8s-ingress",
+            Definition: IstioIngressPatch,
+        })
+        return nil
+    }
+
     // wait until the certificate is up and ready
-    err = i.waitCertificate()
+    err = i.waitCertificate(ctx)
     if err != nil {
         return err
     }
@@ -547,22 +554,12 @@ func (i *InstallIstio) installInMaster() derrors.Error {
     defer os.Remove(file.Name())
 
     log.Info().Msg("call Istioctl to install the master cluster")
-    args := []string{
-        "manifest",
-        "apply",
-        fmt.Sprintf("--kubeconfig=%s", i.KubeConfigPath),
-        "--set", "values.gateways.istio-ingressgateway.sds.enabled=true",
-        "--set", "values.global.k8sIngress.enabled=true",
-        "--set", "values.global.k8sIngress.enableHttps=true",
-        "--set", "values.global.k8sIngress.gatewayName=ingressgateway",
-        "--set", fmt.Sprintf("values.gateways.istio-ingressgateway.loadBalancerIP=%s",i.StaticIpAddress),
-        "-f", file.Name(),
-    }
+    args := istioMasterArgs(i.KubeConfigPath, i.StaticIpAddress, file.Name())
 
     log.Debug().Interface("istioctl",args).Msg("istioctl was called")
 
     rExec := sync.NewExec(fmt.Sprintf("%s/istioctl", i.IstioPath),args)
-    _, err = rExec.Run("")
+    _, err = rExec.Run(ctx, "")
 
     if err != nil {
         return err
@@ -585,7 +582,7 @@ func (i *InstallIstio) installInMaster() derrors.Error {
 
 
 
-func (i *InstallIstio) installInSlave() derrors.Error {
+func (i *InstallIstio) installInSlave(ctx context.Context) derrors.Error {
 
     log.Debug().Msg("install Istio slave")
 
@@ -642,10 +639,17 @@ func (i *InstallIstio) installInSlave() derrors.Error {
          "--set", "autoInjection.enabled=true",
      }
 
+    if i.DryRun() {
+        i.RecordExec(fmt.Sprintf("%s/istioctl %s", i.IstioPath, strings.Join(args, " ")))
+        return nil
+    }
+
     log.Debug().Str("istio",fmt.Sprintf("%s/istioctl",i.IstioPath)).Interface("args",args).Msg("istioctl call")
     rExec := sync.NewExec(fmt.Sprintf("%s/istioctl",i.IstioPath),args)
-    x, execErr := rExec.Run("")
-    log.Debug().Str("istioctl",x.Output).Msg("output from istioctl")
+    x, execErr := rExec.Run(ctx, "")
+    if x != nil {
+        log.Debug().Str("istioctl",x.Output).Msg("output from istioctl")
+    }
     if execErr != nil {
         log.Error().Err(execErr).Msg("error when executing istioctl")
         return execErr
@@ -657,6 +661,10 @@ func (i *InstallIstio) installInSlave() derrors.Error {
 // installGateway to provide the master with a gateway entry point for master
 func (i *InstallIstio) installGateway() derrors.Error {
     gw := istioNetworking.Gateway{
+        TypeMeta: metaV1.TypeMeta{
+            Kind:       "Gateway",
+            APIVersion: "networking.istio.io/v1alpha3",
+        },
         ObjectMeta: metaV1.ObjectMeta{
             Name: "cluster-aware-gateway",
             Namespace: IstioNamespace,
@@ -683,6 +691,10 @@ func (i *InstallIstio) installGateway() derrors.Error {
         },
     }
 
+    if i.DryRun() {
+        return i.Record(entities.CreateAction, &gw)
+    }
+
     _, err := i.Istio.NetworkingV1alpha3().Gateways(IstioNamespace).Create(&gw)
     if err != nil {
         return derrors.NewInternalError("error generating error", err)
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the Istio commands.
+
+package istio
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+func init() {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.InstallIstio,
+		Description: "Installs Istio with a control plane shared by the clusters",
+		FromJSON:    NewInstallIstioFromJSON,
+		Parameters: entities.ParametersOf(&InstallIstio{}, k8s.WithKubernetesParameters(map[string]string{
+			"istio_path":        "Directory with the Istio release",
+			"cluster_id":        "Identifier of the cluster",
+			"is_appCluster":     "Whether the cluster is an application cluster",
+			"static_ip_address": "Static IP address of the ingress gateway",
+			"temp_path":         "Directory for the temporary files",
+			"dns_public_host":   "Public host of the DNS server",
+		})),
+	})
+}
@@ -66,11 +66,11 @@ func NewAddClusterUserFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 	return &r, nil
 }
 
-func (acu *AddClusterUser) getRoleId(client grpc_user_manager_go.UserManagerClient) (string, derrors.Error) {
+func (acu *AddClusterUser) getRoleId(ctx context.Context, client grpc_user_manager_go.UserManagerClient) (string, derrors.Error) {
 	orgID := &grpc_organization_go.OrganizationId{
 		OrganizationId: acu.OrganizationID,
 	}
-	roles, err := client.ListRoles(context.Background(), orgID)
+	roles, err := client.ListRoles(ctx, orgID)
 	if err != nil {
 		return "", conversions.ToDerror(err)
 	}
@@ -83,7 +83,7 @@ func (acu *AddClusterUser) getRoleId(client grpc_user_manager_go.UserManagerClie
 	return "", derrors.NewNotFoundError("cannot find AppCluster role")
 }
 
-func (acu *AddClusterUser) createNewUser(roleID string, client grpc_user_manager_go.UserManagerClient) (string, string, derrors.Error) {
+func (acu *AddClusterUser) createNewUser(ctx context.Context, roleID string, client grpc_user_manager_go.UserManagerClient) (string, string, derrors.Error) {
 
 	addUserRequest := &grpc_user_manager_go.AddUserRequest{
 		OrganizationId: acu.OrganizationID,
@@ -93,7 +93,7 @@ func (acu *AddClusterUser) createNewUser(roleID string, client grpc_user_manager
 		RoleId:         roleID,
 	}
 
-	added, err := client.AddUser(context.Background(), addUserRequest)
+	added, err := client.AddUser(ctx, addUserRequest)
 
 	if err != nil {
 		return "", "", conversions.ToDerror(err)
@@ -126,9 +126,9 @@ func (acu *AddClusterUser) storeClusterUserCredentials(email string, password st
 	return nil
 }
 
-func (acu *AddClusterUser) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (acu *AddClusterUser) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 
-	connectErr := acu.Connect()
+	connectErr := acu.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -140,13 +140,13 @@ func (acu *AddClusterUser) Run(workflowID string) (*entities.CommandResult, derr
 	defer umConn.Close()
 	client := grpc_user_manager_go.NewUserManagerClient(umConn)
 
-	roleId, dErr := acu.getRoleId(client)
+	roleId, dErr := acu.getRoleId(ctx, client)
 	if dErr != nil {
 		return entities.NewCommandResult(
 			false, "cannot determine cluster role", dErr), nil
 	}
 
-	email, password, dErr := acu.createNewUser(roleId, client)
+	email, password, dErr := acu.createNewUser(ctx, roleId, client)
 	if dErr != nil {
 		return entities.NewCommandResult(
 			false, "cannot add cluster user", dErr), nil
@@ -157,7 +157,7 @@ func (acu *AddClusterUser) Run(workflowID string) (*entities.CommandResult, derr
 		return entities.NewCommandResult(
 			false, "cannot determine store cluster credentials", dErr), nil
 	}
-	return entities.NewSuccessCommand([]byte("cluster credentials has been created")), nil
+	return acu.ApplyResult("cluster credentials has been created"), nil
 }
 
 func (acu *AddClusterUser) String() string {
@@ -40,7 +40,7 @@ var _ = ginkgo.Describe("A create cluster user command", func(){
 
 	ginkgo.FIt("should be able to create the cluster user", func(){
 		acu := NewAddClusterUser(itKubeConfigFile, "organizationID", "clusterID", userManagerAddress)
-		result, err := acu.Run("addClusterUser")
+		result, err := acu.Run(context.Background(), "addClusterUser")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 		secret := testChecker.GetSecret(ClusterUserSecretName, "nalej")
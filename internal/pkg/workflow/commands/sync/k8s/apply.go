@@ -0,0 +1,325 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the apply modes that determine how the objects of a command are sent to Kubernetes
+//
+// The create mode fails if an object already exists. The update and apply modes make the commands idempotent so a
+// workflow can be executed again after a partial failure: the update mode retrieves the current object and patches
+// it if it differs from the desired one, and the apply mode uses server-side apply with the field manager of the
+// installer.
+
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sync"
+)
+
+// ApplyMode defines how the objects are sent to Kubernetes.
+type ApplyMode string
+
+// CreateMode creates the objects failing if they already exist. This is the default mode.
+const CreateMode ApplyMode = "create"
+
+// UpdateMode creates the objects that do not exist, and patches the existing ones that differ.
+const UpdateMode ApplyMode = "update"
+
+// ServerSideApplyMode sends the objects with server-side apply, taking the ownership of their fields.
+const ServerSideApplyMode ApplyMode = "apply"
+
+// DefaultFieldManager is the name of the manager of the fields sent by the installer.
+const DefaultFieldManager = "nalej-installer"
+
+// ValidApplyModes contains the supported apply modes.
+var ValidApplyModes = []ApplyMode{CreateMode, UpdateMode, ServerSideApplyMode}
+
+// ApplyCounts structure with the result of the objects sent by a command.
+type ApplyCounts struct {
+	// Created with the number of objects that did not exist.
+	Created int `json:"created"`
+	// Updated with the number of existing objects that have been modified.
+	Updated int `json:"updated"`
+	// Unchanged with the number of existing objects that already had the desired state.
+	Unchanged int `json:"unchanged"`
+}
+
+func (ac ApplyCounts) String() string {
+	return fmt.Sprintf("created %d, updated %d, unchanged %d", ac.Created, ac.Updated, ac.Unchanged)
+}
+
+// applyCounter keeps the counts of the objects sent by a command. The objects of a command may be sent
+// concurrently, so the counts are updated under a lock.
+type applyCounter struct {
+	sync.Mutex
+	counts ApplyCounts
+}
+
+// ValidateApplyMode checks that the apply mode of the command is supported.
+func (k *Kubernetes) ValidateApplyMode() derrors.Error {
+	if k.ApplyMode == "" {
+		return nil
+	}
+	for _, mode := range ValidApplyModes {
+		if k.ApplyMode == mode {
+			return nil
+		}
+	}
+	return derrors.NewInvalidArgumentError(errors.InvalidApplyMode).WithParams(k.ApplyMode)
+}
+
+// Counts returns the objects created, updated and unchanged by the command since it connected.
+func (k *Kubernetes) Counts() ApplyCounts {
+	if k.counter == nil {
+		return ApplyCounts{}
+	}
+	k.counter.Lock()
+	defer k.counter.Unlock()
+	return k.counter.counts
+}
+
+// ApplyResult creates a successful result reporting the objects sent by the command.
+func (k *Kubernetes) ApplyResult(msg string) *entities.CommandResult {
+	counts := k.Counts()
+	return entities.NewCommandResult(true, fmt.Sprintf("%s (%s)", msg, counts.String()), nil)
+}
+
+// countCreated adds a created object to the counts.
+func (k *Kubernetes) countCreated() {
+	k.count(func(counts *ApplyCounts) { counts.Created++ })
+}
+
+// countUpdated adds an updated object to the counts.
+func (k *Kubernetes) countUpdated() {
+	k.count(func(counts *ApplyCounts) { counts.Updated++ })
+}
+
+// countUnchanged adds an unchanged object to the counts.
+func (k *Kubernetes) countUnchanged() {
+	k.count(func(counts *ApplyCounts) { counts.Unchanged++ })
+}
+
+// count updates the counts under the lock of the counter. The counts are not kept if the command is not connected.
+func (k *Kubernetes) count(update func(counts *ApplyCounts)) {
+	if k.counter == nil {
+		return
+	}
+	k.counter.Lock()
+	update(&k.counter.counts)
+	k.counter.Unlock()
+}
+
+func (k *Kubernetes) applyMode() ApplyMode {
+	if k.ApplyMode == "" {
+		return CreateMode
+	}
+	return k.ApplyMode
+}
+
+func (k *Kubernetes) fieldManager() string {
+	if k.FieldManager == "" {
+		return DefaultFieldManager
+	}
+	return k.FieldManager
+}
+
+// apply sends an object to Kubernetes following the apply mode of the command.
+func (k *Kubernetes) apply(client dynamic.ResourceInterface, obj *unstructured.Unstructured) derrors.Error {
+	mode := k.applyMode()
+	// Objects with a generated name cannot be retrieved before being created.
+	if mode == CreateMode || obj.GetName() == "" {
+		return k.createObject(client, obj)
+	}
+
+	existing, err := client.Get(obj.GetName(), metaV1.GetOptions{})
+	if err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return derrors.NewInternalError("cannot retrieve object", err).WithParams(obj.GetKind(), obj.GetName())
+		}
+		existing = nil
+	}
+
+	if k.DryRun() {
+		switch {
+		case existing == nil:
+			k.countCreated()
+			return k.Record(entities.CreateAction, obj)
+		case MatchesObject(withoutRequestID(obj), existing):
+			k.countUnchanged()
+			return nil
+		default:
+			k.countUpdated()
+			return k.Record(entities.UpdateAction, obj)
+		}
+	}
+
+	if mode == UpdateMode {
+		if existing == nil {
+			return k.createObject(client, obj)
+		}
+		if MatchesObject(withoutRequestID(obj), existing) {
+			log.Debug().Str("kind", obj.GetKind()).Str("name", obj.GetName()).Msg("object is up to date")
+			k.countUnchanged()
+			return nil
+		}
+		return k.patchObject(client, obj, types.MergePatchType, nil)
+	}
+
+	force := true
+	applied, derr := k.sendPatch(client, obj, types.ApplyPatchType, &force)
+	if derr != nil {
+		return derr
+	}
+	switch {
+	case existing == nil:
+		k.countCreated()
+	case applied.GetResourceVersion() == existing.GetResourceVersion():
+		k.countUnchanged()
+	default:
+		k.countUpdated()
+	}
+	log.Debug().Str("resource", applied.GetSelfLink()).Msg("applied")
+	return nil
+}
+
+// createObject creates a new object failing if it already exists.
+func (k *Kubernetes) createObject(client dynamic.ResourceInterface, obj *unstructured.Unstructured) derrors.Error {
+	log.Debug().Interface("obj", obj).Msg("creating resource")
+	created, err := client.Create(obj, metaV1.CreateOptions{FieldManager: k.fieldManager()})
+	if err != nil {
+		log.Error().Err(err).Msg("unable to crate kubernetes object")
+		return derrors.NewInternalError("unable to create object", err).WithParams(obj)
+	}
+	k.countCreated()
+	log.Debug().Str("resource", created.GetSelfLink()).Msg("created")
+	return nil
+}
+
+// patchObject patches an existing object with the desired state.
+func (k *Kubernetes) patchObject(client dynamic.ResourceInterface, obj *unstructured.Unstructured, patchType types.PatchType, force *bool) derrors.Error {
+	patched, err := k.sendPatch(client, obj, patchType, force)
+	if err != nil {
+		return err
+	}
+	k.countUpdated()
+	log.Debug().Str("resource", patched.GetSelfLink()).Msg("updated")
+	return nil
+}
+
+func (k *Kubernetes) sendPatch(client dynamic.ResourceInterface, obj *unstructured.Unstructured, patchType types.PatchType, force *bool) (*unstructured.Unstructured, derrors.Error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, derrors.NewInternalError("cannot marshal object", err).WithParams(obj.GetKind(), obj.GetName())
+	}
+	opts := metaV1.PatchOptions{FieldManager: k.fieldManager(), Force: force}
+	patched, err := client.Patch(obj.GetName(), patchType, data, opts)
+	if err != nil {
+		log.Error().Err(err).Str("kind", obj.GetKind()).Str("name", obj.GetName()).Msg("unable to patch kubernetes object")
+		return nil, derrors.NewInternalError("unable to patch object", err).WithParams(obj.GetKind(), obj.GetName())
+	}
+	return patched, nil
+}
+
+// MatchesObject checks if an existing object already contains the desired state. The fields that are not set in
+// the desired object, such as the ones filled by Kubernetes, are ignored.
+//
+//	params:
+//	  desired The object to be sent.
+//	  existing The object found in the cluster.
+//	returns:
+//	  Whether the existing object does not need to be updated.
+func MatchesObject(desired *unstructured.Unstructured, existing *unstructured.Unstructured) bool {
+	target := desired.UnstructuredContent()
+	if desired.GetKind() == "Secret" {
+		target = secretWithData(target)
+	}
+	return matchesValue(target, existing.UnstructuredContent())
+}
+
+// secretWithData moves the stringData of a secret into its data as Kubernetes does when storing it.
+func secretWithData(secret map[string]interface{}) map[string]interface{} {
+	stringData, exists := secret["stringData"].(map[string]interface{})
+	if !exists || len(stringData) == 0 {
+		return secret
+	}
+	result := make(map[string]interface{}, len(secret))
+	for key, value := range secret {
+		if key != "stringData" {
+			result[key] = value
+		}
+	}
+	data := make(map[string]interface{}, 0)
+	if current, exists := secret["data"].(map[string]interface{}); exists {
+		for key, value := range current {
+			data[key] = value
+		}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(value)))
+	}
+	result["data"] = data
+	return result
+}
+
+// matchesValue checks if the desired value is contained in the existing one.
+func matchesValue(desired interface{}, existing interface{}) bool {
+	switch value := desired.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		current, ok := existing.(map[string]interface{})
+		if !ok {
+			return len(value) == 0
+		}
+		for key, element := range value {
+			if !matchesValue(element, current[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		current, ok := existing.([]interface{})
+		if !ok {
+			return len(value) == 0
+		}
+		if len(value) != len(current) {
+			return false
+		}
+		for index, element := range value {
+			if !matchesValue(element, current[index]) {
+				return false
+			}
+		}
+		return true
+	default:
+		if existing == nil {
+			return false
+		}
+		// Numbers may be decoded with different types.
+		return fmt.Sprint(desired) == fmt.Sprint(existing)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the applyManifest command that creates the Kubernetes objects defined in the workflow
+//
+// The objects are embedded in the command as JSON objects or as a YAML stream, so the workflow template substitutes
+// its parameters in them. The referenced files are rendered as templates with the params of the command.
+
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"strings"
+	"text/template"
+)
+
+// ApplyManifest command that creates a set of Kubernetes objects defined inline or in files.
+type ApplyManifest struct {
+	Kubernetes
+	// Objects with the objects to be created in JSON.
+	Objects []json.RawMessage `json:"objects"`
+	// Manifest with a YAML stream of objects separated by ---.
+	Manifest string `json:"manifest"`
+	// Files with the paths of YAML or JSON files with objects.
+	Files []string `json:"files"`
+	// Params with the values available to the files as a Go template, such as {{.namespace}}.
+	Params map[string]string `json:"params"`
+	// Concurrency with the maximum number of objects of the same tier created at the same time.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// NewApplyManifest creates a new ApplyManifest command.
+func NewApplyManifest(kubeConfigPath string, manifest string) *ApplyManifest {
+	return &ApplyManifest{
+		Kubernetes: Kubernetes{
+			GenericSyncCommand: *entities.NewSyncCommand(entities.ApplyManifest),
+			KubeConfigPath:     kubeConfigPath,
+		},
+		Manifest: manifest,
+	}
+}
+
+// NewApplyManifestFromJSON creates an ApplyManifest command from its JSON definition.
+func NewApplyManifestFromJSON(raw []byte) (*entities.Command, derrors.Error) {
+	am := &ApplyManifest{}
+	if err := json.Unmarshal(raw, &am); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if len(am.Objects) == 0 && am.Manifest == "" && len(am.Files) == 0 {
+		return nil, derrors.NewInvalidArgumentError(errors.EmptyManifest).WithParams(am.Name())
+	}
+	am.CommandID = entities.GenerateCommandID(am.Name())
+	var r entities.Command = am
+	return &r, nil
+}
+
+// ReadObjects decodes the inline objects, the manifest and the files of the command in that order.
+func (am *ApplyManifest) ReadObjects() ([]*unstructured.Unstructured, derrors.Error) {
+	result := make([]*unstructured.Unstructured, 0)
+	for index, raw := range am.Objects {
+		obj := &unstructured.Unstructured{}
+		err := obj.UnmarshalJSON(raw)
+		if err != nil {
+			return nil, derrors.NewInvalidArgumentError("cannot parse objects", err).WithParams("objects", index)
+		}
+		result = append(result, obj)
+	}
+	if am.Manifest != "" {
+		decoded, err := DecodeObjects(strings.NewReader(am.Manifest), "manifest")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, decoded...)
+	}
+	for _, filePath := range am.Files {
+		rendered, err := am.renderFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := DecodeObjects(bytes.NewReader(rendered), filePath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, decoded...)
+	}
+	return result, nil
+}
+
+// renderFile reads a file applying the params of the command. Missing params are reported as errors.
+func (am *ApplyManifest) renderFile(filePath string) ([]byte, derrors.Error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, derrors.NewPermissionDeniedError("cannot read manifest file", err).WithParams(filePath)
+	}
+	tmpl, err := template.New(filePath).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.CannotParseTemplate, err).WithParams(filePath)
+	}
+	buf := new(bytes.Buffer)
+	params := am.Params
+	if params == nil {
+		params = make(map[string]string, 0)
+	}
+	err = tmpl.Execute(buf, params)
+	if err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.CannotApplyTemplate, err).WithParams(filePath)
+	}
+	return buf.Bytes(), nil
+}
+
+func (am *ApplyManifest) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := am.ConnectWithContext(ctx)
+	if connectErr != nil {
+		return nil, connectErr
+	}
+	objects, err := am.ReadObjects()
+	if err != nil {
+		return entities.NewCommandResult(false, "cannot read manifest", err), nil
+	}
+	err = am.ApplyInTiers(ctx, objects, am.Concurrency, func(obj *unstructured.Unstructured) derrors.Error {
+		log.Debug().Str("object", describeObject(obj)).Msg("applying manifest object")
+		return am.Create(obj)
+	})
+	if err != nil {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return entities.NewCommandResult(false, "cannot apply manifest", err), nil
+	}
+	return am.ApplyResult(fmt.Sprintf("%d objects have been applied", len(objects))), nil
+}
+
+func (am *ApplyManifest) String() string {
+	return fmt.Sprintf("SYNC ApplyManifest with %d objects and %d files", len(am.Objects), len(am.Files))
+}
+
+func (am *ApplyManifest) PrettyPrint(indentation int) string {
+	simpleIden := strings.Repeat(" ", indentation) + "  "
+	entrySep := simpleIden + "  "
+	oStr := ""
+	objects, err := am.ReadObjects()
+	if err != nil {
+		oStr = oStr + "\n" + entrySep + "<invalid>"
+	} else {
+		for _, obj := range objects {
+			oStr = oStr + "\n" + entrySep + describeObject(obj)
+		}
+	}
+	return strings.Repeat(" ", indentation) + am.String() + oStr
+}
+
+func (am *ApplyManifest) UserString() string {
+	return "Applying Kubernetes manifest"
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const testManifestFile = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.name}}
+  namespace: {{.namespace}}
+`
+
+var _ = ginkgo.Describe("ApplyManifest", func() {
+
+	var tmpDir string
+
+	ginkgo.BeforeEach(func() {
+		dir, err := ioutil.TempDir("", "manifest")
+		gomega.Expect(err).To(gomega.Succeed())
+		tmpDir = dir
+	})
+
+	ginkgo.AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	ginkgo.It("should read the objects in order", func() {
+		filePath := path.Join(tmpDir, "service.yaml")
+		gomega.Expect(ioutil.WriteFile(filePath, []byte(testManifestFile), 0644)).To(gomega.Succeed())
+		raw := `{"type":"sync", "name":"applyManifest", "kubeConfigPath":"/tmp/config",
+			"objects":[{"apiVersion":"v1", "kind":"Namespace", "metadata":{"name":"nalej"}}],
+			"manifest":"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  namespace: nalej\n---\n",
+			"files":["` + filePath + `"], "params":{"name":"dns", "namespace":"nalej"}}`
+		cmd, err := NewApplyManifestFromJSON([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		objects, err := (*cmd).(*ApplyManifest).ReadObjects()
+		gomega.Expect(err).To(gomega.BeNil())
+		described := make([]string, 0)
+		for _, obj := range objects {
+			described = append(described, describeObject(obj))
+		}
+		gomega.Expect(described).To(gomega.Equal([]string{"Namespace nalej", "ConfigMap nalej/config", "Service nalej/dns"}))
+	})
+
+	ginkgo.It("should fail on missing params", func() {
+		filePath := path.Join(tmpDir, "service.yaml")
+		gomega.Expect(ioutil.WriteFile(filePath, []byte(testManifestFile), 0644)).To(gomega.Succeed())
+		am := NewApplyManifest("/tmp/config", "")
+		am.Files = []string{filePath}
+		am.Params = map[string]string{"name": "dns"}
+		_, err := am.ReadObjects()
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+	ginkgo.It("should reject commands without objects", func() {
+		_, err := NewApplyManifestFromJSON([]byte(`{"type":"sync", "name":"applyManifest", "kubeConfigPath":"/tmp/config"}`))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+})
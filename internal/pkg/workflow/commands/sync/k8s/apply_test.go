@@ -0,0 +1,95 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func toUnstructured(obj runtime.Object) *unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	gomega.Expect(err).To(gomega.Succeed())
+	return &unstructured.Unstructured{Object: content}
+}
+
+func testConfigMap(data map[string]string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		TypeMeta:   metaV1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metaV1.ObjectMeta{Name: "config", Namespace: "nalej"},
+		Data:       data,
+	}
+}
+
+var _ = ginkgo.Describe("Apply modes", func() {
+
+	ginkgo.It("should ignore the fields set by Kubernetes", func() {
+		desired := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		existing := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		existing.SetResourceVersion("42")
+		existing.SetUID("uid")
+		existing.SetLabels(map[string]string{"other": "label"})
+		gomega.Expect(MatchesObject(desired, existing)).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("should detect modified fields", func() {
+		desired := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		existing := toUnstructured(testConfigMap(map[string]string{"key": "previous"}))
+		gomega.Expect(MatchesObject(desired, existing)).To(gomega.BeFalse())
+		missing := toUnstructured(testConfigMap(map[string]string{}))
+		gomega.Expect(MatchesObject(desired, missing)).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should compare the string data of the secrets", func() {
+		secret := &v1.Secret{
+			TypeMeta:   metaV1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+			ObjectMeta: metaV1.ObjectMeta{Name: "secret", Namespace: "nalej"},
+			StringData: map[string]string{"key": "value"},
+		}
+		stored := &v1.Secret{
+			TypeMeta:   metaV1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+			ObjectMeta: metaV1.ObjectMeta{Name: "secret", Namespace: "nalej"},
+			Data:       map[string][]byte{"key": []byte("value")},
+		}
+		gomega.Expect(MatchesObject(toUnstructured(secret), toUnstructured(stored))).To(gomega.BeTrue())
+		stored.Data["key"] = []byte("other")
+		gomega.Expect(MatchesObject(toUnstructured(secret), toUnstructured(stored))).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should validate the apply mode", func() {
+		k := &Kubernetes{}
+		gomega.Expect(k.ValidateApplyMode()).To(gomega.BeNil())
+		gomega.Expect(k.applyMode()).To(gomega.Equal(CreateMode))
+		k.ApplyMode = UpdateMode
+		gomega.Expect(k.ValidateApplyMode()).To(gomega.BeNil())
+		k.ApplyMode = "replace"
+		gomega.Expect(k.ValidateApplyMode()).ToNot(gomega.BeNil())
+	})
+
+	ginkgo.It("should report the counts in the result", func() {
+		k := &Kubernetes{counter: &applyCounter{counts: ApplyCounts{Created: 2, Updated: 1, Unchanged: 3}}}
+		result := k.ApplyResult("done")
+		gomega.Expect(result.Success).To(gomega.BeTrue())
+		gomega.Expect(result.Output).To(gomega.Equal("done (created 2, updated 1, unchanged 3)"))
+	})
+
+})
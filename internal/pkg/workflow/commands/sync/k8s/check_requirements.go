@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -79,9 +80,9 @@ func (cr *CheckRequirements) CheckVersion(major string, minor string) bool {
 	return (majorServer >= majorRequired) && (minorServer >= minorRequired)
 }
 
-func (cr *CheckRequirements) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (cr *CheckRequirements) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 
-	connectErr := cr.Connect()
+	connectErr := cr.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
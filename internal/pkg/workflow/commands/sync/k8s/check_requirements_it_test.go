@@ -27,6 +27,7 @@ IT_K8S_KUBECONFIG=/Users/daniel/.kube/config
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -46,7 +47,7 @@ var _ = ginkgo.Describe("A check requirements command", func() {
 
 	ginkgo.It("should pass the requirements on a common config", func() {
 		cr := NewCheckRequirements("1.9", itKubeConfigFile)
-		result, err := cr.Run("checkRequirements")
+		result, err := cr.Run(context.Background(), "checkRequirements")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result).ShouldNot(gomega.BeNil())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
@@ -19,6 +19,7 @@ package k8s
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -149,8 +150,8 @@ func (cc *CreateCACert) createCertSecret() derrors.Error {
 	return nil
 }
 
-func (cc *CreateCACert) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := cc.Connect()
+func (cc *CreateCACert) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := cc.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -174,7 +175,7 @@ func (cc *CreateCACert) Run(workflowID string) (*entities.CommandResult, derrors
 		return entities.NewCommandResult(false, "cannot create CA certificate secret", err), nil
 	}
 
-	return entities.NewSuccessCommand([]byte("CA cert created an installed on cluster")), nil
+	return cc.ApplyResult("CA cert created an installed on cluster"), nil
 }
 
 func (cc *CreateCACert) String() string {
@@ -23,6 +23,7 @@ IT_K8S_KUBECONFIG=/Users/daniel/.kube/config
 */
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -48,7 +49,7 @@ var _ = ginkgo.Describe("A create CA certificate command", func() {
 	ginkgo.It("should be able to create the CA certificate", func() {
 		cc := NewCreateCACert(
 			itKubeConfigFile, "nalej39.nalej.tech")
-		result, err := cc.Run("createCACert")
+		result, err := cc.Run(context.Background(), "createCACert")
 		if err != nil {
 			log.Error().Str("trace", err.DebugReport()).Msg("failed")
 		}
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/rs/zerolog/log"
@@ -76,8 +77,8 @@ func NewCreateClusterConfigFromJSON(raw []byte) (*entities.Command, derrors.Erro
 	return &r, nil
 }
 
-func (ccc *CreateClusterConfig) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := ccc.Connect()
+func (ccc *CreateClusterConfig) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := ccc.ConnectWithContext(ctx)
 	if connectErr != nil {
 		log.Error().Str("connection error", connectErr.DebugReport()).Str("connection error", connectErr.DebugReport())
 		return nil, connectErr
@@ -131,7 +132,7 @@ func (ccc *CreateClusterConfig) Run(workflowID string) (*entities.CommandResult,
 	if derr != nil {
 		return entities.NewCommandResult(false, "cannot create cluster config", derr), nil
 	}
-	return entities.NewSuccessCommand([]byte("cluster config has been created")), nil
+	return ccc.ApplyResult("cluster config has been created"), nil
 }
 
 func (ccc *CreateClusterConfig) String() string {
@@ -23,6 +23,7 @@ IT_K8S_KUBECONFIG=/Users/daniel/.kube/config
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -52,7 +53,7 @@ var _ = ginkgo.Describe("A create cluster config command", func() {
 			"clusterPublicHostname",
 			"dnsPublicHost", "53",
 			"MINIKUBE")
-		result, err := ccc.Run("createClusterConfig")
+		result, err := ccc.Run(context.Background(), "createClusterConfig")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 	})
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -75,8 +76,8 @@ func (cmd *CreateDockerSecret) getDockerConfigJSON() string {
 	return toEncode
 }
 
-func (cmd *CreateDockerSecret) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := cmd.Connect()
+func (cmd *CreateDockerSecret) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := cmd.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -106,7 +107,7 @@ func (cmd *CreateDockerSecret) Run(workflowID string) (*entities.CommandResult,
 		return entities.NewCommandResult(
 			false, "cannot create docker registry credentials", derrors.AsError(derr, "cannot create registry credentials")), nil
 	}
-	return entities.NewSuccessCommand([]byte("docker registry credentials have been created")), nil
+	return cmd.ApplyResult("docker registry credentials have been created"), nil
 }
 
 func (cmd *CreateDockerSecret) String() string {
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -120,8 +121,8 @@ func (cmc *CreateManagementConfig) createAuthSecret() derrors.Error {
 	return nil
 }
 
-func (cmc *CreateManagementConfig) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := cmc.Connect()
+func (cmc *CreateManagementConfig) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := cmc.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -143,7 +144,7 @@ func (cmc *CreateManagementConfig) Run(workflowID string) (*entities.CommandResu
 			false, "cannot create management config", err), nil
 	}
 
-	return entities.NewSuccessCommand([]byte("management cluster config has been created")), nil
+	return cmc.ApplyResult("management cluster config has been created"), nil
 }
 
 func (cmc *CreateManagementConfig) String() string {
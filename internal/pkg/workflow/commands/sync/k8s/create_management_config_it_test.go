@@ -23,6 +23,7 @@ IT_K8S_KUBECONFIG=/Users/daniel/.kube/config
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -49,7 +50,7 @@ var _ = ginkgo.Describe("A create management config command", func() {
 		cmc := NewCreateManagementConfig(
 			itKubeConfigFile, "publicHost", "publicPort",
 			"MINIKUBE", "PRODUCTION")
-		result, err := cmc.Run("createManagementConfig")
+		result, err := cmc.Run(context.Background(), "createManagementConfig")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 	})
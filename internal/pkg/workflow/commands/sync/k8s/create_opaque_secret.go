@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -106,8 +107,8 @@ func (cmd *CreateOpaqueSecret) createKubernetesSecrets() derrors.Error {
 }
 
 // Run triggers the execution of the command.
-func (cmd *CreateOpaqueSecret) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	err := cmd.Connect()
+func (cmd *CreateOpaqueSecret) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	err := cmd.ConnectWithContext(ctx)
 	if err != nil {
 		log.Info().Str("kubeConfigPath", cmd.KubeConfigPath).Msg("error connecting to cluster")
 		return nil, derrors.NewGenericError("error connecting to cluster", err)
@@ -118,7 +119,7 @@ func (cmd *CreateOpaqueSecret) Run(workflowID string) (*entities.CommandResult,
 		return nil, dErr
 	}
 
-	return entities.NewSuccessCommand([]byte("Secret successfully created.")), nil
+	return cmd.ApplyResult("Secret successfully created."), nil
 }
 
 func (cmd *CreateOpaqueSecret) String() string {
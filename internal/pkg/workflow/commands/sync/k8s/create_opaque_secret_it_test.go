@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -59,7 +60,7 @@ var _ = ginkgo.Describe("A Create Opaque Secret command", func() {
 	ginkgo.It("should be able to create the secret", func() {
 		// Create secret in Kubernetes
 		cmd := NewCreateOpaqueSecret(itKubeConfigFile, "zt-planet", "planet", "AQAAAH", false, "")
-		result, err := cmd.Run("createZtPlanetFiles")
+		result, err := cmd.Run(context.Background(), "createZtPlanetFiles")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 		// Retrieve secret from kubernetes
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -97,13 +98,13 @@ func (cmd *CreateRegistrySecrets) createEnvironmentSecret() derrors.Error {
 	return nil
 }
 
-func (cmd *CreateRegistrySecrets) createDockerSecrets(workflowID string) derrors.Error {
+func (cmd *CreateRegistrySecrets) createDockerSecrets(ctx context.Context, workflowID string) derrors.Error {
 	// Reuse the existing create docker secret commands
 
 	// Create the production secret
 	secret := NewCreateDockerSecret(cmd.KubeConfigPath, cmd.CredentialsName,
 		cmd.Username, cmd.Password, cmd.URL)
-	result, err := secret.Run(workflowID)
+	result, err := secret.Run(ctx, workflowID)
 	if err != nil {
 		return err
 	}
@@ -114,8 +115,8 @@ func (cmd *CreateRegistrySecrets) createDockerSecrets(workflowID string) derrors
 	return nil
 }
 
-func (cmd *CreateRegistrySecrets) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := cmd.Connect()
+func (cmd *CreateRegistrySecrets) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := cmd.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -131,13 +132,13 @@ func (cmd *CreateRegistrySecrets) Run(workflowID string) (*entities.CommandResul
 			return entities.NewCommandResult(false, "cannot create environment secret", sErr), nil
 		}
 	}
-	sErr := cmd.createDockerSecrets(workflowID)
+	sErr := cmd.createDockerSecrets(ctx, workflowID)
 	if sErr != nil {
 		return entities.NewCommandResult(false, "cannot create docker registry secret", sErr), nil
 	}
 	// Create Docker secrets
 	log.Debug().Msg("management registry secret has been created")
-	return entities.NewSuccessCommand([]byte("management registry secret has been created")), nil
+	return cmd.ApplyResult("management registry secret has been created"), nil
 }
 
 func (cmd *CreateRegistrySecrets) String() string {
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -109,8 +110,8 @@ func (cmd *CreateTLSSecret) createKubernetesSecrets() derrors.Error {
 }
 
 // Run triggers the execution of the command.
-func (cmd *CreateTLSSecret) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	err := cmd.Connect()
+func (cmd *CreateTLSSecret) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	err := cmd.ConnectWithContext(ctx)
 	if err != nil {
 		log.Info().Str("kubeConfigPath", cmd.KubeConfigPath).Msg("error connecting to cluster")
 		return nil, derrors.NewGenericError("error connecting to cluster", err)
@@ -122,7 +123,7 @@ func (cmd *CreateTLSSecret) Run(workflowID string) (*entities.CommandResult, der
 		return nil, dErr
 	}
 
-	return entities.NewSuccessCommand([]byte("Secret successfully created.")), nil
+	return cmd.ApplyResult("Secret successfully created."), nil
 }
 
 func (cmd *CreateTLSSecret) String() string {
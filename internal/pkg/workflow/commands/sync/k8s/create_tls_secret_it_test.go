@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -46,7 +47,7 @@ var _ = ginkgo.Describe("A Create TLS Secret command", func() {
 	ginkgo.It("should be able to create the secret", func() {
 		// Create secret in Kubernetes
 		cmd := NewCreateTLSSecret(itKubeConfigFile, "tls-secret", "", "AQAAAH")
-		result, err := cmd.Run("createTLSSecret")
+		result, err := cmd.Run(context.Background(), "createTLSSecret")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 		// Retrieve secret from kubernetes
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -73,8 +74,8 @@ func (cc *CreateCredentials) getDockerConfigJSON() string {
 	return toEncode
 }
 
-func (cc *CreateCredentials) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := cc.Connect()
+func (cc *CreateCredentials) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := cc.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -104,7 +105,7 @@ func (cc *CreateCredentials) Run(workflowID string) (*entities.CommandResult, de
 		return entities.NewCommandResult(
 			false, "cannot create registry credentials", derrors.AsError(derr, "cannot create registry credentials")), nil
 	}
-	return entities.NewSuccessCommand([]byte("registry credentials have been created")), nil
+	return cc.ApplyResult("registry credentials have been created"), nil
 }
 
 func (cc *CreateCredentials) String() string {
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -42,7 +43,7 @@ var _ = ginkgo.Describe("A create credentials command", func() {
 
 	ginkgo.It("should be able to create the config", func() {
 		uc := NewCreateCredentials(itKubeConfigFile, itRegistryUsername, itRegistryPassword)
-		result, err := uc.Run("createCredentials")
+		result, err := uc.Run(context.Background(), "createCredentials")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 	})
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -60,8 +61,8 @@ func NewDeleteClusterRoleFromJSON(raw []byte) (*entities.Command, derrors.Error)
 }
 
 // Run the current command returning the result or an error.
-func (dcr *DeleteClusterRole) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dcr.Connect()
+func (dcr *DeleteClusterRole) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dcr.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -58,8 +59,8 @@ func NewDeleteClusterRoleBindingFromJSON(raw []byte) (*entities.Command, derrors
 }
 
 // Run the current command returning the result or an error.
-func (dcrb *DeleteClusterRoleBinding) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dcrb.Connect()
+func (dcrb *DeleteClusterRoleBinding) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dcrb.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -70,8 +71,8 @@ func NewDeleteNalejNamespaceFromJSON(raw []byte) (*entities.Command, derrors.Err
 }
 
 // Run the current command returning the result or an error.
-func (dnn *DeleteNalejNamespace) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dnn.Connect()
+func (dnn *DeleteNalejNamespace) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dnn.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -85,7 +86,7 @@ func (dnn *DeleteNalejNamespace) Run(workflowID string) (*entities.CommandResult
 	}
 	if exists {
 		// Delete ingresses
-		if err = dnn.DeleteAllEntities(NalejNamespace, "extensions", "v1beta1", "ingresses"); err != nil {
+		if err = dnn.DeleteAllOfKind(NalejNamespace, IngressKind, IngressGroups); err != nil {
 			return entities.NewErrCommand("cannot delete Nalej ingresses", err), nil
 		}
 		// Delete deployments
@@ -137,7 +138,7 @@ func (dnn *DeleteNalejNamespace) Run(workflowID string) (*entities.CommandResult
 			return entities.NewErrCommand("cannot delete Nalej events", err), nil
 		}
 		// CRD
-		if err = dnn.DeleteAllEntities("", "apiextensions.k8s.io", "v1beta1", "customresourcedefinitions", ExcludedCRDs...); err != nil {
+		if err = dnn.DeleteAllOfKind("", CRDKind, []string{CRDGroup}, ExcludedCRDs...); err != nil {
 			return entities.NewErrCommand("cannot delete Nalej CRD", err), nil
 		}
 	}
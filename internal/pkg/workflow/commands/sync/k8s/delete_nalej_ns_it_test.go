@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -42,7 +43,7 @@ var _ = ginkgo.Describe("A delete nalej namespace command", func() {
 
 	ginkgo.It("should be able to delete the contents of the nalej namespace", func() {
 		dsa := NewDeleteNalejNamespace(itKubeConfigFile)
-		result, err := dsa.Run("deleteNalejNamespace")
+		result, err := dsa.Run(context.Background(), "deleteNalejNamespace")
 		gomega.Expect(err).To(gomega.Succeed())
 		if !result.Success {
 			log.Debug().Interface("result", result).Msg("failed")
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -59,8 +60,8 @@ func NewDeleteNamespaceFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 }
 
 // Run the current command returning the result or an error.
-func (dn *DeleteNamespace) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dn.Connect()
+func (dn *DeleteNamespace) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dn.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/restmapper"
+	"strings"
+)
+
+// ownedObject structure with an object owned by the installer and its resource.
+type ownedObject struct {
+	resource schema.GroupVersionResource
+	obj      *unstructured.Unstructured
+}
+
+// DeleteOwnedResources structure with the attributes required to delete all the objects created by the installer.
+type DeleteOwnedResources struct {
+	// Kubernetes embedded object
+	Kubernetes
+	// ClusterID restricts the deletion to the objects installed for a cluster. All the owned objects are deleted
+	// if empty.
+	ClusterID string `json:"cluster_id"`
+	// PropagationPolicy determines how the dependents are deleted: Orphan, Background or Foreground.
+	PropagationPolicy string `json:"propagation_policy"`
+}
+
+// NewDeleteOwnedResources creates a new DeleteOwnedResources command.
+func NewDeleteOwnedResources(kubeConfigPath string, clusterID string) *DeleteOwnedResources {
+	return &DeleteOwnedResources{
+		Kubernetes: Kubernetes{
+			GenericSyncCommand: *entities.NewSyncCommand(entities.DeleteOwnedResources),
+			KubeConfigPath:     kubeConfigPath,
+		},
+		ClusterID: clusterID,
+	}
+}
+
+// NewDeleteOwnedResourcesFromJSON creates a new DeleteOwnedResources command from a raw JSON representation.
+func NewDeleteOwnedResourcesFromJSON(raw []byte) (*entities.Command, derrors.Error) {
+	dor := &DeleteOwnedResources{}
+	if err := json.Unmarshal(raw, &dor); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if dor.PropagationPolicy != "" {
+		valid := false
+		for _, policy := range ValidPropagationPolicies {
+			valid = valid || string(policy) == dor.PropagationPolicy
+		}
+		if !valid {
+			return nil, derrors.NewInvalidArgumentError(errors.InvalidDeleteResources).WithParams("propagation_policy", dor.PropagationPolicy)
+		}
+	}
+	dor.CommandID = entities.GenerateCommandID(dor.Name())
+	var r entities.Command = dor
+	return &r, nil
+}
+
+// Run the current command returning the result or an error.
+func (dor *DeleteOwnedResources) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dor.ConnectWithContext(ctx)
+	if connectErr != nil {
+		return nil, connectErr
+	}
+	owned, err := dor.listOwned(ctx)
+	if err != nil {
+		return entities.NewCommandResult(false, "cannot list owned resources", err), nil
+	}
+	options := &metaV1.DeleteOptions{}
+	if dor.PropagationPolicy != "" {
+		policy := metaV1.DeletionPropagation(dor.PropagationPolicy)
+		options.PropagationPolicy = &policy
+	}
+	for _, toDelete := range OrderForDeletion(owned) {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if dor.DryRun() {
+			dor.recordDelete(toDelete.resource, toDelete.obj.GetNamespace(), toDelete.obj.GetName())
+			continue
+		}
+		log.Debug().Str("object", describeObject(toDelete.obj)).Msg("deleting owned object")
+		client := dor.dynClient.Resource(toDelete.resource)
+		var err error
+		if toDelete.obj.GetNamespace() == "" {
+			err = client.Delete(toDelete.obj.GetName(), options)
+		} else {
+			err = client.Namespace(toDelete.obj.GetNamespace()).Delete(toDelete.obj.GetName(), options)
+		}
+		// The objects of a deleted namespace may be already gone.
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return entities.NewErrCommand("cannot delete owned resource",
+				derrors.NewInternalError("cannot delete entity", err).WithParams(toDelete.obj.GetNamespace(), toDelete.obj.GetName())), nil
+		}
+	}
+	return entities.NewSuccessCommand([]byte(fmt.Sprintf("%d owned resources deleted", len(owned)))), nil
+}
+
+// listOwned obtains the objects labelled as owned by the installer in all the resources that can be listed and
+// deleted, and the objects recorded in the inventory of the cluster.
+func (dor *DeleteOwnedResources) listOwned(ctx context.Context) ([]ownedObject, derrors.Error) {
+	lists, err := dor.discoveryClient.ServerPreferredResources()
+	if err != nil {
+		// The resources of the groups that cannot be discovered are not considered.
+		if !discovery.IsGroupDiscoveryFailedError(err) || lists == nil {
+			return nil, derrors.NewInternalError("cannot discover resources", err)
+		}
+		log.Warn().Err(err).Msg("some resource groups cannot be discovered")
+	}
+	lists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, lists)
+	selector := entities.OwnedSelector(dor.ClusterID)
+	result := make([]ownedObject, 0)
+	// The same objects may be served by several groups, such as the deployments in apps and extensions.
+	found := make(map[types.UID]bool, 0)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range list.APIResources {
+			if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+				return nil, ctxErr
+			}
+			resource := gv.WithResource(apiResource.Name)
+			items, err := dor.dynClient.Resource(resource).List(metaV1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				log.Warn().Err(err).Str("resource", resource.String()).Msg("cannot list resource")
+				continue
+			}
+			for index := range items.Items {
+				obj := &items.Items[index]
+				if found[obj.GetUID()] {
+					continue
+				}
+				found[obj.GetUID()] = true
+				result = append(result, ownedObject{resource: resource, obj: obj})
+			}
+		}
+	}
+	inventoried, iErr := dor.listInventoried(ctx, found)
+	if iErr != nil {
+		return nil, iErr
+	}
+	return append(result, inventoried...), nil
+}
+
+// listInventoried obtains the objects recorded in the inventory of the cluster that have not been found by their
+// labels, such as the objects whose labels have been modified.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  found The identifiers of the objects already found.
+//	returns:
+//	  The objects of the inventory that still exist.
+func (dor *DeleteOwnedResources) listInventoried(ctx context.Context, found map[types.UID]bool) ([]ownedObject, derrors.Error) {
+	result := make([]ownedObject, 0)
+	inventory, err := dor.ReadInventory(entities.InventoryNamespace)
+	if err != nil {
+		log.Warn().Str("err", err.DebugReport()).Msg("inventory ignored")
+		return result, nil
+	}
+	if inventory == nil || (dor.ClusterID != "" && inventory.ClusterID != dor.ClusterID) {
+		return result, nil
+	}
+	resources, rErr := restmapper.GetAPIGroupResources(dor.discoveryClient)
+	if rErr != nil {
+		return nil, derrors.NewInternalError("failed to get api group resources", rErr)
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(resources)
+	for _, entry := range inventory.Entries() {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		gv, pErr := schema.ParseGroupVersion(entry.APIVersion)
+		if pErr != nil {
+			continue
+		}
+		mapping, mErr := mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: entry.Kind}, gv.Version)
+		if mErr != nil {
+			// The definition of the kind may have been removed already.
+			continue
+		}
+		client := dor.dynClient.Resource(mapping.Resource)
+		var obj *unstructured.Unstructured
+		var gErr error
+		if entry.Namespace == "" {
+			obj, gErr = client.Get(entry.Name, metaV1.GetOptions{})
+		} else {
+			obj, gErr = client.Namespace(entry.Namespace).Get(entry.Name, metaV1.GetOptions{})
+		}
+		if gErr != nil {
+			if !k8sErrors.IsNotFound(gErr) {
+				log.Warn().Err(gErr).Str("object", entry.String()).Msg("cannot read inventoried object")
+			}
+			continue
+		}
+		if found[obj.GetUID()] {
+			continue
+		}
+		found[obj.GetUID()] = true
+		result = append(result, ownedObject{resource: mapping.Resource, obj: obj})
+	}
+	return result, nil
+}
+
+// OrderForDeletion sorts the objects in the reverse order of creation, so the namespaces and the custom resource
+// definitions are deleted once the objects they contain are gone.
+func OrderForDeletion(objects []ownedObject) []ownedObject {
+	byTier := make([][]ownedObject, IngressTier+1)
+	for _, owned := range objects {
+		tier := TierOf(owned.obj)
+		byTier[tier] = append(byTier[tier], owned)
+	}
+	result := make([]ownedObject, 0, len(objects))
+	for tier := len(byTier) - 1; tier >= 0; tier-- {
+		result = append(result, byTier[tier]...)
+	}
+	return result
+}
+
+// String returns a string representation
+func (dor *DeleteOwnedResources) String() string {
+	return fmt.Sprintf("SYNC DeleteOwnedResources %s", entities.OwnedSelector(dor.ClusterID))
+}
+
+// PrettyPrint returns a simple space indexed string.
+func (dor *DeleteOwnedResources) PrettyPrint(indentation int) string {
+	return strings.Repeat(" ", indentation) + dor.String()
+}
+
+// UserString returns a simple string representation of the command for the user.
+func (dor *DeleteOwnedResources) UserString() string {
+	if dor.ClusterID == "" {
+		return "Deleting the resources created by the installer"
+	}
+	return fmt.Sprintf("Deleting the resources created by the installer for cluster %s", dor.ClusterID)
+}
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -60,8 +61,8 @@ func NewDeletePodSecurityPolicyFromJSON(raw []byte) (*entities.Command, derrors.
 }
 
 // Run the current command returning the result or an error.
-func (dpsp *DeletePodSecurityPolicy) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dpsp.Connect()
+func (dpsp *DeletePodSecurityPolicy) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dpsp.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -0,0 +1,328 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"strings"
+	"time"
+)
+
+// DeleteCheckSleep is the time between checks of the resources that are being deleted.
+const DeleteCheckSleep = time.Second * 2
+
+// ValidPropagationPolicies contains the supported propagation policies.
+var ValidPropagationPolicies = []metaV1.DeletionPropagation{
+	metaV1.DeletePropagationOrphan, metaV1.DeletePropagationBackground, metaV1.DeletePropagationForeground,
+}
+
+// DeleteResources structure with the attributes required to delete a set of resources selected by name or labels.
+type DeleteResources struct {
+	// Kubernetes embedded object
+	Kubernetes
+	// Group of the resources, empty for the core resources.
+	Group string `json:"group"`
+	// Version of the resources. If empty with a kind, the preferred version is used.
+	Version string `json:"version"`
+	// Resource with the plural name of the resources, such as clusterroles.
+	Resource string `json:"resource"`
+	// Kind of the resources, such as ClusterRole, resolved through discovery if the resource is not set.
+	Kind string `json:"kind"`
+	// Namespace with the name of the target namespace, empty for cluster resources.
+	Namespace string `json:"namespace"`
+	// Names with the names of the resources.
+	Names []string `json:"names"`
+	// LabelSelector to select the resources by labels.
+	LabelSelector string `json:"label_selector"`
+	// FieldSelector to select the resources by fields.
+	FieldSelector string `json:"field_selector"`
+	// PropagationPolicy determines how the dependents are deleted: Orphan, Background or Foreground.
+	PropagationPolicy string `json:"propagation_policy"`
+	// FailIfNotExists flag determines if the command fails in case a resource does not exist.
+	FailIfNotExists bool `json:"fail_if_not_exists"`
+	// Wait flag determines if the command waits until the resources disappear.
+	Wait bool `json:"wait"`
+	// WaitTimeout with the maximum time to wait, such as 2m. DefaultWaitTimeout is used if empty.
+	WaitTimeout string `json:"wait_timeout"`
+}
+
+// NewDeleteResources creates a new DeleteResources command for a set of named resources.
+func NewDeleteResources(kubeConfigPath string, resource schema.GroupVersionResource, namespace string, names ...string) *DeleteResources {
+	return &DeleteResources{
+		Kubernetes: Kubernetes{
+			GenericSyncCommand: *entities.NewSyncCommand(entities.DeleteResources),
+			KubeConfigPath:     kubeConfigPath,
+		},
+		Group:     resource.Group,
+		Version:   resource.Version,
+		Resource:  resource.Resource,
+		Namespace: namespace,
+		Names:     names,
+	}
+}
+
+// NewDeleteResourcesFromJSON creates a new DeleteResources command from a raw JSON representation.
+func NewDeleteResourcesFromJSON(raw []byte) (*entities.Command, derrors.Error) {
+	dr := &DeleteResources{}
+	if err := json.Unmarshal(raw, &dr); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if err := dr.Validate(); err != nil {
+		return nil, err
+	}
+	dr.CommandID = entities.GenerateCommandID(dr.Name())
+	var r entities.Command = dr
+	return &r, nil
+}
+
+// Validate checks that the command selects the resources to be deleted.
+func (dr *DeleteResources) Validate() derrors.Error {
+	if dr.Resource == "" && dr.Kind == "" {
+		return derrors.NewInvalidArgumentError(errors.InvalidDeleteResources).WithParams("resource or kind")
+	}
+	if dr.Resource != "" && dr.Version == "" {
+		return derrors.NewInvalidArgumentError(errors.InvalidDeleteResources).WithParams("version")
+	}
+	// Deleting all the resources of a type must be explicit with a selector.
+	if len(dr.Names) == 0 && dr.LabelSelector == "" && dr.FieldSelector == "" {
+		return derrors.NewInvalidArgumentError(errors.InvalidDeleteResources).WithParams("names or selectors")
+	}
+	if dr.PropagationPolicy != "" {
+		valid := false
+		for _, policy := range ValidPropagationPolicies {
+			valid = valid || string(policy) == dr.PropagationPolicy
+		}
+		if !valid {
+			return derrors.NewInvalidArgumentError(errors.InvalidDeleteResources).WithParams("propagation_policy", dr.PropagationPolicy)
+		}
+	}
+	if _, err := dr.waitTimeout(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (dr *DeleteResources) waitTimeout() (time.Duration, derrors.Error) {
+	return parseWaitDuration(dr.WaitTimeout, DefaultWaitTimeout)
+}
+
+// resource returns the resource to be deleted, resolving the kind through discovery if required.
+func (dr *DeleteResources) resource() (schema.GroupVersionResource, bool, derrors.Error) {
+	if dr.Resource != "" {
+		return schema.GroupVersionResource{Group: dr.Group, Version: dr.Version, Resource: dr.Resource}, dr.Namespace != "", nil
+	}
+	mapping, err := dr.ResolveKind(dr.Group, dr.Version, dr.Kind)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// Run the current command returning the result or an error.
+func (dr *DeleteResources) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	if err := dr.Validate(); err != nil {
+		return nil, err
+	}
+	connectErr := dr.ConnectWithContext(ctx)
+	if connectErr != nil {
+		return nil, connectErr
+	}
+	resource, namespaced, err := dr.resource()
+	if err != nil {
+		if !dr.FailIfNotExists && dr.Kind != "" {
+			log.Debug().Str("kind", dr.Kind).Msg("kind not served by the cluster, nothing to delete")
+			return entities.NewSuccessCommand([]byte("No resources to delete")), nil
+		}
+		return entities.NewCommandResult(false, "cannot resolve resource", err), nil
+	}
+	namespace := dr.Namespace
+	if !namespaced {
+		namespace = ""
+	}
+	var client dynamic.ResourceInterface = dr.dynClient.Resource(resource)
+	if namespace != "" {
+		client = dr.dynClient.Resource(resource).Namespace(namespace)
+	}
+
+	targets, missing, err := dr.selectTargets(client)
+	if err != nil {
+		return entities.NewCommandResult(false, "cannot select resources", err), nil
+	}
+	if dr.FailIfNotExists && (len(missing) > 0 || len(targets) == 0) {
+		toReturn := derrors.NewNotFoundError("resources not found").WithParams(resource.String(), namespace, missing)
+		return entities.NewCommandResult(false, "resources do not exist", toReturn), nil
+	}
+
+	options := &metaV1.DeleteOptions{}
+	if dr.PropagationPolicy != "" {
+		policy := metaV1.DeletionPropagation(dr.PropagationPolicy)
+		options.PropagationPolicy = &policy
+	}
+	for _, name := range targets {
+		if dr.DryRun() {
+			dr.recordDelete(resource, namespace, name)
+			continue
+		}
+		log.Debug().Str("resource", resource.String()).Str("namespace", namespace).Str("name", name).Msg("deleting resource")
+		err := client.Delete(name, options)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return entities.NewErrCommand("cannot delete resource",
+				derrors.NewInternalError("cannot delete entity", err).WithParams(namespace, name)), nil
+		}
+	}
+
+	if dr.Wait && !dr.DryRun() && len(targets) > 0 {
+		err = dr.waitDeleted(ctx, client, targets)
+		if err != nil {
+			if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return entities.NewCommandResult(false, "resources have not been deleted", err), nil
+		}
+	}
+	return entities.NewSuccessCommand([]byte(fmt.Sprintf("%d resources deleted", len(targets)))), nil
+}
+
+// selectTargets obtains the names of the resources to be deleted.
+//
+//	returns:
+//	  The names of the existing resources that match the command.
+//	  The names requested by the command that do not exist.
+//	  An error if the resources cannot be retrieved.
+func (dr *DeleteResources) selectTargets(client dynamic.ResourceInterface) ([]string, []string, derrors.Error) {
+	targets := make([]string, 0)
+	missing := make([]string, 0)
+	if dr.LabelSelector == "" && dr.FieldSelector == "" {
+		for _, name := range dr.Names {
+			_, err := client.Get(name, metaV1.GetOptions{})
+			if err != nil {
+				if !k8sErrors.IsNotFound(err) {
+					return nil, nil, derrors.NewInternalError("cannot retrieve resource", err).WithParams(name)
+				}
+				missing = append(missing, name)
+				continue
+			}
+			targets = append(targets, name)
+		}
+		return targets, missing, nil
+	}
+
+	list, err := client.List(metaV1.ListOptions{LabelSelector: dr.LabelSelector, FieldSelector: dr.FieldSelector})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return targets, dr.Names, nil
+		}
+		return nil, nil, derrors.NewInternalError("cannot list resources", err)
+	}
+	targets, missing = filterNames(list.Items, dr.Names)
+	return targets, missing, nil
+}
+
+// filterNames returns the names of the listed resources that are included in the requested names. If no names
+// are requested, all the listed resources are returned.
+func filterNames(items []unstructured.Unstructured, names []string) ([]string, []string) {
+	targets := make([]string, 0)
+	missing := make([]string, 0)
+	found := make(map[string]bool, len(items))
+	for _, item := range items {
+		found[item.GetName()] = true
+		if len(names) == 0 || checkIncluded(item.GetName(), names) {
+			targets = append(targets, item.GetName())
+		}
+	}
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	return targets, missing
+}
+
+// waitDeleted waits until the deleted resources cannot be retrieved.
+func (dr *DeleteResources) waitDeleted(ctx context.Context, client dynamic.ResourceInterface, names []string) derrors.Error {
+	timeout, _ := dr.waitTimeout()
+	pending := names
+	policy := entities.NewRetryPolicy(int(timeout/DeleteCheckSleep), entities.NewConstantBackoff(DeleteCheckSleep))
+	err := policy.Poll(ctx, func() (bool, derrors.Error) {
+		remaining := make([]string, 0)
+		for _, name := range pending {
+			_, err := client.Get(name, metaV1.GetOptions{})
+			if err == nil || !k8sErrors.IsNotFound(err) {
+				remaining = append(remaining, name)
+			}
+		}
+		pending = remaining
+		if len(pending) > 0 {
+			log.Debug().Strs("pending", pending).Msg("waiting for the resources to be deleted")
+		}
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		return derrors.NewDeadlineExceededError(errors.ResourcesNotDeleted, err).WithParams(pending)
+	}
+	return nil
+}
+
+// target returns a description of the resources to be deleted.
+func (dr *DeleteResources) target() string {
+	resource := dr.Resource
+	if resource == "" {
+		resource = dr.Kind
+	}
+	selectors := make([]string, 0)
+	if len(dr.Names) > 0 {
+		selectors = append(selectors, strings.Join(dr.Names, ","))
+	}
+	if dr.LabelSelector != "" {
+		selectors = append(selectors, fmt.Sprintf("-l %s", dr.LabelSelector))
+	}
+	if dr.FieldSelector != "" {
+		selectors = append(selectors, fmt.Sprintf("--field-selector %s", dr.FieldSelector))
+	}
+	if dr.Namespace == "" {
+		return fmt.Sprintf("%s %s", resource, strings.Join(selectors, " "))
+	}
+	return fmt.Sprintf("%s %s:%s", resource, dr.Namespace, strings.Join(selectors, " "))
+}
+
+// String returns a string representation
+func (dr *DeleteResources) String() string {
+	return fmt.Sprintf("SYNC DeleteResources %s", dr.target())
+}
+
+// PrettyPrint returns a simple space indexed string.
+func (dr *DeleteResources) PrettyPrint(indentation int) string {
+	return strings.Repeat(" ", indentation) + dr.String()
+}
+
+// UserString returns a simple string representation of the command for the user.
+func (dr *DeleteResources) UserString() string {
+	return fmt.Sprintf("Deleting %s", dr.target())
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = ginkgo.Describe("DeleteResources", func() {
+
+	ginkgo.It("should parse a command with a kind and names", func() {
+		raw := `{"type":"sync", "name":"deleteResources", "kubeConfigPath":"/tmp/config", "group":"policy",
+			"kind":"PodSecurityPolicy", "names":["node-exporter"], "propagation_policy":"Foreground", "wait":true,
+			"wait_timeout":"1m", "fail_if_not_exists":false}`
+		cmd, err := NewDeleteResourcesFromJSON([]byte(raw))
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect((*cmd).(*DeleteResources).UserString()).To(gomega.Equal("Deleting PodSecurityPolicy node-exporter"))
+	})
+
+	ginkgo.It("should reject commands that do not select the resources", func() {
+		invalid := []string{
+			`{"type":"sync", "name":"deleteResources", "names":["a"]}`,
+			`{"type":"sync", "name":"deleteResources", "resource":"services", "names":["a"]}`,
+			`{"type":"sync", "name":"deleteResources", "version":"v1", "resource":"services"}`,
+			`{"type":"sync", "name":"deleteResources", "version":"v1", "resource":"services", "names":["a"], "propagation_policy":"Later"}`,
+		}
+		for _, raw := range invalid {
+			_, err := NewDeleteResourcesFromJSON([]byte(raw))
+			gomega.Expect(err).ToNot(gomega.BeNil(), raw)
+		}
+	})
+
+	ginkgo.It("should filter the listed resources by name", func() {
+		items := []unstructured.Unstructured{*testObject("Service", "a"), *testObject("Service", "b")}
+		targets, missing := filterNames(items, []string{"b", "c"})
+		gomega.Expect(targets).To(gomega.Equal([]string{"b"}))
+		gomega.Expect(missing).To(gomega.Equal([]string{"c"}))
+		targets, missing = filterNames(items, nil)
+		gomega.Expect(targets).To(gomega.Equal([]string{"a", "b"}))
+		gomega.Expect(missing).To(gomega.BeEmpty())
+	})
+
+})
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -63,8 +64,8 @@ func NewDeleteRoleFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 }
 
 // Run the current command returning the result or an error.
-func (dr *DeleteRole) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dr.Connect()
+func (dr *DeleteRole) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dr.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -63,8 +64,8 @@ func NewDeleteServiceFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 }
 
 // Run the current command returning the result or an error.
-func (ds *DeleteService) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := ds.Connect()
+func (ds *DeleteService) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := ds.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -63,8 +64,8 @@ func NewDeleteServiceAccountFromJSON(raw []byte) (*entities.Command, derrors.Err
 }
 
 // Run the current command returning the result or an error.
-func (dsa *DeleteServiceAccount) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := dsa.Connect()
+func (dsa *DeleteServiceAccount) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := dsa.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
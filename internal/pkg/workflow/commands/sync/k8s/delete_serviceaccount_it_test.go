@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -42,7 +43,7 @@ var _ = ginkgo.Describe("A delete service account command", func() {
 
 	ginkgo.It("should be able to delete a service account", func() {
 		dsa := NewDeleteServiceAccount(itKubeConfigFile, "test", "test2")
-		result, err := dsa.Run("deleteServiceAccounts")
+		result, err := dsa.Run(context.Background(), "deleteServiceAccounts")
 		gomega.Expect(err).To(gomega.Succeed())
 		if !result.Success {
 			log.Debug().Interface("result", result).Msg("failed")
@@ -964,7 +964,7 @@ var IngressClusterRole = rbacv1.ClusterRole{
 		},
 		rbacv1.PolicyRule{
 			Verbs:     []string{"get", "list", "watch"},
-			APIGroups: []string{"extensions"},
+			APIGroups: []string{"extensions", "networking.k8s.io"},
 			Resources: []string{"ingresses"},
 		},
 		rbacv1.PolicyRule{
@@ -974,7 +974,7 @@ var IngressClusterRole = rbacv1.ClusterRole{
 		},
 		rbacv1.PolicyRule{
 			Verbs:     []string{"update"},
-			APIGroups: []string{"extensions"},
+			APIGroups: []string{"extensions", "networking.k8s.io"},
 			Resources: []string{"ingresses/status"},
 		},
 	},
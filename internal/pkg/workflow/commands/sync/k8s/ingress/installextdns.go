@@ -18,6 +18,7 @@
 package ingress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -59,8 +60,8 @@ func NewInstallExtDNSFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 	return &r, nil
 }
 
-func (imd *InstallExtDNS) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := imd.Connect()
+func (imd *InstallExtDNS) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := imd.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -91,7 +92,7 @@ func (imd *InstallExtDNS) InstallAzure(workflowID string) (*entities.CommandResu
 			false, "cannot install service", err), nil
 	}
 	msg := fmt.Sprintf("External DNS loadbalancer installed on %s", imd.PlatformType)
-	return entities.NewSuccessCommand([]byte(msg)), nil
+	return imd.ApplyResult(msg), nil
 }
 
 func (imd *InstallExtDNS) InstallMinikube(workflowID string) (*entities.CommandResult, derrors.Error) {
@@ -101,7 +102,7 @@ func (imd *InstallExtDNS) InstallMinikube(workflowID string) (*entities.CommandR
 		return entities.NewCommandResult(
 			false, "cannot install service", err), nil
 	}
-	return entities.NewSuccessCommand([]byte("External DNS loadbalancer installed on Minikube")), nil
+	return imd.ApplyResult("External DNS loadbalancer installed on Minikube"), nil
 }
 
 func (imd *InstallExtDNS) String() string {
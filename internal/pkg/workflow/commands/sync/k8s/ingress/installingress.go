@@ -21,6 +21,7 @@
 package ingress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/grpc-installer-go"
@@ -33,7 +34,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
-	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 )
 
@@ -152,37 +153,15 @@ func (ii *InstallIngress) getService(installType grpc_installer_go.Platform) (*v
 	return &genericService, &CloudGenericServiceDefaultBackend
 }
 
-// GetExistingIngressOnNamespace checks if an ingress exists on a given namespace.
-func (ii *InstallIngress) GetExistingIngressOnNamespace(namespace string) (*v1beta1.Ingress, derrors.Error) {
-	client := ii.Client.ExtensionsV1beta1().Ingresses(namespace)
-	opts := metaV1.ListOptions{}
-	ingresses, err := client.List(opts)
-	if err != nil {
-		return nil, derrors.NewInternalError("cannot retrieve ingresses", err)
-	}
-	if len(ingresses.Items) > 0 {
-		return &ingresses.Items[0], nil
-	}
-	return nil, nil
+// GetExistingIngressOnNamespace checks if an ingress exists on a given namespace. The ingresses are read with the
+// version served by the cluster.
+func (ii *InstallIngress) GetExistingIngressOnNamespace(namespace string) (*unstructured.Unstructured, derrors.Error) {
+	return ii.FirstOfKind(namespace, k8s.IngressKind, k8s.IngressGroups...)
 }
 
 // GetExistingIngress retrieves an ingress if it exists on the system.
-func (ii *InstallIngress) GetExistingIngress() (*v1beta1.Ingress, derrors.Error) {
-	opts := metaV1.ListOptions{}
-	namespaces, err := ii.Client.CoreV1().Namespaces().List(opts)
-	if err != nil {
-		return nil, derrors.NewInternalError("cannot retrieve namespaces", err)
-	}
-	for _, ns := range namespaces.Items {
-		found, err := ii.GetExistingIngressOnNamespace(ns.Name)
-		if err != nil {
-			return nil, err
-		}
-		if found != nil {
-			return found, nil
-		}
-	}
-	return nil, nil
+func (ii *InstallIngress) GetExistingIngress() (*unstructured.Unstructured, derrors.Error) {
+	return ii.FirstOfKind("", k8s.IngressKind, k8s.IngressGroups...)
 }
 
 func (ii *InstallIngress) triggerInstall(installType grpc_installer_go.Platform) derrors.Error {
@@ -272,6 +251,7 @@ func (ii *InstallIngress) triggerAppClusterInstall(installType grpc_installer_go
 	}
 
 	log.Debug().Msg("Installing app cluster ingress rules")
+	// The rules are defined as v1beta1 ingresses and sent with the version served by the cluster.
 	for _, ingressToInstall := range ii.getAppClusterIngressRules() {
 		err = ii.Create(ingressToInstall)
 		if err != nil {
@@ -457,8 +437,8 @@ func (ii *InstallIngress) installNginxSupport(installType grpc_installer_go.Plat
 	return nil
 }
 
-func (ii *InstallIngress) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := ii.Connect()
+func (ii *InstallIngress) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := ii.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -468,7 +448,7 @@ func (ii *InstallIngress) Run(workflowID string) (*entities.CommandResult, derro
 		return nil, err
 	}
 	if existingIngress != nil {
-		log.Warn().Interface("ingress", existingIngress).Msg("An ingress has been found")
+		log.Warn().Str("namespace", existingIngress.GetNamespace()).Str("name", existingIngress.GetName()).Msg("An ingress has been found")
 		return entities.NewSuccessCommand([]byte("[WARN] Ingress has not been installed as it already exists")), nil
 	}
 
@@ -486,7 +466,7 @@ func (ii *InstallIngress) Run(workflowID string) (*entities.CommandResult, derro
 			false, "cannot install an ingress", err), nil
 	}
 
-	return entities.NewSuccessCommand([]byte("Ingress controller credentials have been created")), nil
+	return ii.ApplyResult("Ingress controller credentials have been created"), nil
 
 }
 
@@ -18,6 +18,7 @@
 package ingress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -59,8 +60,8 @@ func NewInstallMngtDNSFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 	return &r, nil
 }
 
-func (imd *InstallMngtDNS) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := imd.Connect()
+func (imd *InstallMngtDNS) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := imd.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -91,7 +92,7 @@ func (imd *InstallMngtDNS) InstallLoadBalancer(workflowID string) (*entities.Com
 			false, "cannot install service", err), nil
 	}
 	msg := fmt.Sprintf("DNS loadbalancer installed on %s", imd.PlatformType)
-	return entities.NewSuccessCommand([]byte(msg)), nil
+	return imd.ApplyResult(msg), nil
 }
 
 func (imd *InstallMngtDNS) InstallMinikube(workflowID string) (*entities.CommandResult, derrors.Error) {
@@ -101,7 +102,7 @@ func (imd *InstallMngtDNS) InstallMinikube(workflowID string) (*entities.Command
 		return entities.NewCommandResult(
 			false, "cannot install service", err), nil
 	}
-	return entities.NewSuccessCommand([]byte("DNS loadbalancer installed on Minikube")), nil
+	return imd.ApplyResult("DNS loadbalancer installed on Minikube"), nil
 }
 
 func (imd *InstallMngtDNS) String() string {
@@ -18,6 +18,7 @@
 package ingress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -56,8 +57,8 @@ func NewInstallVpnServerLBFromJSON(raw []byte) (*entities.Command, derrors.Error
 	return &r, nil
 }
 
-func (imd *InstallVpnServerLB) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := imd.Connect()
+func (imd *InstallVpnServerLB) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := imd.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -88,7 +89,7 @@ func (imd *InstallVpnServerLB) InstallLoadBalancer(workflowID string) (*entities
 			false, "cannot install service", err), nil
 	}
 	msg := fmt.Sprintf("VPN Server installed on %s", imd.PlatformType)
-	return entities.NewSuccessCommand([]byte(msg)), nil
+	return imd.ApplyResult(msg), nil
 }
 
 func (imd *InstallVpnServerLB) InstallMinikube(workflowID string) (*entities.CommandResult, derrors.Error) {
@@ -98,7 +99,7 @@ func (imd *InstallVpnServerLB) InstallMinikube(workflowID string) (*entities.Com
 		return entities.NewCommandResult(
 			false, "cannot install service", err), nil
 	}
-	return entities.NewSuccessCommand([]byte("VPN Server installed on Minikube")), nil
+	return imd.ApplyResult("VPN Server installed on Minikube"), nil
 }
 
 func (imd *InstallVpnServerLB) String() string {
@@ -18,6 +18,7 @@
 package ingress
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -56,8 +57,8 @@ func NewInstallZtPlanetLBFromJSON(raw []byte) (*entities.Command, derrors.Error)
 	return &r, nil
 }
 
-func (imd *InstallZtPlanetLB) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := imd.Connect()
+func (imd *InstallZtPlanetLB) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := imd.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -85,7 +86,7 @@ func (imd *InstallZtPlanetLB) InstallLoadBalancer(workflowID string) (*entities.
 			false, "cannot install service", err), nil
 	}
 	msg := fmt.Sprintf("ZT planet installed on %s", imd.PlatformType)
-	return entities.NewSuccessCommand([]byte(msg)), nil
+	return imd.ApplyResult(msg), nil
 }
 
 func (imd *InstallZtPlanetLB) InstallMinikube(workflowID string) (*entities.CommandResult, derrors.Error) {
@@ -95,7 +96,7 @@ func (imd *InstallZtPlanetLB) InstallMinikube(workflowID string) (*entities.Comm
 		return entities.NewCommandResult(
 			false, "cannot install service", err), nil
 	}
-	return entities.NewSuccessCommand([]byte("ZT planet installed on Minikube")), nil
+	return imd.ApplyResult("ZT planet installed on Minikube"), nil
 }
 
 func (imd *InstallZtPlanetLB) String() string {
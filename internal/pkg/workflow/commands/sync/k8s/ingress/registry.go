@@ -0,0 +1,70 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the commands that install the ingress and the load balancers of the platform.
+
+package ingress
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+// registerIngressCommand registers a synchronous command of the package.
+func registerIngressCommand(name string, description string, fromJSON entities.CommandFactory, command interface{}, descriptions map[string]string) {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        name,
+		Description: description,
+		FromJSON:    fromJSON,
+		Parameters:  entities.ParametersOf(command, k8s.WithKubernetesParameters(descriptions)),
+	})
+}
+
+func init() {
+	registerIngressCommand(entities.InstallIngress, "Installs the ingress controller of the cluster",
+		NewInstallIngressFromJSON, &InstallIngress{}, map[string]string{
+			"platform_type":          "Target platform",
+			"management_public_host": "Public host of the management cluster",
+			"on_management_cluster":  "Whether the ingress is installed on the management cluster",
+			"use_static_ip":          "Whether the ingress uses a static IP address",
+			"static_ip_address":      "Static IP address of the ingress",
+			"network_mode":           "Networking mode of the platform",
+		})
+	registerIngressCommand(entities.InstallMngtDNS, "Installs the load balancer of the DNS of the management cluster",
+		NewInstallMngtDNSFromJSON, &InstallMngtDNS{}, map[string]string{
+			"platform_type":     "Target platform",
+			"use_static_ip":     "Whether the load balancer uses a static IP address",
+			"static_ip_address": "Static IP address of the load balancer",
+		})
+	registerIngressCommand(entities.InstallZtPlanetLB, "Installs the load balancer of the ZeroTier planet",
+		NewInstallZtPlanetLBFromJSON, &InstallZtPlanetLB{}, map[string]string{
+			"platform_type": "Target platform",
+		})
+	registerIngressCommand(entities.InstallVpnServerLB, "Installs the load balancer of the VPN server",
+		NewInstallVpnServerLBFromJSON, &InstallVpnServerLB{}, map[string]string{
+			"platform_type":     "Target platform",
+			"use_static_ip":     "Whether the load balancer uses a static IP address",
+			"static_ip_address": "Static IP address of the load balancer",
+		})
+	registerIngressCommand(entities.InstallExtDNS, "Installs the load balancer of the external DNS",
+		NewInstallExtDNSFromJSON, &InstallExtDNS{}, map[string]string{
+			"platform_type":     "Target platform",
+			"use_static_ip":     "Whether the load balancer uses a static IP address",
+			"static_ip_address": "Static IP address of the load balancer",
+		})
+}
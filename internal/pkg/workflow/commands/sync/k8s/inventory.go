@@ -0,0 +1,221 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the writeInventory command that stores the record of an install in the target cluster
+//
+// The objects applied by the commands are added to the inventory of the execution context. The command stores that
+// inventory as a config map, so the uninstall and the upgrades read what was installed instead of guessing.
+
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	"io/ioutil"
+	"k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ComponentsVersionFile is the file of the components directory with the version of the bundle.
+const ComponentsVersionFile = "VERSION"
+
+// ObjectHash returns the SHA-256 of the definition of an object. The request annotation is not included, so the
+// hash only changes when the object does.
+func ObjectHash(obj *unstructured.Unstructured) string {
+	raw, err := withoutRequestID(obj).MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordInventory adds an applied object to the inventory of the execution, if any.
+func (k *Kubernetes) recordInventory(obj *unstructured.Unstructured) {
+	if k.inventory == nil {
+		return
+	}
+	k.inventory.Record(entities.InventoryObject{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Component:  obj.GetLabels()[entities.ComponentLabel],
+		Hash:       ObjectHash(obj),
+	})
+}
+
+// recordComponentsVersion sets the version of the launched bundle of components in the inventory, if any.
+func (k *Kubernetes) recordComponentsVersion(componentsDir string, files []string) {
+	if k.inventory == nil {
+		return
+	}
+	k.inventory.SetComponentsVersion(ComponentsVersion(componentsDir, files))
+}
+
+// ComponentsVersion returns the version of a bundle of components. The version is read from the VERSION file of the
+// directory if it exists, otherwise the SHA-256 of the names and the contents of the files is used.
+//   params:
+//     componentsDir The directory with the components.
+//     files The names of the component files in the directory.
+//   returns:
+//     The version of the bundle.
+func ComponentsVersion(componentsDir string, files []string) string {
+	content, err := ioutil.ReadFile(path.Join(componentsDir, ComponentsVersionFile))
+	if err == nil && strings.TrimSpace(string(content)) != "" {
+		return strings.TrimSpace(string(content))
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("componentsDir", componentsDir).Msg("cannot read the version of the components")
+	}
+	hash := sha256.New()
+	for _, fileName := range files {
+		hash.Write([]byte(fileName))
+		content, err := ioutil.ReadFile(path.Join(componentsDir, fileName))
+		if err == nil {
+			hash.Write(content)
+		}
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// ReadInventory reads the inventory stored in the cluster.
+//   params:
+//     namespace The namespace of the inventory config map.
+//   returns:
+//     The inventory, or nil if the cluster does not contain an inventory.
+//     An error if the inventory cannot be read.
+func (k *Kubernetes) ReadInventory(namespace string) (*entities.Inventory, derrors.Error) {
+	config, err := k.Client.CoreV1().ConfigMaps(namespace).Get(entities.InventoryName, metaV1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, derrors.NewInternalError("cannot read inventory", err).WithParams(namespace)
+	}
+	raw, found := config.Data[entities.InventoryKey]
+	if !found {
+		return nil, derrors.NewInvalidArgumentError(errors.InvalidInventory).WithParams(namespace, entities.InventoryName)
+	}
+	return entities.NewInventoryFromJSON([]byte(raw))
+}
+
+// WriteInventory command that stores the inventory of the install in the target cluster.
+type WriteInventory struct {
+	Kubernetes
+	// Namespace of the inventory config map. The InventoryNamespace is used if empty.
+	Namespace string `json:"namespace"`
+}
+
+// NewWriteInventory creates a new WriteInventory command.
+func NewWriteInventory(kubeConfigPath string, namespace string) *WriteInventory {
+	return &WriteInventory{
+		Kubernetes: Kubernetes{
+			GenericSyncCommand: *entities.NewSyncCommand(entities.WriteInventory),
+			KubeConfigPath:     kubeConfigPath,
+			ApplyMode:          UpdateMode,
+		},
+		Namespace: namespace,
+	}
+}
+
+// NewWriteInventoryFromJSON creates a new WriteInventory command from a raw JSON representation. The inventory is
+// updated by default, as it is written on every install.
+func NewWriteInventoryFromJSON(raw []byte) (*entities.Command, derrors.Error) {
+	wi := &WriteInventory{}
+	if err := json.Unmarshal(raw, &wi); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if wi.ApplyMode == "" {
+		wi.ApplyMode = UpdateMode
+	}
+	wi.CommandID = entities.GenerateCommandID(wi.Name())
+	var r entities.Command = wi
+	return &r, nil
+}
+
+// namespace returns the namespace of the inventory.
+func (wi *WriteInventory) namespace() string {
+	if wi.Namespace == "" {
+		return entities.InventoryNamespace
+	}
+	return wi.Namespace
+}
+
+func (wi *WriteInventory) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := wi.ConnectWithContext(ctx)
+	if connectErr != nil {
+		return nil, connectErr
+	}
+	inventory := wi.inventory
+	if inventory == nil {
+		return entities.NewSuccessCommand([]byte("the execution does not record an inventory")), nil
+	}
+	previous, err := wi.ReadInventory(wi.namespace())
+	if err != nil {
+		log.Warn().Str("err", err.DebugReport()).Msg("previous inventory ignored")
+	}
+	// A resumed install does not record again the objects applied before the restart.
+	if previous != nil && previous.RequestID != "" && previous.RequestID == inventory.RequestID {
+		inventory.Merge(previous)
+	}
+	raw, err := inventory.ToJSON(time.Now())
+	if err != nil {
+		return entities.NewCommandResult(false, "cannot write inventory", err), nil
+	}
+	config := &v1.ConfigMap{
+		TypeMeta: metaV1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      entities.InventoryName,
+			Namespace: wi.namespace(),
+		},
+		Data: map[string]string{entities.InventoryKey: string(raw)},
+	}
+	err = wi.Create(config)
+	if err != nil {
+		return entities.NewCommandResult(false, "cannot write inventory", err), nil
+	}
+	return wi.ApplyResult(fmt.Sprintf("inventory with %d objects has been written", len(inventory.Entries()))), nil
+}
+
+func (wi *WriteInventory) String() string {
+	return fmt.Sprintf("SYNC WriteInventory %s/%s", wi.namespace(), entities.InventoryName)
+}
+
+func (wi *WriteInventory) PrettyPrint(indentation int) string {
+	return strings.Repeat(" ", indentation) + wi.String()
+}
+
+func (wi *WriteInventory) UserString() string {
+	return "Writing the installation inventory"
+}
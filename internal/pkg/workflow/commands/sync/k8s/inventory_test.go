@@ -0,0 +1,77 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+var _ = ginkgo.Describe("Inventory", func() {
+
+	ginkgo.It("should hash the objects without the request", func() {
+		obj := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		hash := ObjectHash(obj)
+		gomega.Expect(len(hash)).To(gomega.Equal(64))
+		obj.SetAnnotations(map[string]string{entities.RequestIDAnnotation: "request"})
+		gomega.Expect(ObjectHash(obj)).To(gomega.Equal(hash))
+		changed := toUnstructured(testConfigMap(map[string]string{"key": "other"}))
+		gomega.Expect(ObjectHash(changed)).ToNot(gomega.Equal(hash))
+	})
+
+	ginkgo.It("should record the applied objects with their component", func() {
+		inventory := entities.NewInventory("install", "", nil, nil)
+		k := &Kubernetes{GenericSyncCommand: *entities.NewSyncCommand(entities.LaunchComponents), inventory: inventory,
+			ownership: entities.NewOwnership("v1", "", "")}
+		obj := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		SetComponent(obj, "config")
+		k.stampOwnership(obj)
+		k.recordInventory(obj)
+		recorded := inventory.Find("v1", "ConfigMap", "nalej", "config")
+		gomega.Expect(recorded).ToNot(gomega.BeNil())
+		gomega.Expect(recorded.Component).To(gomega.Equal("config"))
+		gomega.Expect(recorded.Hash).To(gomega.Equal(ObjectHash(obj)))
+	})
+
+	ginkgo.It("should obtain the version of the components", func() {
+		dir, err := ioutil.TempDir("", "components")
+		gomega.Expect(err).To(gomega.BeNil())
+		defer os.RemoveAll(dir)
+		gomega.Expect(ioutil.WriteFile(path.Join(dir, "dns.yaml"), []byte("kind: Service"), 0644)).To(gomega.Succeed())
+		hashed := ComponentsVersion(dir, []string{"dns.yaml"})
+		gomega.Expect(hashed).To(gomega.HavePrefix("sha256:"))
+		gomega.Expect(ComponentsVersion(dir, []string{"dns.yaml"})).To(gomega.Equal(hashed))
+		gomega.Expect(ioutil.WriteFile(path.Join(dir, "dns.yaml"), []byte("kind: Pod"), 0644)).To(gomega.Succeed())
+		gomega.Expect(ComponentsVersion(dir, []string{"dns.yaml"})).ToNot(gomega.Equal(hashed))
+		gomega.Expect(ioutil.WriteFile(path.Join(dir, ComponentsVersionFile), []byte("v0.4.0\n"), 0644)).To(gomega.Succeed())
+		gomega.Expect(ComponentsVersion(dir, []string{"dns.yaml"})).To(gomega.Equal("v0.4.0"))
+	})
+
+	ginkgo.It("should update the inventory by default", func() {
+		cmd, err := NewWriteInventoryFromJSON([]byte(`{"type":"sync", "name":"writeInventory", "kubeConfigPath":"/tmp/config"}`))
+		gomega.Expect(err).To(gomega.BeNil())
+		wi := (*cmd).(*WriteInventory)
+		gomega.Expect(wi.ApplyMode).To(gomega.Equal(UpdateMode))
+		gomega.Expect(wi.namespace()).To(gomega.Equal(entities.InventoryNamespace))
+	})
+
+})
@@ -18,12 +18,14 @@
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/derrors"
+	yamlEncoder "gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"strings"
-	"time"
 
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/nalej/installer/version"
 
 	"github.com/rs/zerolog/log"
 
@@ -31,6 +33,7 @@ import (
 
 	"k8s.io/api/core/v1"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -49,14 +52,30 @@ type Kubernetes struct {
 	entities.GenericSyncCommand
 	KubeConfigPath string                `json:"kubeConfigPath"`
 	Client         *kubernetes.Clientset `json:"-"`
+	// ApplyMode determines how the objects are sent to the cluster: create, update or apply. Create is used by default.
+	ApplyMode ApplyMode `json:"applyMode,omitempty"`
+	// FieldManager with the name of the owner of the fields sent in the update and apply modes.
+	FieldManager string `json:"fieldManager,omitempty"`
 
 	// Discovery client for REST mapper to use, so we can figure out
 	// the right endpoints for reserves
 	discoveryClient *discovery.DiscoveryClient
 	// Dynamic client used to create all resources
 	dynClient dynamic.Interface
+	// manifest receives the changes instead of the cluster in dry-run mode.
+	manifest *entities.Manifest
+	// counter with the objects created, updated and unchanged since the command connected. It is held by pointer
+	// as the commands embed Kubernetes by value.
+	counter *applyCounter
+	// ownership with the labels of the objects created by the command.
+	ownership *entities.Ownership
+	// inventory receives the objects applied by the command, if set.
+	inventory *entities.Inventory
 }
 
+// RedactedValue replaces the data of the secrets recorded in dry-run mode.
+const RedactedValue = "<redacted>"
+
 func (k *Kubernetes) Connect() derrors.Error {
 	config, err := clientcmd.BuildConfigFromFlags("", k.KubeConfigPath)
 	if err != nil {
@@ -88,6 +107,91 @@ func (k *Kubernetes) Connect() derrors.Error {
 	}
 	k.dynClient = dynClient
 
+	if k.counter == nil {
+		k.counter = &applyCounter{}
+	}
+	return nil
+}
+
+// ConnectWithContext connects to Kubernetes and resets the counts of the objects sent. If the context carries the
+// manifest of a dry-run execution, the cluster is only read and the changes are recorded in the manifest. The
+// objects created are labelled with the ownership of the context, or only as managed by the installer if not set,
+// and recorded in the inventory of the context.
+func (k *Kubernetes) ConnectWithContext(ctx context.Context) derrors.Error {
+	k.manifest = entities.ManifestFromContext(ctx)
+	k.inventory = entities.InventoryFromContext(ctx)
+	k.ownership = entities.OwnershipFromContext(ctx)
+	if k.ownership == nil {
+		k.ownership = entities.NewOwnership(version.AppVersion, "", "")
+	}
+	k.counter = &applyCounter{}
+	if err := k.ValidateApplyMode(); err != nil {
+		return err
+	}
+	return k.Connect()
+}
+
+// SupportsDryRun returns true as the changes to Kubernetes are recorded in dry-run mode.
+func (k *Kubernetes) SupportsDryRun() bool {
+	return true
+}
+
+// DryRun checks if the changes are being recorded instead of applied.
+func (k *Kubernetes) DryRun() bool {
+	return k.manifest != nil
+}
+
+// RecordEntry adds a change to the manifest of the dry-run execution.
+func (k *Kubernetes) RecordEntry(entry entities.ManifestEntry) {
+	entry.CommandID = k.CommandID
+	log.Debug().Str("entry", entry.String()).Msg("change recorded in dry-run mode")
+	k.manifest.Record(entry)
+}
+
+// RecordExec adds an external tool that would be executed to the manifest of the dry-run execution.
+func (k *Kubernetes) RecordExec(description string) {
+	k.RecordEntry(entities.ManifestEntry{Action: entities.ExecAction, Name: description})
+}
+
+// Record adds an object to the manifest of the dry-run execution. The data of the secrets is redacted.
+func (k *Kubernetes) Record(action entities.ManifestAction, obj runtime.Object) derrors.Error {
+	unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return derrors.NewInvalidArgumentError("cannot convert object to unstructured", err).WithParams(obj)
+	}
+	unstructuredObj := &unstructured.Unstructured{
+		Object: unstructuredMap,
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "" {
+		kind, derr := getKind(obj)
+		if derr != nil {
+			return derr
+		}
+		gvk = kind
+	}
+	unstructuredObj.SetGroupVersionKind(gvk)
+	if gvk.Kind == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			if data, exists := unstructuredObj.Object[field].(map[string]interface{}); exists {
+				for key := range data {
+					data[key] = RedactedValue
+				}
+			}
+		}
+	}
+	definition, err := yamlEncoder.Marshal(unstructuredObj.Object)
+	if err != nil {
+		return derrors.NewInternalError("cannot marshal object", err).WithParams(gvk.String())
+	}
+	k.RecordEntry(entities.ManifestEntry{
+		Action:     action,
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  unstructuredObj.GetNamespace(),
+		Name:       unstructuredObj.GetName(),
+		Definition: string(definition),
+	})
 	return nil
 }
 
@@ -107,6 +211,9 @@ func (k *Kubernetes) ResolveIP(address string) ([]string, derrors.Error) {
 }
 
 func (k *Kubernetes) ExistsNamespace(name string) (bool, derrors.Error) {
+	if k.DryRun() && k.manifest.Contains(entities.CreateAction, "Namespace", "", name) {
+		return true, nil
+	}
 	namespaceClient := k.Client.CoreV1().Namespaces()
 	opts := metaV1.ListOptions{}
 	list, err := namespaceClient.List(opts)
@@ -212,6 +319,26 @@ func (k *Kubernetes) Create(obj runtime.Object) derrors.Error {
 		return nil
 	}
 
+	k.stampOwnership(unstructuredObj)
+	derr = k.adaptVersion(unstructuredObj)
+	if derr != nil {
+		return derr
+	}
+	derr = k.send(unstructuredObj, unstructuredObj.GroupVersionKind())
+	if derr == nil {
+		k.recordInventory(unstructuredObj)
+	}
+	return derr
+}
+
+// send creates or updates an object in the cluster using the apply mode of the command, or records it in dry-run
+// mode.
+func (k *Kubernetes) send(unstructuredObj *unstructured.Unstructured, gvk schema.GroupVersionKind) derrors.Error {
+	if k.DryRun() && k.applyMode() == CreateMode {
+		k.countCreated()
+		return k.Record(entities.CreateAction, unstructuredObj)
+	}
+
 	// Create the REST mapper through a discovery client
 	// We do this every time we create a resource, because if we created
 	// a custom resource definition in a previous step, we need to
@@ -225,6 +352,11 @@ func (k *Kubernetes) Create(obj runtime.Object) derrors.Error {
 	// Get the right REST endpoint through the mapper
 	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
+		if k.DryRun() {
+			// The definition of the resource may have been recorded in the same dry-run execution.
+			k.countCreated()
+			return k.Record(entities.CreateAction, unstructuredObj)
+		}
 		return derrors.NewInternalError("unable to get REST mapping for object", err).WithParams(unstructuredObj)
 	}
 
@@ -236,17 +368,7 @@ func (k *Kubernetes) Create(obj runtime.Object) derrors.Error {
 		client = k.dynClient.Resource(mapping.Resource)
 	}
 
-	log.Debug().Interface("obj", unstructuredObj).Msg("creating resource")
-
-	created, err := client.Create(unstructuredObj, metaV1.CreateOptions{})
-	if err != nil {
-		log.Error().Err(err).Msg("unable to crate kubernetes object")
-		return derrors.NewInternalError("unable to create object", err).WithParams(unstructuredObj)
-	}
-
-	log.Debug().Str("resource", created.GetSelfLink()).Msg("created")
-
-	return nil
+	return k.apply(client, unstructuredObj)
 }
 
 
@@ -316,12 +438,40 @@ func getKind(obj runtime.Object) (schema.GroupVersionKind, derrors.Error) {
 	return kind, nil
 }
 
+// ResolveKind obtains the resource of a kind through the discovery client.
+//   params:
+//     group The group of the kind, empty for the core kinds.
+//     version The version of the kind. If empty, the preferred version of the group is used.
+//     kind The kind, such as ClusterRole.
+//   returns:
+//     The mapping with the resource and its scope.
+//     An error if the kind is not served by the cluster.
+func (k *Kubernetes) ResolveKind(group string, version string, kind string) (*meta.RESTMapping, derrors.Error) {
+	mapper, derr := k.newMapper()
+	if derr != nil {
+		return nil, derr
+	}
+	versions := make([]string, 0)
+	if version != "" {
+		versions = append(versions, version)
+	}
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: group, Kind: kind}, versions...)
+	if err != nil {
+		return nil, derrors.NewNotFoundError("unable to get REST mapping for kind", err).WithParams(group, version, kind)
+	}
+	return mapping, nil
+}
+
 //
 // Delete commands
 //
 
 // DeleteNamespace deletes a given namespace from Kubernetes and all its associated resources.
 func (k *Kubernetes) DeleteNamespace(name string) derrors.Error {
+	if k.DryRun() {
+		k.RecordEntry(entities.ManifestEntry{Action: entities.DeleteAction, APIVersion: "v1", Kind: "Namespace", Name: name})
+		return nil
+	}
 	namespaceClient := k.Client.CoreV1().Namespaces()
 	dOpts := metaV1.DeleteOptions{}
 	err := namespaceClient.Delete(name, &dOpts)
@@ -379,6 +529,10 @@ func (k *Kubernetes) DeleteEntity(namespace string, group string, version string
 	} else {
 		client = k.dynClient.Resource(resourceRequest).Namespace(namespace)
 	}
+	if k.DryRun() {
+		k.recordDelete(resourceRequest, namespace, name)
+		return nil
+	}
 	err := client.Delete(name, &metaV1.DeleteOptions{})
 	if err != nil {
 		return derrors.NewInternalError("cannot delete entity", err).WithParams(namespace, name)
@@ -410,6 +564,10 @@ func (k *Kubernetes) DeleteAllEntities(namespace string, group string, version s
 	for _, element := range list.Items {
 		if !checkIncluded(element.GetName(), excludedNames) {
 			log.Debug().Str("name", element.GetName()).Str("resource", resource).Msg("deleting entity")
+			if k.DryRun() {
+				k.recordDelete(resourceRequest, namespace, element.GetName())
+				continue
+			}
 			err := client.Delete(element.GetName(), &metaV1.DeleteOptions{})
 			if err != nil {
 				return derrors.NewInternalError("cannot delete entity", err).WithParams(namespace, element.GetName())
@@ -419,7 +577,39 @@ func (k *Kubernetes) DeleteAllEntities(namespace string, group string, version s
 	return nil
 }
 
-// MatchCRDStatus retrieves a non-namespaced CRD, and checks if a set of keys matches a given value.
+// recordDelete adds an entity that would be deleted to the manifest of the dry-run execution. The kind of the
+// entity is resolved with the resources served by the cluster so the entry can be compared with the created ones.
+func (k *Kubernetes) recordDelete(resource schema.GroupVersionResource, namespace string, name string) {
+	k.RecordEntry(entities.ManifestEntry{
+		Action:     entities.DeleteAction,
+		APIVersion: resource.GroupVersion().String(),
+		Kind:       k.kindFor(resource),
+		Resource:   resource.Resource,
+		Namespace:  namespace,
+		Name:       name,
+	})
+}
+
+// kindFor obtains the kind of a resource, or an empty string if the cluster does not serve it.
+func (k *Kubernetes) kindFor(resource schema.GroupVersionResource) string {
+	if k.discoveryClient == nil {
+		return ""
+	}
+	mapper, err := k.newMapper()
+	if err != nil {
+		log.Warn().Str("trace", err.DebugReport()).Msg("cannot resolve the kind of the deleted resource")
+		return ""
+	}
+	gvk, kErr := mapper.KindFor(resource)
+	if kErr != nil {
+		log.Warn().Err(kErr).Str("resource", resource.String()).Msg("cannot resolve the kind of the deleted resource")
+		return ""
+	}
+	return gvk.Kind
+}
+
+// MatchCRDStatus retrieves a CRD, and checks if a set of keys matches a given value. The check is performed once,
+// callers waiting for a status are expected to apply a retry policy.
 func (k *Kubernetes) MatchCRDStatus(namespace string, group string, version string, resource string, name string, key []string, expected string) (*bool, derrors.Error) {
 	resourceRequest := schema.GroupVersionResource{
 		Group:    group,
@@ -433,26 +623,15 @@ func (k *Kubernetes) MatchCRDStatus(namespace string, group string, version stri
 	} else {
 		client = k.dynClient.Resource(resourceRequest).Namespace(namespace)
 	}
-	numRetries := 36
-	issued := false
-	for retry := 0; retry < numRetries && !issued; retry++ {
-		unstructure, err := client.Get(name, metaV1.GetOptions{})
-		if err != nil {
-			log.Warn().Err(err).Msg("unable to retrieve resource")
-		} else {
-			log.Debug().Interface("raw", unstructure.Object).Msg("resource retrieved")
-			matches := k.MatchUnstructuredField(unstructure, key, expected)
-			log.Debug().Bool("match", matches).Msg("CRD status")
-			if matches {
-				return &matches, nil
-			}
-		}
-		if !issued {
-			time.Sleep(20 * time.Second)
-		}
+	unstructure, err := client.Get(name, metaV1.GetOptions{})
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to retrieve resource")
+		return nil, derrors.NewUnavailableError("unable to retrieve resource", err).WithParams(name)
 	}
-
-	return &issued, nil
+	log.Debug().Interface("raw", unstructure.Object).Msg("resource retrieved")
+	matches := k.MatchUnstructuredField(unstructure, key, expected)
+	log.Debug().Bool("match", matches).Msg("CRD status")
+	return &matches, nil
 }
 
 // MatchUnstructureField matches a json path as defined by the gjson package with a given expected value.
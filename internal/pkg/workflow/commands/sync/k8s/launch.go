@@ -18,13 +18,17 @@
 package k8s
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/nalej/derrors"
 	"github.com/nalej/grpc-installer-go"
@@ -53,6 +57,12 @@ type LaunchComponents struct {
 	ComponentsDir string   `json:"componentsDir"`
 	PlatformType  string   `json:"platform_type"`
 	Environment   string   `json:"environment"`
+	// Concurrency with the maximum number of objects of the same tier created at the same time.
+	Concurrency int `json:"concurrency,omitempty"`
+	// WaitReady determines if the command waits until the launched workloads are available.
+	WaitReady bool `json:"waitReady,omitempty"`
+	// ReadyTimeout with the maximum time to wait for the workloads, such as 10m. DefaultReadyTimeout is used if empty.
+	ReadyTimeout string `json:"readyTimeout,omitempty"`
 }
 
 // NewLaunchComponents creates a new LaunchComponents command.
@@ -80,9 +90,9 @@ func NewLaunchComponentsFromJSON(raw []byte) (*entities.Command, derrors.Error)
 }
 
 // Run the command.
-func (lc *LaunchComponents) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (lc *LaunchComponents) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 
-	connectErr := lc.Connect()
+	connectErr := lc.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -104,17 +114,52 @@ func (lc *LaunchComponents) Run(workflowID string) (*entities.CommandResult, der
 		return nil, err
 	}
 
-	numLaunched := 0
+	log.Debug().Str("targetEnvironment", entities2.TargetEnvironmentToString[targetEnvironment]).Msg("launching components")
+	objects := make([]*unstructured.Unstructured, 0)
 	for _, fileName := range components {
 		log.Info().Str("fileName", fileName).Msg("processing component")
-		err := lc.launchComponent(path.Join(lc.ComponentsDir, fileName), targetEnvironment)
+		decoded, err := ReadComponent(path.Join(lc.ComponentsDir, fileName))
 		if err != nil {
 			return entities.NewCommandResult(false, "cannot launch component", err), nil
 		}
-		numLaunched++
+		for _, obj := range decoded {
+			SetComponent(obj, ComponentName(fileName))
+		}
+		objects = append(objects, decoded...)
+	}
+
+	err = lc.ApplyInTiers(ctx, objects, lc.Concurrency, lc.launchObject)
+	if err != nil {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return entities.NewCommandResult(false, "cannot launch component", err), nil
+	}
+	if lc.WaitReady {
+		timeout, tErr := lc.readyTimeout()
+		if tErr != nil {
+			return nil, tErr
+		}
+		report, err := lc.WaitWorkloadsReady(ctx, objects, timeout)
+		if err != nil {
+			return entities.NewCommandResult(false, fmt.Sprintf("components are not ready\n%s", report), err), nil
+		}
+	}
+	lc.recordComponentsVersion(lc.ComponentsDir, components)
+	msg := fmt.Sprintf("%d objects from %d components have been launched", len(objects), len(components))
+	return lc.ApplyResult(msg), nil
+}
+
+// readyTimeout returns the maximum time to wait for the workloads to be ready.
+func (lc *LaunchComponents) readyTimeout() (time.Duration, derrors.Error) {
+	if lc.ReadyTimeout == "" {
+		return DefaultReadyTimeout, nil
 	}
-	msg := fmt.Sprintf("%d components have been launched", numLaunched)
-	return entities.NewCommandResult(true, msg, nil), nil
+	timeout, err := time.ParseDuration(lc.ReadyTimeout)
+	if err != nil || timeout <= 0 {
+		return 0, derrors.NewInvalidArgumentError(errors.InvalidTimeout).WithParams(lc.Name(), lc.ReadyTimeout)
+	}
+	return timeout, nil
 }
 
 // ListComponents obtains a list of the files that need to be installed. Platform dependent YAML files overwrite the
@@ -158,32 +203,64 @@ func (lc *LaunchComponents) ListComponents() ([]string, derrors.Error) {
 	return result, nil
 }
 
-// launchComponent triggers the creation of a given component from a YAML file
-func (lc *LaunchComponents) launchComponent(componentPath string, targetEnvironment entities2.TargetEnvironment) derrors.Error {
-	log.Debug().
-		Str("path", componentPath).
-		Str("targetEnvironment", entities2.TargetEnvironmentToString[targetEnvironment]).
-		Msg("launch component")
-
+// ReadComponent decodes the objects of a component file in order. A YAML file may contain several documents
+// separated by ---, and the empty documents are ignored.
+//   params:
+//     componentPath The path of the component file.
+//   returns:
+//     The list of decoded objects.
+//     An error if the file cannot be read or any document is invalid.
+func ReadComponent(componentPath string) ([]*unstructured.Unstructured, derrors.Error) {
 	f, err := os.Open(componentPath)
 	if err != nil {
-		return derrors.NewPermissionDeniedError("cannot read component file", err)
+		return nil, derrors.NewPermissionDeniedError("cannot read component file", err)
 	}
 	defer f.Close()
 	log.Debug().Str("path", componentPath).Msg("parsing component")
+	return DecodeObjects(f, componentPath)
+}
 
-	// We use a YAML decoder to decode the resource straight into an
-	// unstructured object. This way, we can deal with resources that are
+// DecodeObjects decodes a stream of YAML or JSON documents separated by ---. The empty documents are ignored.
+//   params:
+//     reader The reader of the stream.
+//     source The name of the stream for the error messages.
+//   returns:
+//     The list of decoded objects.
+//     An error if any document is invalid.
+func DecodeObjects(reader io.Reader, source string) ([]*unstructured.Unstructured, derrors.Error) {
+	// We use a YAML decoder to decode the resources straight into
+	// unstructured objects. This way, we can deal with resources that are
 	// not known to this client - like CustomResourceDefinitions
-	obj := runtime.Object(&unstructured.Unstructured{})
-
-	yamlDecoder := yaml.NewYAMLOrJSONDecoder(f, 1024)
-	err = yamlDecoder.Decode(obj)
-	if err != nil {
-		return derrors.NewInvalidArgumentError("cannot parse component file", err)
+	result := make([]*unstructured.Unstructured, 0)
+	yamlDecoder := yaml.NewYAMLOrJSONDecoder(reader, 1024)
+	for document := 0; ; document++ {
+		raw := json.RawMessage{}
+		err := yamlDecoder.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, derrors.NewInvalidArgumentError("cannot parse objects", err).WithParams(source, document)
+		}
+		content := bytes.TrimSpace(raw)
+		if len(content) == 0 || bytes.Equal(content, []byte("null")) {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		err = obj.UnmarshalJSON(content)
+		if err != nil {
+			return nil, derrors.NewInvalidArgumentError("cannot parse objects", err).WithParams(source, document)
+		}
+		result = append(result, obj)
 	}
+	return result, nil
+}
+
+// launchObject applies the platform modifications to an object and creates it.
+func (lc *LaunchComponents) launchObject(decoded *unstructured.Unstructured) derrors.Error {
+	obj := runtime.Object(decoded)
 	gvk := obj.GetObjectKind().GroupVersionKind()
-	log.Debug().Str("resource", gvk.String()).Msg("decoded resource")
+	log.Debug().Str("resource", gvk.String()).Str("name", decoded.GetName()).Msg("decoded resource")
 
 	// Now let's see if it's a resource we know and can type, so we can
 	// decide if we need to do some modifications. We ignore the error
@@ -254,11 +331,27 @@ func (lc *LaunchComponents) PrettyPrint(indentation int) string {
 	} else {
 		for _, c := range components {
 			cStr = cStr + "\n" + entrySep + c
+			objects, err := ReadComponent(path.Join(lc.ComponentsDir, c))
+			if err != nil {
+				cStr = cStr + "\n" + entrySep + "  <invalid>"
+				continue
+			}
+			for _, obj := range objects {
+				cStr = cStr + "\n" + entrySep + "  " + describeObject(obj)
+			}
 		}
 	}
 	return strings.Repeat(" ", indentation) + lc.String() + cStr
 }
 
+// describeObject returns the kind and the name of an object.
+func describeObject(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", obj.GetKind(), obj.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
 func (lc *LaunchComponents) UserString() string {
 	return fmt.Sprintf("Launching K8s components from %s for %s", lc.ComponentsDir, lc.Environment)
 }
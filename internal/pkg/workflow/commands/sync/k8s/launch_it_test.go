@@ -27,6 +27,7 @@ IT_K8S_KUBECONFIG=/Users/daniel/.kube/config
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
@@ -103,7 +104,7 @@ var _ = ginkgo.Describe("A launch command", func() {
 
 	ginkgo.It("should create the deployments on kubernetes", func() {
 		lc := NewLaunchComponents(itKubeConfigFile, []string{itAuxNamespace}, componentsDir, "MINIKUBE")
-		result, err := lc.Run("testLaunchComponents")
+		result, err := lc.Run(context.Background(), "testLaunchComponents")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result).ShouldNot(gomega.BeNil())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
@@ -22,6 +22,7 @@ import (
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
 	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"os"
 	"path/filepath"
 	"strings"
@@ -90,4 +91,53 @@ var _ = ginkgo.Describe("A Launch command", func() {
 			gomega.Expect(toInstall[i]).Should(gomega.Equal(expectedName))
 		}
 	})
+
+	ginkgo.It("should read every document of a component file", func() {
+		componentsDir, err := ioutil.TempDir("", "launch")
+		gomega.Expect(err).To(gomega.Succeed())
+		err = ioutil.WriteFile(filepath.Join(componentsDir, "component.yaml"), []byte(multiDocumentComponent), 0777)
+		gomega.Expect(err).To(gomega.Succeed())
+
+		objects, derr := ReadComponent(filepath.Join(componentsDir, "component.yaml"))
+		gomega.Expect(derr).To(gomega.BeNil())
+		gomega.Expect(objects).To(gomega.HaveLen(3))
+		gomega.Expect(objects[0].GetKind()).To(gomega.Equal("ServiceAccount"))
+		gomega.Expect(objects[1].GetKind()).To(gomega.Equal("ConfigMap"))
+		gomega.Expect(objects[2].GetKind()).To(gomega.Equal("Deployment"))
+		replicas, _, _ := unstructured.NestedInt64(objects[2].Object, "spec", "replicas")
+		gomega.Expect(replicas).To(gomega.Equal(int64(2)))
+
+		launchCmd := NewLaunchComponents("kubeConfigPath", []string{}, componentsDir, grpc_installer_go.Platform_AZURE.String())
+		plan := launchCmd.PrettyPrint(0)
+		gomega.Expect(plan).To(gomega.ContainSubstring("component.yaml"))
+		gomega.Expect(plan).To(gomega.ContainSubstring("ServiceAccount nalej/component"))
+		gomega.Expect(plan).To(gomega.ContainSubstring("ConfigMap nalej/component-config"))
+		gomega.Expect(plan).To(gomega.ContainSubstring("Deployment nalej/component"))
+		gomega.Expect(os.RemoveAll(componentsDir)).To(gomega.Succeed())
+	})
 })
+
+const multiDocumentComponent = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: component
+  namespace: nalej
+---
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: component-config
+  namespace: nalej
+data:
+  key: value
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: component
+  namespace: nalej
+spec:
+  replicas: 2
+`
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"path"
+	"strings"
+)
+
+// stampOwnership labels an object as owned by the installer. The component label is kept if already set, so the
+// commands may assign their own components, and defaults to the name of the command.
+func (k *Kubernetes) stampOwnership(obj *unstructured.Unstructured) {
+	if k.ownership == nil {
+		return
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 0)
+	}
+	component := labels[entities.ComponentLabel]
+	if component == "" {
+		component = k.Name()
+	}
+	for key, value := range k.ownership.Labels(component) {
+		labels[key] = value
+	}
+	obj.SetLabels(labels)
+
+	ownershipAnnotations := k.ownership.Annotations()
+	if len(ownershipAnnotations) == 0 {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 0)
+	}
+	for key, value := range ownershipAnnotations {
+		annotations[key] = value
+	}
+	obj.SetAnnotations(annotations)
+}
+
+// SetComponent assigns the component label of an object.
+func SetComponent(obj *unstructured.Unstructured, component string) {
+	value := entities.LabelValue(component)
+	if value == "" {
+		return
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 0)
+	}
+	labels[entities.ComponentLabel] = value
+	obj.SetLabels(labels)
+}
+
+// ComponentName returns the name of the component of a file, removing the extension and the platform suffix.
+func ComponentName(fileName string) string {
+	name := path.Base(fileName)
+	if index := strings.Index(name, ".yaml"); index > 0 {
+		return name[:index]
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// withoutRequestID returns a copy of an object without the annotation of the request that creates it, so the
+// objects are not updated only because they are sent by a new request.
+func withoutRequestID(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	annotations := obj.GetAnnotations()
+	if _, found := annotations[entities.RequestIDAnnotation]; !found {
+		return obj
+	}
+	result := obj.DeepCopy()
+	filtered := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		if key != entities.RequestIDAnnotation {
+			filtered[key] = value
+		}
+	}
+	if len(filtered) == 0 {
+		unstructured.RemoveNestedField(result.Object, "metadata", "annotations")
+	} else {
+		result.SetAnnotations(filtered)
+	}
+	return result
+}
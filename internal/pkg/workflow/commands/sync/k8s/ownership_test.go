@@ -0,0 +1,77 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Ownership", func() {
+
+	ginkgo.It("should label the objects with the component of the command", func() {
+		k := &Kubernetes{GenericSyncCommand: *entities.NewSyncCommand(entities.InstallIngress),
+			ownership: entities.NewOwnership("v1", "request", "cluster")}
+		obj := testObject("Service", "dns")
+		obj.SetLabels(map[string]string{"app": "dns"})
+		k.stampOwnership(obj)
+		gomega.Expect(obj.GetLabels()).To(gomega.Equal(map[string]string{
+			"app":                          "dns",
+			entities.ManagedByLabel:        entities.ManagedByValue,
+			entities.InstallerVersionLabel: "v1",
+			entities.ClusterIDLabel:        "cluster",
+			entities.ComponentLabel:        entities.InstallIngress,
+		}))
+		gomega.Expect(obj.GetAnnotations()).To(gomega.HaveKeyWithValue(entities.RequestIDAnnotation, "request"))
+
+		launched := testObject("Deployment", "dns")
+		SetComponent(launched, ComponentName("dns.yaml.azure"))
+		k.stampOwnership(launched)
+		gomega.Expect(launched.GetLabels()).To(gomega.HaveKeyWithValue(entities.ComponentLabel, "dns"))
+	})
+
+	ginkgo.It("should not update the objects only because of a new request", func() {
+		k := &Kubernetes{GenericSyncCommand: *entities.NewSyncCommand(entities.InstallIngress),
+			ownership: entities.NewOwnership("v1", "second", "")}
+		desired := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		k.stampOwnership(desired)
+		existing := toUnstructured(testConfigMap(map[string]string{"key": "value"}))
+		existing.SetLabels(desired.GetLabels())
+		existing.SetAnnotations(map[string]string{entities.RequestIDAnnotation: "first"})
+		gomega.Expect(MatchesObject(desired, existing)).To(gomega.BeFalse())
+		gomega.Expect(MatchesObject(withoutRequestID(desired), existing)).To(gomega.BeTrue())
+		gomega.Expect(desired.GetAnnotations()).To(gomega.HaveKeyWithValue(entities.RequestIDAnnotation, "second"))
+	})
+
+	ginkgo.It("should delete the namespaces and definitions last", func() {
+		objects := []ownedObject{
+			{obj: testObject("Namespace", "nalej")},
+			{obj: testObject("CustomResourceDefinition", "crd")},
+			{obj: testObject("Deployment", "dns")},
+			{obj: testObject("Ingress", "ingress")},
+			{obj: testObject("ClusterRole", "role")},
+		}
+		names := make([]string, 0)
+		for _, owned := range OrderForDeletion(objects) {
+			names = append(names, owned.obj.GetName())
+		}
+		gomega.Expect(names).To(gomega.Equal([]string{"ingress", "dns", "role", "crd", "nalej"}))
+	})
+
+})
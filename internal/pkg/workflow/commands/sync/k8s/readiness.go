@@ -0,0 +1,308 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the readiness checks of the workloads created by the commands
+//
+// The API server accepts the objects before their pods are running. The readiness checks retrieve the status of the
+// Deployments, StatefulSets, DaemonSets and Jobs every ReadyCheckSleep until they are available or the timeout
+// expires, and describe the pods that are not ready when the wait fails.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultReadyTimeout is the maximum time to wait for the workloads to be ready if the command does not define it.
+const DefaultReadyTimeout = time.Minute * 5
+
+// ReadyCheckSleep is the time between checks of the status of the workloads.
+const ReadyCheckSleep = time.Second * 5
+
+// MaxReportedEvents is the number of recent events reported for each pod that is not ready.
+const MaxReportedEvents = 5
+
+// WorkloadResources contains the resources of the kinds whose readiness is checked.
+var WorkloadResources = map[string]string{
+	"Deployment":  "deployments",
+	"StatefulSet": "statefulsets",
+	"DaemonSet":   "daemonsets",
+	"Job":         "jobs",
+}
+
+// IsWorkload checks if the readiness of an object can be checked.
+func IsWorkload(obj *unstructured.Unstructured) bool {
+	_, found := WorkloadResources[obj.GetKind()]
+	return found
+}
+
+// WorkloadReady checks the status of a workload retrieved from the cluster.
+//
+//	params:
+//	  obj The current state of the workload.
+//	returns:
+//	  Whether the workload is available.
+//	  A description of the progress of the workload.
+//	  An error if the workload has failed and will not become ready.
+func WorkloadReady(obj *unstructured.Unstructured) (bool, string, derrors.Error) {
+	generation := obj.GetGeneration()
+	observed := nestedInt(obj, "status", "observedGeneration")
+	if obj.GetKind() != "Job" && observed < generation {
+		return false, "waiting for the controller to observe the last generation", nil
+	}
+	switch obj.GetKind() {
+	case "Deployment":
+		desired := desiredReplicas(obj)
+		updated := nestedInt(obj, "status", "updatedReplicas")
+		available := nestedInt(obj, "status", "availableReplicas")
+		return updated >= desired && available >= desired, fmt.Sprintf("%d/%d available", available, desired), nil
+	case "StatefulSet":
+		desired := desiredReplicas(obj)
+		ready := nestedInt(obj, "status", "readyReplicas")
+		updated := nestedInt(obj, "status", "updatedReplicas")
+		// The OnDelete strategy does not update the pods, so only the ready replicas are considered.
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+		if strategy == "OnDelete" {
+			updated = desired
+		}
+		return ready >= desired && updated >= desired, fmt.Sprintf("%d/%d ready", ready, desired), nil
+	case "DaemonSet":
+		desired := nestedInt(obj, "status", "desiredNumberScheduled")
+		updated := nestedInt(obj, "status", "updatedNumberScheduled")
+		available := nestedInt(obj, "status", "numberAvailable")
+		return updated >= desired && available >= desired, fmt.Sprintf("%d/%d available", available, desired), nil
+	case "Job":
+		if HasCondition(obj, "Failed", "True") {
+			return false, "failed", derrors.NewFailedPreconditionError(errors.WorkloadFailed).WithParams(describeObject(obj))
+		}
+		completions := int64(1)
+		if value, found, _ := unstructured.NestedInt64(obj.Object, "spec", "completions"); found {
+			completions = value
+		}
+		succeeded := nestedInt(obj, "status", "succeeded")
+		return succeeded >= completions, fmt.Sprintf("%d/%d succeeded", succeeded, completions), nil
+	}
+	return true, "", nil
+}
+
+// desiredReplicas returns the number of replicas of a workload, which defaults to one.
+func desiredReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil || !found {
+		return 1
+	}
+	return replicas
+}
+
+// nestedInt returns an integer field of an object, or zero if it is not set.
+func nestedInt(obj *unstructured.Unstructured, path ...string) int64 {
+	value, found, err := unstructured.NestedInt64(obj.Object, path...)
+	if err != nil || !found {
+		return 0
+	}
+	return value
+}
+
+// WaitWorkloadsReady polls the workloads until they are available. The wait is bounded by a context with the
+// timeout, so slow requests to the API server do not extend it. The objects whose readiness cannot be checked are
+// ignored.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  objects The objects that have been created.
+//	  timeout The maximum time to wait.
+//	returns:
+//	  A report of the workloads and pods that are not ready if the wait fails.
+//	  An error if any workload is not ready before the timeout.
+func (k *Kubernetes) WaitWorkloadsReady(ctx context.Context, objects []*unstructured.Unstructured, timeout time.Duration) (string, derrors.Error) {
+	if k.DryRun() {
+		return "", nil
+	}
+	pending := make([]*unstructured.Unstructured, 0)
+	for _, obj := range objects {
+		if IsWorkload(obj) {
+			pending = append(pending, obj)
+		}
+	}
+	if len(pending) == 0 {
+		return "", nil
+	}
+	log.Info().Int("workloads", len(pending)).Str("timeout", timeout.String()).Msg("waiting for the workloads to be ready")
+
+	// current contains the last state retrieved of the workloads that are not ready.
+	current := make(map[*unstructured.Unstructured]*unstructured.Unstructured, 0)
+	progress := make(map[*unstructured.Unstructured]string, 0)
+	var failed derrors.Error
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	policy := entities.NewRetryPolicy(int(timeout/ReadyCheckSleep)+1, entities.NewConstantBackoff(ReadyCheckSleep), entities.RetryOnFailure)
+	pollErr := policy.Poll(waitCtx, func() (bool, derrors.Error) {
+		notReady := make([]*unstructured.Unstructured, 0)
+		for _, obj := range pending {
+			retrieved, err := k.workloadClient(obj).Get(obj.GetName(), metaV1.GetOptions{})
+			if err != nil {
+				progress[obj] = "cannot be retrieved"
+				notReady = append(notReady, obj)
+				continue
+			}
+			current[obj] = retrieved
+			ready, description, derr := WorkloadReady(retrieved)
+			if derr != nil {
+				failed = derr
+				return false, derr
+			}
+			progress[obj] = description
+			if !ready {
+				notReady = append(notReady, obj)
+			}
+		}
+		pending = notReady
+		return len(pending) == 0, nil
+	})
+	if pollErr == nil {
+		return "", nil
+	}
+	if ctx.Err() != nil {
+		// The command has been canceled, or its own timeout has been reached.
+		return "", entities.ContextError(ctx)
+	}
+
+	report := make([]string, 0)
+	for _, obj := range pending {
+		report = append(report, fmt.Sprintf("%s: %s", describeObject(obj), progress[obj]))
+		if retrieved, found := current[obj]; found {
+			report = append(report, k.describePods(retrieved)...)
+		}
+	}
+	if failed != nil {
+		return strings.Join(report, "\n"), failed
+	}
+	return strings.Join(report, "\n"), derrors.NewDeadlineExceededError(errors.WorkloadsNotReady, pollErr).WithParams(len(pending))
+}
+
+// workloadClient returns the dynamic client of the resource of a workload.
+func (k *Kubernetes) workloadClient(obj *unstructured.Unstructured) dynamic.ResourceInterface {
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		gv = schema.GroupVersion{Group: "apps", Version: "v1"}
+	}
+	resource := k.dynClient.Resource(gv.WithResource(WorkloadResources[obj.GetKind()]))
+	if obj.GetNamespace() == "" {
+		return resource
+	}
+	return resource.Namespace(obj.GetNamespace())
+}
+
+// describePods returns the description of the pods of a workload that are not ready.
+func (k *Kubernetes) describePods(workload *unstructured.Unstructured) []string {
+	selector, found, err := unstructured.NestedStringMap(workload.Object, "spec", "selector", "matchLabels")
+	if err != nil || !found || len(selector) == 0 {
+		return nil
+	}
+	labels := make([]string, 0, len(selector))
+	for key, value := range selector {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(labels)
+	pods, lErr := k.Client.CoreV1().Pods(workload.GetNamespace()).List(metaV1.ListOptions{LabelSelector: strings.Join(labels, ",")})
+	if lErr != nil {
+		log.Warn().Err(lErr).Str("workload", describeObject(workload)).Msg("cannot list pods")
+		return nil
+	}
+	result := make([]string, 0)
+	for _, pod := range pods.Items {
+		if PodReady(pod) {
+			continue
+		}
+		selector := fields.OneTermEqualSelector("involvedObject.name", pod.Name).String()
+		events, eErr := k.Client.CoreV1().Events(pod.Namespace).List(metaV1.ListOptions{FieldSelector: selector})
+		if eErr != nil {
+			log.Warn().Err(eErr).Str("pod", pod.Name).Msg("cannot list events")
+			result = append(result, DescribePod(pod, nil)...)
+			continue
+		}
+		result = append(result, DescribePod(pod, events.Items)...)
+	}
+	return result
+}
+
+// PodReady checks if a pod has the Ready condition or has completed.
+func PodReady(pod v1.Pod) bool {
+	if pod.Status.Phase == v1.PodSucceeded {
+		return true
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// DescribePod returns the status of the containers and the last events of a pod that is not ready.
+//
+//	params:
+//	  pod The pod.
+//	  events The events of the pod.
+//	returns:
+//	  The lines of the description.
+func DescribePod(pod v1.Pod, events []v1.Event) []string {
+	result := []string{fmt.Sprintf("  pod %s: %s", pod.Name, pod.Status.Phase)}
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		line := fmt.Sprintf("    container %s: ready %t, restarts %d", status.Name, status.Ready, status.RestartCount)
+		if status.State.Waiting != nil {
+			line = line + fmt.Sprintf(", waiting %s", status.State.Waiting.Reason)
+			if status.State.Waiting.Message != "" {
+				line = line + fmt.Sprintf(" (%s)", status.State.Waiting.Message)
+			}
+		}
+		if status.State.Terminated != nil {
+			line = line + fmt.Sprintf(", terminated %s", status.State.Terminated.Reason)
+		}
+		if status.LastTerminationState.Terminated != nil {
+			line = line + fmt.Sprintf(", last terminated %s (exit code %d)",
+				status.LastTerminationState.Terminated.Reason, status.LastTerminationState.Terminated.ExitCode)
+		}
+		result = append(result, line)
+	}
+	sorted := append([]v1.Event{}, events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].LastTimestamp.Before(&sorted[j].LastTimestamp)
+	})
+	if len(sorted) > MaxReportedEvents {
+		sorted = sorted[len(sorted)-MaxReportedEvents:]
+	}
+	for _, event := range sorted {
+		result = append(result, fmt.Sprintf("    event %s %s: %s", event.Type, event.Reason, event.Message))
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testWorkload(kind string, spec map[string]interface{}, status map[string]interface{}) *unstructured.Unstructured {
+	obj := testObject(kind, "workload")
+	obj.SetNamespace("nalej")
+	obj.SetGeneration(2)
+	obj.Object["spec"] = spec
+	obj.Object["status"] = status
+	return obj
+}
+
+var _ = ginkgo.Describe("Workload readiness", func() {
+
+	ginkgo.It("should check the available replicas of a deployment", func() {
+		deployment := testWorkload("Deployment", map[string]interface{}{"replicas": int64(2)},
+			map[string]interface{}{"observedGeneration": int64(2), "updatedReplicas": int64(2), "availableReplicas": int64(1)})
+		ready, description, err := WorkloadReady(deployment)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(ready).To(gomega.BeFalse())
+		gomega.Expect(description).To(gomega.Equal("1/2 available"))
+		deployment.Object["status"].(map[string]interface{})["availableReplicas"] = int64(2)
+		ready, _, _ = WorkloadReady(deployment)
+		gomega.Expect(ready).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("should wait for the controller to observe the last generation", func() {
+		statefulSet := testWorkload("StatefulSet", map[string]interface{}{},
+			map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1), "updatedReplicas": int64(1)})
+		ready, _, err := WorkloadReady(statefulSet)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(ready).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should check the scheduled pods of a daemon set", func() {
+		daemonSet := testWorkload("DaemonSet", map[string]interface{}{}, map[string]interface{}{"observedGeneration": int64(2),
+			"desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(3)})
+		ready, _, err := WorkloadReady(daemonSet)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(ready).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("should fail on failed jobs", func() {
+		job := testWorkload("Job", map[string]interface{}{}, map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Failed", "status": "True"}},
+		})
+		ready, _, err := WorkloadReady(job)
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		gomega.Expect(ready).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should describe the pods that are not ready", func() {
+		pod := v1.Pod{}
+		pod.Name = "workload-1"
+		pod.Status.Phase = v1.PodRunning
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+			Name:         "app",
+			RestartCount: 3,
+			State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: v1.ContainerState{
+				Terminated: &v1.ContainerStateTerminated{Reason: "Error", ExitCode: 1},
+			},
+		}}
+		events := []v1.Event{{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container"}}
+		gomega.Expect(PodReady(pod)).To(gomega.BeFalse())
+		gomega.Expect(DescribePod(pod, events)).To(gomega.Equal([]string{
+			"  pod workload-1: Running",
+			"    container app: ready false, restarts 3, waiting CrashLoopBackOff, last terminated Error (exit code 1)",
+			"    event Warning BackOff: Back-off restarting failed container",
+		}))
+	})
+
+})
@@ -0,0 +1,219 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the commands that interact with Kubernetes.
+
+package k8s
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+// KubernetesParameters contains the descriptions of the parameters shared by the commands that embed Kubernetes.
+var KubernetesParameters = map[string]string{
+	"kubeConfigPath": "Path of the kubeconfig file of the target cluster",
+	"applyMode":      "How the objects are sent to the cluster: create, update or apply. Create by default",
+	"fieldManager":   "Owner of the fields sent in the update and apply modes",
+}
+
+// WithKubernetesParameters returns the descriptions of a command including the ones of the Kubernetes parameters.
+//   params:
+//     descriptions The descriptions of the parameters specific to the command.
+//   returns:
+//     A new map with all the descriptions.
+func WithKubernetesParameters(descriptions map[string]string) map[string]string {
+	result := make(map[string]string, len(KubernetesParameters)+len(descriptions))
+	for name, description := range KubernetesParameters {
+		result[name] = description
+	}
+	for name, description := range descriptions {
+		result[name] = description
+	}
+	return result
+}
+
+// registerKubernetesCommand registers a synchronous command that embeds Kubernetes.
+func registerKubernetesCommand(name string, description string, fromJSON entities.CommandFactory, command interface{}, descriptions map[string]string) {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        name,
+		Description: description,
+		FromJSON:    fromJSON,
+		Parameters:  entities.ParametersOf(command, WithKubernetesParameters(descriptions)),
+	})
+}
+
+// deleteParameters contains the descriptions of the parameters of the commands that delete a single object.
+var deleteParameters = map[string]string{
+	"namespace":          "Namespace of the object",
+	"fail_if_not_exists": "Whether the command fails if the object does not exist",
+}
+
+// withDeleteParameters returns the descriptions of a delete command including the common ones.
+func withDeleteParameters(name string, description string) map[string]string {
+	result := map[string]string{name: description}
+	for parameter, parameterDescription := range deleteParameters {
+		result[parameter] = parameterDescription
+	}
+	return result
+}
+
+func init() {
+	registerKubernetesCommand(entities.LaunchComponents, "Creates the objects of the component files of a directory",
+		NewLaunchComponentsFromJSON, &LaunchComponents{}, map[string]string{
+			"namespaces":    "Namespaces created before the components",
+			"componentsDir": "Directory with the YAML files of the components",
+			"platform_type": "Target platform, used to select the platform specific files",
+			"environment":   "Target environment, used to select the environment specific files",
+			"concurrency":   "Maximum number of objects of the same tier created at the same time",
+			"waitReady":     "Whether the command waits until the launched workloads are available",
+			"readyTimeout":  "Maximum time to wait for the workloads, such as 10m",
+		})
+	registerKubernetesCommand(entities.CheckRequirements, "Checks that the cluster satisfies the requirements of the platform",
+		NewCheckRequirementsFromJSON, &CheckRequirements{}, map[string]string{
+			"minVersion": "Minimum version of Kubernetes",
+		})
+	registerKubernetesCommand(entities.CreateClusterConfig, "Creates the config map with the configuration of an application cluster",
+		NewCreateClusterConfigFromJSON, &CreateClusterConfig{}, map[string]string{
+			"organization_id":         "Identifier of the organization",
+			"cluster_id":              "Identifier of the cluster",
+			"management_public_host":  "Public host of the management cluster",
+			"management_public_port":  "Public port of the management cluster",
+			"cluster_public_hostname": "Public hostname of the cluster",
+			"dns_public_host":         "Public host of the DNS server",
+			"dns_public_port":         "Public port of the DNS server",
+			"platform_type":           "Target platform",
+		})
+	registerKubernetesCommand(entities.CreateManagementConfig, "Creates the config map with the configuration of the management cluster",
+		NewCreateManagementConfigFromJSON, &CreateManagementConfig{}, map[string]string{
+			"public_host":   "Public host of the management cluster",
+			"public_port":   "Public port of the management cluster",
+			"dns_host":      "Host of the DNS server",
+			"dns_port":      "Port of the DNS server",
+			"platform_type": "Target platform",
+			"environment":   "Target environment",
+		})
+	registerKubernetesCommand(entities.UpdateCoreDNS, "Forwards the platform domain of CoreDNS to the DNS of the management cluster",
+		NewUpdateCoreDNSFromJSON, &UpdateCoreDNS{}, map[string]string{
+			"dns_public_host": "Public host of the DNS server",
+			"dns_public_port": "Public port of the DNS server",
+		})
+	registerKubernetesCommand(entities.UpdateKubeDNS, "Forwards the platform domain of KubeDNS to the DNS of the management cluster",
+		NewUpdateKubeDNSFromJSON, &UpdateKubeDNS{}, map[string]string{
+			"dns_public_host": "Public host of the DNS server",
+		})
+	registerKubernetesCommand(entities.CreateRegistrySecrets, "Creates the secrets to pull images from a Docker registry",
+		NewCreateRegistrySecretsFromJSON, &CreateRegistrySecrets{}, map[string]string{
+			"on_management_cluster": "Whether the secrets are created on the management cluster",
+			"credentials_name":      "Name of the secret",
+			"username":              "Username of the registry",
+			"password":              "Password of the registry",
+			"url":                   "URL of the registry",
+		})
+	registerKubernetesCommand(entities.AddClusterUser, "Creates the user of an application cluster in the management cluster",
+		NewAddClusterUserFromJSON, &AddClusterUser{}, map[string]string{
+			"organization_id":      "Identifier of the organization",
+			"cluster_id":           "Identifier of the cluster",
+			"user_manager_address": "Address of the user manager",
+		})
+	registerKubernetesCommand(entities.CreateOpaqueSecret, "Creates a secret with a single value",
+		NewCreateOpaqueSecretFromJSON, &CreateOpaqueSecret{}, map[string]string{
+			"secret_name":            "Name of the secret",
+			"secret_key":             "Key of the value in the secret",
+			"secret_value":           "Value of the secret",
+			"load_from_path":         "Whether the value is read from a file",
+			"secret_value_from_path": "Path of the file with the value",
+		})
+	registerKubernetesCommand(entities.CreateCACert, "Creates the CA certificate of the platform",
+		NewCreateCACertFromJSON, &CreateCACert{}, map[string]string{
+			"public_host": "Public host of the management cluster",
+		})
+	registerKubernetesCommand(entities.CreateTLSSecret, "Creates a TLS secret from a certificate and its private key",
+		NewCreateTLSSecretFromJSON, &CreateTLSSecret{}, map[string]string{
+			"secret_name":      "Name of the secret",
+			"private_key_path": "Path of the private key",
+			"cert_path":        "Path of the certificate",
+		})
+	registerKubernetesCommand(entities.DeleteNamespace, "Deletes a namespace",
+		NewDeleteNamespaceFromJSON, &DeleteNamespace{}, withDeleteParameters("namespace", "Name of the namespace"))
+	registerKubernetesCommand(entities.DeleteNalejNamespace, "Deletes the nalej namespace and the cluster objects of the platform",
+		NewDeleteNalejNamespaceFromJSON, &DeleteNalejNamespace{}, deleteParameters)
+	registerKubernetesCommand(entities.DeleteServiceAccount, "Deletes a service account",
+		NewDeleteServiceAccountFromJSON, &DeleteServiceAccount{}, withDeleteParameters("service_account", "Name of the service account"))
+	registerKubernetesCommand(entities.DeleteClusterRoleBinding, "Deletes a cluster role binding",
+		NewDeleteClusterRoleBindingFromJSON, &DeleteClusterRoleBinding{}, withDeleteParameters("role_binding_name", "Name of the cluster role binding"))
+	registerKubernetesCommand(entities.DeleteClusterRole, "Deletes a cluster role",
+		NewDeleteClusterRoleFromJSON, &DeleteClusterRole{}, withDeleteParameters("role_name", "Name of the cluster role"))
+	registerKubernetesCommand(entities.DeleteRole, "Deletes a role",
+		NewDeleteRoleFromJSON, &DeleteRole{}, withDeleteParameters("role_name", "Name of the role"))
+	registerKubernetesCommand(entities.DeleteRoleBinding, "Deletes a role binding",
+		NewDeleteRoleBindingFromJSON, &DeleteRoleBinding{}, withDeleteParameters("role_name", "Name of the role binding"))
+	registerKubernetesCommand(entities.DeleteConfigMap, "Deletes a config map",
+		NewDeleteConfigMapFromJSON, &DeleteConfigMap{}, withDeleteParameters("config_map_name", "Name of the config map"))
+	registerKubernetesCommand(entities.DeleteService, "Deletes a service",
+		NewDeleteServiceFromJSON, &DeleteService{}, withDeleteParameters("service_name", "Name of the service"))
+	registerKubernetesCommand(entities.DeleteDeployment, "Deletes a deployment",
+		NewDeleteDeploymentFromJSON, &DeleteDeployment{}, withDeleteParameters("deployment_name", "Name of the deployment"))
+	registerKubernetesCommand(entities.DeletePodSecurityPolicy, "Deletes a pod security policy",
+		NewDeletePodSecurityPolicyFromJSON, &DeletePodSecurityPolicy{}, withDeleteParameters("policy_name", "Name of the pod security policy"))
+	registerKubernetesCommand(entities.WaitFor, "Waits until a resource reaches a state",
+		NewWaitForFromJSON, &WaitFor{}, map[string]string{
+			"group":         "Group of the resource, empty for the core resources",
+			"version":       "Version of the resource",
+			"resource":      "Plural name of the resource, such as services",
+			"namespace":     "Namespace of the resources, empty for cluster resources",
+			"resourceName":  "Name of the resource; either the name or the label selector must be set",
+			"labelSelector": "Label selector of the resources",
+			"path":          "Path that must exist in the resource, such as status.loadBalancer.ingress.0.ip",
+			"value":         "Value expected in the path; any non empty value if not set",
+			"condition":     "Type of a status condition that must be True, such as Ready",
+			"interval":      "Interval between the checks, such as 30s",
+			"waitTimeout":   "Maximum time to wait, such as 10m",
+		})
+	registerKubernetesCommand(entities.ApplyManifest, "Applies inline objects, a YAML stream or templated files",
+		NewApplyManifestFromJSON, &ApplyManifest{}, map[string]string{
+			"objects":     "Objects in JSON",
+			"manifest":    "YAML stream of objects separated by ---",
+			"files":       "Paths of YAML or JSON files with objects",
+			"params":      "Values available to the files as a Go template",
+			"concurrency": "Maximum number of objects of the same tier created at the same time",
+		})
+	registerKubernetesCommand(entities.DeleteResources, "Deletes the resources of a type by name or selector",
+		NewDeleteResourcesFromJSON, &DeleteResources{}, map[string]string{
+			"group":              "Group of the resources, empty for the core resources",
+			"version":            "Version of the resources; the preferred one if empty with a kind",
+			"resource":           "Plural name of the resources, such as clusterroles",
+			"kind":               "Kind of the resources, resolved through discovery if the resource is not set",
+			"namespace":          "Namespace of the resources, empty for cluster resources",
+			"names":              "Names of the resources",
+			"label_selector":     "Label selector of the resources",
+			"field_selector":     "Field selector of the resources",
+			"propagation_policy": "How the dependents are deleted: Orphan, Background or Foreground",
+			"fail_if_not_exists": "Whether the command fails if a resource does not exist",
+			"wait":               "Whether the command waits until the resources disappear",
+			"wait_timeout":       "Maximum time to wait, such as 2m",
+		})
+	registerKubernetesCommand(entities.DeleteOwnedResources, "Deletes the objects labelled as installed by the installer",
+		NewDeleteOwnedResourcesFromJSON, &DeleteOwnedResources{}, map[string]string{
+			"cluster_id":         "Restricts the deletion to the objects of a cluster; all the owned objects if empty",
+			"propagation_policy": "How the dependents are deleted: Orphan, Background or Foreground",
+		})
+	registerKubernetesCommand(entities.WriteInventory, "Stores the inventory of the install in the target cluster",
+		NewWriteInventoryFromJSON, &WriteInventory{}, map[string]string{
+			"namespace": "Namespace of the inventory config map, nalej if empty",
+		})
+}
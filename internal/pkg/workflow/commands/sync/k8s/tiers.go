@@ -0,0 +1,245 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the ordering of the objects launched from the component files
+//
+// The objects are grouped in tiers by kind so the objects other kinds depend on are created first. The objects of
+// the same tier are independent, so they are created concurrently.
+
+package k8s
+
+import (
+	"context"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sync"
+	"time"
+)
+
+// ObjectTier defines the position in which a kind of object is created.
+type ObjectTier int
+
+const (
+	// NamespaceTier for the namespaces.
+	NamespaceTier ObjectTier = iota
+	// CRDTier for the custom resource definitions.
+	CRDTier
+	// RBACTier for the service accounts and the RBAC and policy objects.
+	RBACTier
+	// ConfigTier for the config maps and secrets.
+	ConfigTier
+	// StorageTier for the storage classes and the volumes.
+	StorageTier
+	// WorkloadTier for the services and the workloads.
+	WorkloadTier
+	// CustomTier for the objects of other kinds, such as custom resources.
+	CustomTier
+	// IngressTier for the ingresses.
+	IngressTier
+)
+
+// TierByKind contains the tier of the known kinds. Other kinds belong to the CustomTier.
+var TierByKind = map[string]ObjectTier{
+	"Namespace":                NamespaceTier,
+	"CustomResourceDefinition": CRDTier,
+	"ServiceAccount":           RBACTier,
+	"ClusterRole":              RBACTier,
+	"ClusterRoleBinding":       RBACTier,
+	"Role":                     RBACTier,
+	"RoleBinding":              RBACTier,
+	"PodSecurityPolicy":        RBACTier,
+	"ConfigMap":                ConfigTier,
+	"Secret":                   ConfigTier,
+	"StorageClass":             StorageTier,
+	"PersistentVolume":         StorageTier,
+	"PersistentVolumeClaim":    StorageTier,
+	"Service":                  WorkloadTier,
+	"Deployment":               WorkloadTier,
+	"StatefulSet":              WorkloadTier,
+	"DaemonSet":                WorkloadTier,
+	"ReplicaSet":               WorkloadTier,
+	"Job":                      WorkloadTier,
+	"CronJob":                  WorkloadTier,
+	"Pod":                      WorkloadTier,
+	"Ingress":                  IngressTier,
+}
+
+// CRDEstablishedTimeout is the maximum time to wait for a custom resource definition to be established.
+const CRDEstablishedTimeout = time.Minute * 2
+
+// CRDCheckSleep is the time between checks of the status of a custom resource definition.
+const CRDCheckSleep = time.Second * 2
+
+// DefaultLaunchConcurrency is the default number of objects of the same tier created at the same time.
+const DefaultLaunchConcurrency = 10
+
+// TierOf returns the tier of an object.
+func TierOf(obj *unstructured.Unstructured) ObjectTier {
+	tier, found := TierByKind[obj.GetKind()]
+	if !found {
+		return CustomTier
+	}
+	return tier
+}
+
+// GroupByTier splits a list of objects in tiers. The tiers are returned in order of creation, and the objects of
+// each tier keep their original order.
+func GroupByTier(objects []*unstructured.Unstructured) [][]*unstructured.Unstructured {
+	grouped := make([][]*unstructured.Unstructured, IngressTier+1)
+	for _, obj := range objects {
+		tier := TierOf(obj)
+		grouped[tier] = append(grouped[tier], obj)
+	}
+	result := make([][]*unstructured.Unstructured, 0, len(grouped))
+	for _, tier := range grouped {
+		if len(tier) > 0 {
+			result = append(result, tier)
+		}
+	}
+	return result
+}
+
+// launchConcurrently creates the objects of a tier running at most concurrency creations at the same time.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  objects The objects of the tier.
+//	  concurrency The maximum number of objects created at the same time.
+//	  launch The function that creates an object.
+//	returns:
+//	  The first error found.
+func launchConcurrently(ctx context.Context, objects []*unstructured.Unstructured, concurrency int,
+	launch func(obj *unstructured.Unstructured) derrors.Error) derrors.Error {
+	if concurrency <= 0 {
+		concurrency = DefaultLaunchConcurrency
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	var firstErr derrors.Error
+	for _, obj := range objects {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			errLock.Lock()
+			if firstErr == nil {
+				firstErr = ctxErr
+			}
+			errLock.Unlock()
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(obj *unstructured.Unstructured) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			err := launch(obj)
+			if err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errLock.Unlock()
+			}
+		}(obj)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// ApplyInTiers creates a set of objects by tiers, so the objects other kinds depend on, such as the namespaces or
+// the custom resource definitions, exist before the objects that use them.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  objects The objects to be created.
+//	  concurrency The maximum number of objects of a tier created at the same time.
+//	  launch The function that creates an object.
+//	returns:
+//	  The first error found.
+func (k *Kubernetes) ApplyInTiers(ctx context.Context, objects []*unstructured.Unstructured, concurrency int,
+	launch func(obj *unstructured.Unstructured) derrors.Error) derrors.Error {
+	for _, tier := range GroupByTier(objects) {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return ctxErr
+		}
+		err := launchConcurrently(ctx, tier, concurrency, launch)
+		if err != nil {
+			return err
+		}
+		if TierOf(tier[0]) == CRDTier {
+			for _, crd := range tier {
+				err = k.WaitEstablished(ctx, crd)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WaitEstablished waits until a custom resource definition is established, so its custom resources can be created.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  crd The custom resource definition.
+//	returns:
+//	  An error if the definition is not established before the timeout.
+func (k *Kubernetes) WaitEstablished(ctx context.Context, crd *unstructured.Unstructured) derrors.Error {
+	if k.DryRun() {
+		return nil
+	}
+	mapping, err := k.PreferredResource(CRDKind, CRDGroup)
+	if err != nil {
+		return err
+	}
+	resource := mapping.Resource
+	log.Debug().Str("name", crd.GetName()).Msg("waiting for the custom resource definition to be established")
+	policy := entities.NewRetryPolicy(int(CRDEstablishedTimeout/CRDCheckSleep), entities.NewConstantBackoff(CRDCheckSleep))
+	pollErr := policy.Poll(ctx, func() (bool, derrors.Error) {
+		current, err := k.dynClient.Resource(resource).Get(crd.GetName(), metaV1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		return HasCondition(current, "Established", "True"), nil
+	})
+	if pollErr != nil {
+		return derrors.NewDeadlineExceededError(errors.CRDNotEstablished, pollErr).WithParams(crd.GetName())
+	}
+	return nil
+}
+
+// HasCondition checks if the status of an object contains a condition with the expected status.
+func HasCondition(obj *unstructured.Unstructured, conditionType string, status string) bool {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return false
+	}
+	for _, element := range conditions {
+		condition, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == conditionType && condition["status"] == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"context"
+	"github.com/nalej/derrors"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sync"
+)
+
+func testObject(kind string, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind(kind)
+	obj.SetName(name)
+	return obj
+}
+
+var _ = ginkgo.Describe("Object tiers", func() {
+
+	ginkgo.It("should group the objects in order of creation", func() {
+		objects := []*unstructured.Unstructured{
+			testObject("Ingress", "ingress"),
+			testObject("Deployment", "first"),
+			testObject("Certificate", "custom"),
+			testObject("ConfigMap", "config"),
+			testObject("Namespace", "nalej"),
+			testObject("Deployment", "second"),
+			testObject("CustomResourceDefinition", "crd"),
+			testObject("ServiceAccount", "account"),
+			testObject("PersistentVolumeClaim", "claim"),
+		}
+		tiers := GroupByTier(objects)
+		names := make([][]string, 0)
+		for _, tier := range tiers {
+			tierNames := make([]string, 0)
+			for _, obj := range tier {
+				tierNames = append(tierNames, obj.GetName())
+			}
+			names = append(names, tierNames)
+		}
+		gomega.Expect(names).To(gomega.Equal([][]string{
+			{"nalej"}, {"crd"}, {"account"}, {"config"}, {"claim"}, {"first", "second"}, {"custom"}, {"ingress"},
+		}))
+	})
+
+	ginkgo.It("should check the conditions of an object", func() {
+		crd := testObject("CustomResourceDefinition", "crd")
+		gomega.Expect(HasCondition(crd, "Established", "True")).To(gomega.BeFalse())
+		err := unstructured.SetNestedSlice(crd.Object, []interface{}{
+			map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+			map[string]interface{}{"type": "Established", "status": "True"},
+		}, "status", "conditions")
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(HasCondition(crd, "Established", "True")).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("should launch every object of a tier and report the first error", func() {
+		objects := []*unstructured.Unstructured{
+			testObject("ConfigMap", "a"), testObject("ConfigMap", "b"), testObject("Secret", "c"),
+		}
+		var lock sync.Mutex
+		launched := make(map[string]bool, 0)
+		err := launchConcurrently(context.Background(), objects, 2, func(obj *unstructured.Unstructured) derrors.Error {
+			lock.Lock()
+			defer lock.Unlock()
+			launched[obj.GetName()] = true
+			if obj.GetName() == "b" {
+				return derrors.NewInternalError("failed")
+			}
+			return nil
+		})
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		gomega.Expect(len(launched)).To(gomega.Equal(3))
+	})
+
+})
@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -79,8 +80,8 @@ func NewUpdateCoreDNSFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 	return &r, nil
 }
 
-func (uc *UpdateCoreDNS) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := uc.Connect()
+func (uc *UpdateCoreDNS) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := uc.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -117,6 +118,9 @@ func (uc *UpdateCoreDNS) updateConfig(cfg *v1.ConfigMap) derrors.Error {
 
 	toUpdate := strings.Replace(CoreDNSUpdateTemplate, "DNS_PUBLIC_IPS", strings.Join(mgntIPs, " "), 1)
 	cfg.Data[CoreDNSSection] = toUpdate
+	if uc.DryRun() {
+		return uc.Record(entities.UpdateAction, cfg)
+	}
 	client := uc.Client.CoreV1().ConfigMaps(CoreDNSNamespace)
 	updated, err := client.Update(cfg)
 	if err != nil {
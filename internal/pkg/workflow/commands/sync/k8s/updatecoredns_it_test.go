@@ -23,6 +23,7 @@ IT_K8S_KUBECONFIG=/Users/daniel/.kube/config
 package k8s
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
@@ -47,7 +48,7 @@ var _ = ginkgo.Describe("An update CoreDNS config command", func() {
 
 	ginkgo.It("should be able to update the config map", func() {
 		uc := NewUpdateCoreDNS(itKubeConfigFile, "managementPublicHost")
-		result, err := uc.Run("updateCoreDNS")
+		result, err := uc.Run(context.Background(), "updateCoreDNS")
 		gomega.Expect(err).To(gomega.Succeed())
 		gomega.Expect(result.Success).Should(gomega.BeTrue())
 	})
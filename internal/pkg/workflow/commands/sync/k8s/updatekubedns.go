@@ -18,6 +18,7 @@
 package k8s
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -62,8 +63,8 @@ func NewUpdateKubeDNSFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 	return &r, nil
 }
 
-func (uk *UpdateKubeDNS) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
-	connectErr := uk.Connect()
+func (uk *UpdateKubeDNS) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	connectErr := uk.ConnectWithContext(ctx)
 	if connectErr != nil {
 		return nil, connectErr
 	}
@@ -103,6 +104,9 @@ func (uk *UpdateKubeDNS) updateConfig(cfg *v1.ConfigMap) derrors.Error {
 
 	toUpdate := strings.Replace(KubeDNSUpdateTemplate, "DNS_PUBLIC_IPS", strings.Join(mgntIPs, ", "), 1)
 	cfg.Data[KubeDNSSection] = toUpdate
+	if uk.DryRun() {
+		return uk.Record(entities.UpdateAction, cfg)
+	}
 	client := uk.Client.CoreV1().ConfigMaps(KubeDNSNamespace)
 	updated, err := client.Update(cfg)
 	if err != nil {
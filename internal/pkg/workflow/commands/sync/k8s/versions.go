@@ -0,0 +1,342 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the resolution of the API versions served by the cluster
+//
+// Some kinds have moved between groups and versions, such as the ingresses from extensions/v1beta1 to
+// networking.k8s.io/v1, or the custom resource definitions from apiextensions.k8s.io/v1beta1 to v1. The objects of
+// those kinds are sent with the preferred version served by the cluster, converting the definitions written for
+// the older versions.
+
+package k8s
+
+import (
+	"github.com/nalej/derrors"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+)
+
+// IngressKind is the kind of the ingresses.
+const IngressKind = "Ingress"
+
+// IngressGroups contains the groups that may serve the ingresses in order of preference.
+var IngressGroups = []string{"networking.k8s.io", "extensions"}
+
+// IngressV1 is the version of the ingresses that changes the definition of the backends.
+var IngressV1 = schema.GroupVersion{Group: "networking.k8s.io", Version: "v1"}
+
+// CRDKind is the kind of the custom resource definitions.
+const CRDKind = "CustomResourceDefinition"
+
+// CRDGroup is the group of the custom resource definitions.
+const CRDGroup = "apiextensions.k8s.io"
+
+// CRDV1 is the version of the custom resource definitions that requires a schema per version.
+var CRDV1 = schema.GroupVersion{Group: CRDGroup, Version: "v1"}
+
+// DefaultIngressPathType is the path type of the ingress paths converted from the versions that do not define it.
+const DefaultIngressPathType = "ImplementationSpecific"
+
+// newMapper creates a REST mapper with the resources currently served by the cluster.
+func (k *Kubernetes) newMapper() (meta.RESTMapper, derrors.Error) {
+	resources, err := restmapper.GetAPIGroupResources(k.discoveryClient)
+	if err != nil {
+		return nil, derrors.NewInternalError("failed to get api group resources", err)
+	}
+	return restmapper.NewDiscoveryRESTMapper(resources), nil
+}
+
+// PreferredResource obtains the resource of a kind that may be served by several groups. The preferred version of
+// the first group that serves the kind is used.
+//
+//	params:
+//	  kind The kind, such as Ingress.
+//	  groups The groups that may serve the kind in order of preference.
+//	returns:
+//	  The mapping with the resource and its scope.
+//	  An error if none of the groups serves the kind.
+func (k *Kubernetes) PreferredResource(kind string, groups ...string) (*meta.RESTMapping, derrors.Error) {
+	mapper, err := k.newMapper()
+	if err != nil {
+		return nil, err
+	}
+	return preferredMapping(mapper, kind, groups...)
+}
+
+func preferredMapping(mapper meta.RESTMapper, kind string, groups ...string) (*meta.RESTMapping, derrors.Error) {
+	for _, group := range groups {
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: group, Kind: kind})
+		if err == nil {
+			return mapping, nil
+		}
+	}
+	return nil, derrors.NewNotFoundError("kind not served by the cluster").WithParams(kind, groups)
+}
+
+// FirstOfKind retrieves an object of a kind that may be served by several groups.
+//
+//	params:
+//	  namespace The namespace of the object, or empty to look in all the namespaces.
+//	  kind The kind of the object.
+//	  groups The groups that may serve the kind in order of preference.
+//	returns:
+//	  The first object found, or nil if there are not objects of that kind.
+//	  An error if the objects cannot be listed.
+func (k *Kubernetes) FirstOfKind(namespace string, kind string, groups ...string) (*unstructured.Unstructured, derrors.Error) {
+	mapping, err := k.PreferredResource(kind, groups...)
+	if err != nil {
+		return nil, err
+	}
+	opts := metaV1.ListOptions{Limit: 1}
+	var list *unstructured.UnstructuredList
+	var lErr error
+	if namespace == "" {
+		list, lErr = k.dynClient.Resource(mapping.Resource).List(opts)
+	} else {
+		list, lErr = k.dynClient.Resource(mapping.Resource).Namespace(namespace).List(opts)
+	}
+	if lErr != nil {
+		return nil, derrors.NewInternalError("cannot list entities", lErr).WithParams(mapping.Resource.String())
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	return &list.Items[0], nil
+}
+
+// DeleteAllOfKind deletes all the entities of a kind that may be served by several groups.
+//
+//	params:
+//	  namespace The namespace of the entities, or empty for cluster wide kinds.
+//	  kind The kind of the entities.
+//	  groups The groups that may serve the kind in order of preference.
+//	  excludedNames The names of the entities that must be kept.
+//	returns:
+//	  An error if the entities cannot be deleted.
+func (k *Kubernetes) DeleteAllOfKind(namespace string, kind string, groups []string, excludedNames ...string) derrors.Error {
+	mapping, err := k.PreferredResource(kind, groups...)
+	if err != nil {
+		return err
+	}
+	resource := mapping.Resource
+	return k.DeleteAllEntities(namespace, resource.Group, resource.Version, resource.Resource, excludedNames...)
+}
+
+// adaptVersion sends the ingresses and the custom resource definitions with the preferred version of the cluster.
+// The objects of other kinds are not modified.
+func (k *Kubernetes) adaptVersion(obj *unstructured.Unstructured) derrors.Error {
+	gvk := obj.GroupVersionKind()
+	var groups []string
+	switch {
+	case gvk.Kind == IngressKind && (gvk.Group == IngressGroups[0] || gvk.Group == IngressGroups[1]):
+		groups = IngressGroups
+	case gvk.Kind == CRDKind && gvk.Group == CRDGroup:
+		groups = []string{CRDGroup}
+	default:
+		return nil
+	}
+	mapping, err := k.PreferredResource(gvk.Kind, groups...)
+	if err != nil {
+		// The mapping of the original version reports the error if the kind is not served.
+		return nil
+	}
+	return ConvertVersion(obj, mapping.GroupVersionKind.GroupVersion())
+}
+
+// ConvertVersion transforms an ingress or a custom resource definition to the given version. The definitions are
+// converted when moving from v1beta1 to v1, otherwise only the apiVersion changes.
+//
+//	params:
+//	  obj The object to be converted.
+//	  target The group and version to be used.
+//	returns:
+//	  An error if the object cannot be converted.
+func ConvertVersion(obj *unstructured.Unstructured, target schema.GroupVersion) derrors.Error {
+	current := obj.GroupVersionKind().GroupVersion()
+	if current == target {
+		return nil
+	}
+	log.Debug().Str("kind", obj.GetKind()).Str("name", obj.GetName()).Str("from", current.String()).
+		Str("to", target.String()).Msg("converting object to the served version")
+	var err derrors.Error
+	if current.Version == "v1beta1" {
+		switch {
+		case obj.GetKind() == IngressKind && target == IngressV1:
+			err = convertIngressV1(obj)
+		case obj.GetKind() == CRDKind && target == CRDV1:
+			err = convertCRDV1(obj)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetAPIVersion(target.String())
+	return nil
+}
+
+// convertIngressV1 transforms the definition of a v1beta1 ingress into a v1 one.
+func convertIngressV1(obj *unstructured.Unstructured) derrors.Error {
+	spec, found, _ := unstructured.NestedMap(obj.Object, "spec")
+	if !found {
+		return nil
+	}
+	if backend, ok := spec["backend"].(map[string]interface{}); ok {
+		spec["defaultBackend"] = convertIngressBackend(backend)
+		delete(spec, "backend")
+	}
+	rules, _ := spec["rules"].([]interface{})
+	for _, rawRule := range rules {
+		rule, ok := rawRule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		http, ok := rule["http"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _ := http["paths"].([]interface{})
+		for _, rawPath := range paths {
+			path, ok := rawPath.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				path["backend"] = convertIngressBackend(backend)
+			}
+			if pathType, _ := path["pathType"].(string); pathType == "" {
+				path["pathType"] = DefaultIngressPathType
+			}
+		}
+	}
+	if err := unstructured.SetNestedMap(obj.Object, spec, "spec"); err != nil {
+		return derrors.NewInternalError("cannot convert ingress", err).WithParams(obj.GetName())
+	}
+	return nil
+}
+
+// convertIngressBackend transforms a v1beta1 backend with serviceName and servicePort into a v1 service backend.
+func convertIngressBackend(backend map[string]interface{}) map[string]interface{} {
+	serviceName, found := backend["serviceName"]
+	if !found {
+		return backend
+	}
+	port := make(map[string]interface{}, 0)
+	switch value := backend["servicePort"].(type) {
+	case string:
+		port["name"] = value
+	case int64:
+		port["number"] = value
+	case float64:
+		port["number"] = int64(value)
+	case int:
+		port["number"] = int64(value)
+	}
+	result := make(map[string]interface{}, len(backend))
+	for key, value := range backend {
+		if key != "serviceName" && key != "servicePort" {
+			result[key] = value
+		}
+	}
+	result["service"] = map[string]interface{}{"name": serviceName, "port": port}
+	return result
+}
+
+// convertCRDV1 transforms the definition of a v1beta1 custom resource definition into a v1 one. The top level
+// fields that apply to all the versions are moved into each version, and the versions without a schema preserve the
+// unknown fields as the v1beta1 definitions did by default.
+func convertCRDV1(obj *unstructured.Unstructured) derrors.Error {
+	spec, found, _ := unstructured.NestedMap(obj.Object, "spec")
+	if !found {
+		return nil
+	}
+	versions, _ := spec["versions"].([]interface{})
+	if len(versions) == 0 {
+		if name, ok := spec["version"].(string); ok && name != "" {
+			versions = []interface{}{map[string]interface{}{"name": name, "served": true, "storage": true}}
+		}
+	}
+	preserveUnknown := true
+	if preserve, ok := spec["preserveUnknownFields"].(bool); ok {
+		preserveUnknown = preserve
+	}
+	validation, _ := spec["validation"].(map[string]interface{})
+	subresources, hasSubresources := spec["subresources"]
+	columns, hasColumns := spec["additionalPrinterColumns"].([]interface{})
+	for _, rawVersion := range versions {
+		version, ok := rawVersion.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, found := version["schema"]; !found {
+			if validation != nil {
+				version["schema"] = validation
+			} else {
+				version["schema"] = map[string]interface{}{"openAPIV3Schema": map[string]interface{}{"type": "object"}}
+			}
+		}
+		if preserveUnknown {
+			if schema, ok := version["schema"].(map[string]interface{}); ok {
+				if openAPI, ok := schema["openAPIV3Schema"].(map[string]interface{}); ok {
+					openAPI["x-kubernetes-preserve-unknown-fields"] = true
+				}
+			}
+		}
+		if _, found := version["subresources"]; !found && hasSubresources {
+			version["subresources"] = subresources
+		}
+		versionColumns, found := version["additionalPrinterColumns"].([]interface{})
+		if !found && hasColumns {
+			versionColumns = columns
+		}
+		if versionColumns != nil {
+			version["additionalPrinterColumns"] = convertPrinterColumns(versionColumns)
+		}
+	}
+	spec["versions"] = versions
+	for _, field := range []string{"version", "validation", "subresources", "additionalPrinterColumns", "preserveUnknownFields"} {
+		delete(spec, field)
+	}
+	if err := unstructured.SetNestedMap(obj.Object, spec, "spec"); err != nil {
+		return derrors.NewInternalError("cannot convert custom resource definition", err).WithParams(obj.GetName())
+	}
+	return nil
+}
+
+// convertPrinterColumns renames the JSONPath field of the v1beta1 printer columns.
+func convertPrinterColumns(columns []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(columns))
+	for _, rawColumn := range columns {
+		column, ok := rawColumn.(map[string]interface{})
+		if !ok {
+			result = append(result, rawColumn)
+			continue
+		}
+		converted := make(map[string]interface{}, len(column))
+		for key, value := range column {
+			if key == "JSONPath" {
+				key = "jsonPath"
+			}
+			converted[key] = value
+		}
+		result = append(result, converted)
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strings"
+)
+
+func testIngress() *unstructured.Unstructured {
+	return toUnstructured(&v1beta1.Ingress{
+		TypeMeta:   metaV1.TypeMeta{Kind: "Ingress", APIVersion: "extensions/v1beta1"},
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "nalej"},
+		Spec: v1beta1.IngressSpec{
+			Backend: &v1beta1.IngressBackend{ServiceName: "default", ServicePort: intstr.FromString("http")},
+			Rules: []v1beta1.IngressRule{{
+				Host: "web.nalej.com",
+				IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{
+					Paths: []v1beta1.HTTPIngressPath{{
+						Path:    "/",
+						Backend: v1beta1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)},
+					}},
+				}},
+			}},
+		},
+	})
+}
+
+const testCRD = `
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: alertmanagers.monitoring.coreos.com
+spec:
+  group: monitoring.coreos.com
+  names:
+    kind: Alertmanager
+    plural: alertmanagers
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+  additionalPrinterColumns:
+  - name: Replicas
+    type: integer
+    JSONPath: .spec.replicas
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+`
+
+var _ = ginkgo.Describe("API versions", func() {
+
+	ginkgo.It("should choose the first group that serves a kind", func() {
+		extensions := schema.GroupVersion{Group: "extensions", Version: "v1beta1"}
+		mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{IngressV1, extensions})
+		mapper.Add(extensions.WithKind("Ingress"), meta.RESTScopeNamespace)
+		mapping, err := preferredMapping(mapper, IngressKind, IngressGroups...)
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(mapping.Resource.GroupVersion().String()).To(gomega.Equal("extensions/v1beta1"))
+		mapper.Add(IngressV1.WithKind("Ingress"), meta.RESTScopeNamespace)
+		mapping, err = preferredMapping(mapper, IngressKind, IngressGroups...)
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(mapping.Resource).To(gomega.Equal(IngressV1.WithResource("ingresses")))
+		_, err = preferredMapping(mapper, CRDKind, CRDGroup)
+		gomega.Expect(err).ToNot(gomega.Succeed())
+	})
+
+	ginkgo.It("should convert the ingresses to networking.k8s.io/v1", func() {
+		obj := testIngress()
+		gomega.Expect(ConvertVersion(obj, IngressV1)).To(gomega.Succeed())
+		gomega.Expect(obj.GetAPIVersion()).To(gomega.Equal("networking.k8s.io/v1"))
+		defaultBackend, _, _ := unstructured.NestedMap(obj.Object, "spec", "defaultBackend")
+		gomega.Expect(defaultBackend).To(gomega.Equal(map[string]interface{}{
+			"service": map[string]interface{}{"name": "default", "port": map[string]interface{}{"name": "http"}}}))
+		_, found, _ := unstructured.NestedMap(obj.Object, "spec", "backend")
+		gomega.Expect(found).To(gomega.BeFalse())
+		rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+		paths, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "http", "paths")
+		path := paths[0].(map[string]interface{})
+		gomega.Expect(path["pathType"]).To(gomega.Equal(DefaultIngressPathType))
+		gomega.Expect(path["backend"]).To(gomega.Equal(map[string]interface{}{
+			"service": map[string]interface{}{"name": "web", "port": map[string]interface{}{"number": int64(80)}}}))
+	})
+
+	ginkgo.It("should only change the group of the ingresses served as v1beta1", func() {
+		obj := testIngress()
+		target := schema.GroupVersion{Group: "networking.k8s.io", Version: "v1beta1"}
+		gomega.Expect(ConvertVersion(obj, target)).To(gomega.Succeed())
+		gomega.Expect(obj.GetAPIVersion()).To(gomega.Equal("networking.k8s.io/v1beta1"))
+		serviceName, _, _ := unstructured.NestedString(obj.Object, "spec", "backend", "serviceName")
+		gomega.Expect(serviceName).To(gomega.Equal("default"))
+	})
+
+	ginkgo.It("should convert the custom resource definitions to apiextensions.k8s.io/v1", func() {
+		objects, err := DecodeObjects(strings.NewReader(testCRD), "crd")
+		gomega.Expect(err).To(gomega.Succeed())
+		obj := objects[0]
+		gomega.Expect(ConvertVersion(obj, CRDV1)).To(gomega.Succeed())
+		gomega.Expect(obj.GetAPIVersion()).To(gomega.Equal("apiextensions.k8s.io/v1"))
+		spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+		for _, field := range []string{"version", "validation", "subresources", "additionalPrinterColumns"} {
+			gomega.Expect(spec).ToNot(gomega.HaveKey(field))
+		}
+		versions := spec["versions"].([]interface{})
+		gomega.Expect(len(versions)).To(gomega.Equal(1))
+		version := versions[0].(map[string]interface{})
+		gomega.Expect(version["name"]).To(gomega.Equal("v1"))
+		gomega.Expect(version["storage"]).To(gomega.Equal(true))
+		gomega.Expect(version["subresources"]).To(gomega.Equal(map[string]interface{}{"status": map[string]interface{}{}}))
+		preserve, _, _ := unstructured.NestedBool(version, "schema", "openAPIV3Schema", "x-kubernetes-preserve-unknown-fields")
+		gomega.Expect(preserve).To(gomega.BeTrue())
+		columns := version["additionalPrinterColumns"].([]interface{})
+		gomega.Expect(columns[0]).To(gomega.HaveKeyWithValue("jsonPath", ".spec.replicas"))
+	})
+
+	ginkgo.It("should not modify the objects already in the target version", func() {
+		obj := testIngress()
+		expected := obj.DeepCopy()
+		gomega.Expect(ConvertVersion(obj, schema.GroupVersion{Group: "extensions", Version: "v1beta1"})).To(gomega.Succeed())
+		gomega.Expect(obj).To(gomega.Equal(expected))
+	})
+
+})
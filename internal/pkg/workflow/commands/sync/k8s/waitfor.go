@@ -0,0 +1,358 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the waitFor command that waits until a set of Kubernetes resources satisfy a condition
+//
+// The resources are listed once and then watched from the listed version. The watch is restarted with a new listing
+// after each interval, so the condition is also checked if some events are lost.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultWaitInterval is the default time between the listings of the resources.
+const DefaultWaitInterval = time.Second * 30
+
+// DefaultWaitTimeout is the default maximum time to wait for the condition.
+const DefaultWaitTimeout = time.Minute * 5
+
+// WaitFor command that waits until a resource, or all the resources matching a label selector, satisfy a condition.
+type WaitFor struct {
+	Kubernetes
+	// Group of the resource, empty for the core resources.
+	Group string `json:"group"`
+	// Version of the resource.
+	Version string `json:"version"`
+	// Resource with the plural name of the resource, such as certificates or services.
+	Resource string `json:"resource"`
+	// Namespace of the resources, empty for cluster resources.
+	Namespace string `json:"namespace"`
+	// ResourceName with the name of the resource. Either the name or the label selector must be set.
+	ResourceName string `json:"resourceName"`
+	// LabelSelector to wait for all the resources matching it.
+	LabelSelector string `json:"labelSelector"`
+	// Path with a gjson path that must exist in the resource, such as status.loadBalancer.ingress.0.ip.
+	Path string `json:"path"`
+	// Value expected in the path. If empty, the path must have any non empty value.
+	Value string `json:"value"`
+	// Condition with the type of a status condition that must be True, such as Ready.
+	Condition string `json:"condition"`
+	// Interval between the listings of the resources, such as 30s.
+	Interval string `json:"interval"`
+	// WaitTimeout with the maximum time to wait, such as 10m.
+	WaitTimeout string `json:"waitTimeout"`
+}
+
+// NewWaitFor creates a new WaitFor command for a named resource.
+func NewWaitFor(kubeConfigPath string, resource schema.GroupVersionResource, namespace string, name string) *WaitFor {
+	return &WaitFor{
+		Kubernetes: Kubernetes{
+			GenericSyncCommand: *entities.NewSyncCommand(entities.WaitFor),
+			KubeConfigPath:     kubeConfigPath,
+		},
+		Group:        resource.Group,
+		Version:      resource.Version,
+		Resource:     resource.Resource,
+		Namespace:    namespace,
+		ResourceName: name,
+	}
+}
+
+// NewWaitForFromJSON creates a WaitFor command from its JSON definition.
+func NewWaitForFromJSON(raw []byte) (*entities.Command, derrors.Error) {
+	wf := &WaitFor{}
+	if err := json.Unmarshal(raw, &wf); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	wf.CommandID = entities.GenerateCommandID(wf.Name())
+	var r entities.Command = wf
+	return &r, nil
+}
+
+// Validate checks that the command defines the resources, the condition and valid durations.
+func (wf *WaitFor) Validate() derrors.Error {
+	if wf.Version == "" || wf.Resource == "" {
+		return derrors.NewInvalidArgumentError(errors.InvalidWaitFor).WithParams("resource")
+	}
+	if (wf.ResourceName == "") == (wf.LabelSelector == "") {
+		return derrors.NewInvalidArgumentError(errors.InvalidWaitFor).WithParams("resourceName or labelSelector")
+	}
+	if wf.Path == "" && wf.Condition == "" {
+		return derrors.NewInvalidArgumentError(errors.InvalidWaitFor).WithParams("path or condition")
+	}
+	if _, err := wf.interval(); err != nil {
+		return err
+	}
+	if _, err := wf.timeout(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (wf *WaitFor) interval() (time.Duration, derrors.Error) {
+	return parseWaitDuration(wf.Interval, DefaultWaitInterval)
+}
+
+func (wf *WaitFor) timeout() (time.Duration, derrors.Error) {
+	return parseWaitDuration(wf.WaitTimeout, DefaultWaitTimeout)
+}
+
+// parseWaitDuration parses a positive duration returning the default value if empty.
+func parseWaitDuration(value string, defaultValue time.Duration) (time.Duration, derrors.Error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return 0, derrors.NewInvalidArgumentError(errors.InvalidTimeout).WithParams(entities.WaitFor, value)
+	}
+	return parsed, nil
+}
+
+// Matches checks if a resource satisfies the condition of the command.
+func (wf *WaitFor) Matches(obj *unstructured.Unstructured) bool {
+	if wf.Condition != "" && !HasCondition(obj, wf.Condition, "True") {
+		return false
+	}
+	if wf.Path != "" {
+		raw, err := obj.MarshalJSON()
+		if err != nil {
+			return false
+		}
+		result := gjson.GetBytes(raw, wf.Path)
+		if wf.Value == "" {
+			return result.Exists() && result.String() != ""
+		}
+		return result.String() == wf.Value
+	}
+	return true
+}
+
+// satisfied checks if the condition holds for the current resources. At least a resource must exist.
+func (wf *WaitFor) satisfied(current map[string]*unstructured.Unstructured) bool {
+	if len(current) == 0 {
+		return false
+	}
+	for _, obj := range current {
+		if !wf.Matches(obj) {
+			return false
+		}
+	}
+	return true
+}
+
+func (wf *WaitFor) client() dynamic.ResourceInterface {
+	resource := wf.dynClient.Resource(schema.GroupVersionResource{Group: wf.Group, Version: wf.Version, Resource: wf.Resource})
+	if wf.Namespace == "" {
+		return resource
+	}
+	return resource.Namespace(wf.Namespace)
+}
+
+func (wf *WaitFor) listOptions() metaV1.ListOptions {
+	if wf.ResourceName != "" {
+		return metaV1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", wf.ResourceName).String()}
+	}
+	return metaV1.ListOptions{LabelSelector: wf.LabelSelector}
+}
+
+func (wf *WaitFor) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	connectErr := wf.ConnectWithContext(ctx)
+	if connectErr != nil {
+		return nil, connectErr
+	}
+	if wf.DryRun() {
+		// The resources are not created in dry-run mode, so there is nothing to wait for.
+		return entities.NewCommandResult(true, "wait skipped in dry-run mode", nil), nil
+	}
+	interval, _ := wf.interval()
+	timeout, _ := wf.timeout()
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	current := make(map[string]*unstructured.Unstructured, 0)
+	for {
+		done, err := wf.watchInterval(waitCtx, interval, current)
+		if err != nil {
+			return entities.NewCommandResult(false, "cannot wait for resource", err), nil
+		}
+		if done {
+			return entities.NewCommandResult(true, fmt.Sprintf("%s satisfied the condition", wf.target()), nil), nil
+		}
+		if waitCtx.Err() != nil {
+			if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+				return nil, ctxErr
+			}
+			msg := fmt.Sprintf("%s did not satisfy the condition in %s\n%s", wf.target(), timeout.String(), wf.describe(current))
+			return entities.NewCommandResult(false, msg,
+				derrors.NewDeadlineExceededError(errors.WaitForTimeout).WithParams(wf.target(), timeout.String())), nil
+		}
+	}
+}
+
+// watchInterval lists the resources and watches their changes until the condition is satisfied, the interval
+// ends, or the context is done.
+//
+//	params:
+//	  ctx The context of the wait.
+//	  interval The maximum duration of the watch.
+//	  current The map that receives the last state of the resources by name.
+//	returns:
+//	  Whether the condition is satisfied.
+//	  An error if the resources cannot be listed.
+func (wf *WaitFor) watchInterval(ctx context.Context, interval time.Duration, current map[string]*unstructured.Unstructured) (bool, derrors.Error) {
+	client := wf.client()
+	list, err := client.List(wf.listOptions())
+	if err != nil {
+		return false, derrors.NewUnavailableError("unable to list resources", err).WithParams(wf.target())
+	}
+	for name := range current {
+		delete(current, name)
+	}
+	for index := range list.Items {
+		current[list.Items[index].GetName()] = &list.Items[index]
+	}
+	if wf.satisfied(current) {
+		return true, nil
+	}
+
+	opts := wf.listOptions()
+	opts.ResourceVersion = list.GetResourceVersion()
+	seconds := int64(interval / time.Second)
+	opts.TimeoutSeconds = &seconds
+	watcher, err := client.Watch(opts)
+	if err != nil {
+		log.Warn().Err(err).Str("target", wf.target()).Msg("unable to watch resources, listing again after the interval")
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+		return false, nil
+	}
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			obj, isObject := event.Object.(*unstructured.Unstructured)
+			if !isObject {
+				// Errors such as an expired resource version are solved by listing again.
+				return false, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				current[obj.GetName()] = obj
+			case watch.Deleted:
+				delete(current, obj.GetName())
+			}
+			if wf.satisfied(current) {
+				return true, nil
+			}
+		}
+	}
+}
+
+// target returns a description of the resources being waited for.
+func (wf *WaitFor) target() string {
+	resource := schema.GroupVersionResource{Group: wf.Group, Version: wf.Version, Resource: wf.Resource}.String()
+	selector := wf.ResourceName
+	if selector == "" {
+		selector = fmt.Sprintf("-l %s", wf.LabelSelector)
+	}
+	if wf.Namespace == "" {
+		return fmt.Sprintf("%s %s", resource, selector)
+	}
+	return fmt.Sprintf("%s %s/%s", resource, wf.Namespace, selector)
+}
+
+// describe returns the state of the resources that do not satisfy the condition.
+func (wf *WaitFor) describe(current map[string]*unstructured.Unstructured) string {
+	if len(current) == 0 {
+		return "no resources found"
+	}
+	lines := make([]string, 0)
+	for name, obj := range current {
+		if wf.Matches(obj) {
+			continue
+		}
+		line := fmt.Sprintf("%s: condition not satisfied", name)
+		if wf.Path != "" {
+			raw, err := obj.MarshalJSON()
+			if err == nil {
+				line = fmt.Sprintf("%s: %s=%q", name, wf.Path, gjson.GetBytes(raw, wf.Path).String())
+			}
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func (wf *WaitFor) expected() string {
+	conditions := make([]string, 0)
+	if wf.Condition != "" {
+		conditions = append(conditions, fmt.Sprintf("condition %s", wf.Condition))
+	}
+	if wf.Path != "" {
+		if wf.Value == "" {
+			conditions = append(conditions, wf.Path)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s=%s", wf.Path, wf.Value))
+		}
+	}
+	return strings.Join(conditions, " and ")
+}
+
+func (wf *WaitFor) String() string {
+	return fmt.Sprintf("SYNC WaitFor %s with %s", wf.target(), wf.expected())
+}
+
+func (wf *WaitFor) PrettyPrint(indentation int) string {
+	return strings.Repeat(" ", indentation) + wf.String()
+}
+
+func (wf *WaitFor) UserString() string {
+	return fmt.Sprintf("Waiting for %s to have %s", wf.target(), wf.expected())
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package k8s
+
+import (
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = ginkgo.Describe("WaitFor", func() {
+
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	ginkgo.It("should match a path with any value", func() {
+		wf := NewWaitFor("", services, "nalej", "lb")
+		wf.Path = "status.loadBalancer.ingress.0.ip"
+		gomega.Expect(wf.Validate()).To(gomega.BeNil())
+		service := testObject("Service", "lb")
+		gomega.Expect(wf.Matches(service)).To(gomega.BeFalse())
+		err := unstructured.SetNestedSlice(service.Object,
+			[]interface{}{map[string]interface{}{"ip": "10.0.0.1"}}, "status", "loadBalancer", "ingress")
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(wf.Matches(service)).To(gomega.BeTrue())
+		wf.Value = "10.0.0.2"
+		gomega.Expect(wf.Matches(service)).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("should match a status condition", func() {
+		wf := NewWaitFor("", schema.GroupVersionResource{Group: "certmanager.k8s.io", Version: "v1alpha1",
+			Resource: "certificates"}, "istio-system", "ingress-cert")
+		wf.Condition = "Ready"
+		cert := testObject("Certificate", "ingress-cert")
+		gomega.Expect(wf.Matches(cert)).To(gomega.BeFalse())
+		err := unstructured.SetNestedSlice(cert.Object,
+			[]interface{}{map[string]interface{}{"type": "Ready", "status": "True"}}, "status", "conditions")
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(wf.Matches(cert)).To(gomega.BeTrue())
+	})
+
+	ginkgo.It("should require every selected resource to match", func() {
+		wf := NewWaitFor("", services, "nalej", "")
+		wf.LabelSelector = "app=lb"
+		wf.Path = "metadata.name"
+		wf.Value = "a"
+		gomega.Expect(wf.satisfied(map[string]*unstructured.Unstructured{})).To(gomega.BeFalse())
+		current := map[string]*unstructured.Unstructured{"a": testObject("Service", "a")}
+		gomega.Expect(wf.satisfied(current)).To(gomega.BeTrue())
+		current["b"] = testObject("Service", "b")
+		gomega.Expect(wf.satisfied(current)).To(gomega.BeFalse())
+		gomega.Expect(wf.describe(current)).To(gomega.Equal(`b: metadata.name="b"`))
+	})
+
+	ginkgo.It("should reject invalid durations", func() {
+		wf := NewWaitFor("", services, "nalej", "lb")
+		wf.Condition = "Ready"
+		wf.Interval = "often"
+		gomega.Expect(wf.Validate()).ToNot(gomega.BeNil())
+	})
+
+})
@@ -23,6 +23,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"strings"
@@ -57,7 +58,7 @@ func NewLoggerFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (l *Logger) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (l *Logger) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 	return entities.NewSuccessCommand([]byte(l.Msg)), nil
 }
 
@@ -75,3 +76,8 @@ func (l *Logger) PrettyPrint(identation int) string {
 func (l *Logger) UserString() string {
 	return "adding log entry"
 }
+
+// SupportsDryRun returns true as the command has no side effects.
+func (l *Logger) SupportsDryRun() bool {
+	return true
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Plugin command
+// Executes an external executable of the plugins directory of the binary path.
+//
+// {"type":"sync", "name": "plugin", "binaryPath": "/bin", "plugin": "external-dns",
+// "kubeConfigPath": "/kube/config", "params": {"zone": "nalej.tech"}}
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/nalej/installer/internal/pkg/workflow/handler"
+	"github.com/rs/zerolog/log"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// maxLineSize is the maximum length of a line written by a plugin.
+const maxLineSize = 1024 * 1024
+
+// Plugin command structure with the plugin to be executed and its parameters.
+type Plugin struct {
+	entities.GenericSyncCommand
+	// BinaryPath with the directory of the binaries that contains the plugins directory.
+	BinaryPath string `json:"binaryPath"`
+	// Plugin with the name of the executable in the plugins directory.
+	Plugin string `json:"plugin"`
+	// Description of the step performed by the plugin.
+	Description string `json:"description"`
+	// Params with the parameters sent to the plugin.
+	Params json.RawMessage `json:"params"`
+	// KubeConfigPath with the path of the kubeconfig file sent to the plugin.
+	KubeConfigPath string `json:"kubeConfigPath"`
+	// PrivateKeyPath with the path of the private key sent to the plugin.
+	PrivateKeyPath string `json:"privateKeyPath"`
+}
+
+// NewPlugin creates a new Plugin command.
+func NewPlugin(binaryPath string, plugin string, params json.RawMessage, kubeConfigPath string) *Plugin {
+	return &Plugin{
+		GenericSyncCommand: *entities.NewSyncCommand(entities.Plugin),
+		BinaryPath:         binaryPath,
+		Plugin:             plugin,
+		Params:             params,
+		KubeConfigPath:     kubeConfigPath,
+	}
+}
+
+// NewPluginFromJSON creates a new Plugin command from a raw JSON representation.
+func NewPluginFromJSON(raw []byte) (*entities.Command, derrors.Error) {
+	p := &Plugin{}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if p.Plugin == "" || strings.ContainsAny(p.Plugin, "/\\") || strings.HasPrefix(p.Plugin, ".") {
+		return nil, derrors.NewInvalidArgumentError(errors.InvalidPlugin).WithParams(p.Plugin)
+	}
+	p.CommandID = entities.GenerateCommandID(p.Name())
+	var r entities.Command = p
+	return &r, nil
+}
+
+// path returns the path of the executable of the plugin, checking that it exists.
+func (p *Plugin) path() (string, derrors.Error) {
+	pluginPath := PluginPath(p.BinaryPath, p.Plugin)
+	info, err := os.Stat(pluginPath)
+	if err != nil || !isExecutable(info) {
+		available, _ := DiscoverPlugins(p.BinaryPath)
+		return "", derrors.NewNotFoundError(errors.PluginNotFound).WithParams(pluginPath, available)
+	}
+	return pluginPath, nil
+}
+
+// Run executes the plugin sending the request to its standard input. The log messages of the plugin are added to
+// the log of the command, and the result message is returned as the result of the command.
+func (p *Plugin) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
+	pluginPath, dErr := p.path()
+	if dErr != nil {
+		return nil, dErr
+	}
+	request, err := json.Marshal(Request{
+		ProtocolVersion: ProtocolVersion,
+		WorkflowID:      workflowID,
+		CommandID:       p.CommandID,
+		Params:          p.Params,
+		Credentials: Credentials{
+			KubeConfigPath: p.KubeConfigPath,
+			PrivateKeyPath: p.PrivateKeyPath,
+		},
+	})
+	if err != nil {
+		return nil, derrors.NewInternalError(errors.MarshalError, err)
+	}
+
+	// The plugin is killed if the context is done.
+	cmd := exec.CommandContext(ctx, pluginPath)
+	cmd.Stdin = bytes.NewReader(request)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, derrors.AsError(err, errors.IOError)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, derrors.AsError(err, errors.IOError)
+	}
+
+	commandHandler := handler.GetCommandHandler()
+	log.Debug().Str("path", pluginPath).Msg("Starting plugin")
+	if err := cmd.Start(); err != nil {
+		return nil, derrors.NewInternalError(errors.OpFail, err).WithParams(pluginPath)
+	}
+
+	var result *Message
+	var stdoutErr, stderrErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		result, stdoutErr = p.readMessages(commandHandler, stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		_, stderrErr = p.readMessages(commandHandler, stderr)
+	}()
+	// Wait for the stdout and stderr pipes to close before waiting for the plugin.
+	wg.Wait()
+	waitErr := cmd.Wait()
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if readErr := firstError(stdoutErr, stderrErr); readErr != nil {
+		return entities.NewCommandResult(false, "",
+			derrors.NewInternalError(errors.PluginOutputUnreadable, readErr).WithParams(p.Plugin, maxLineSize)), nil
+	}
+	return p.toResult(result, waitErr), nil
+}
+
+// firstError returns the first error that is not nil.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMessages adds the lines written by the plugin to the log, and returns the last result message. If the output
+// cannot be read, as a line exceeds maxLineSize, the rest of the output is discarded so the plugin is not blocked
+// writing to the pipe.
+//
+//	returns:
+//	  The last result message, if any.
+//	  An error if the output of the plugin cannot be read.
+func (p *Plugin) readMessages(commandHandler handler.CommandHandler, r io.Reader) (*Message, error) {
+	var result *Message
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		message := ParseMessage(line)
+		switch {
+		case message == nil:
+			if strings.TrimSpace(line) != "" {
+				commandHandler.AddLogEntry(p.CommandID, strings.TrimSpace(line))
+			}
+		case message.Type == LogMessage:
+			commandHandler.AddLogEntry(p.CommandID, message.Message)
+		case message.Type == ResultMessage:
+			result = message
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warn().Err(err).Str("plugin", p.Plugin).Msg("cannot read the output of the plugin")
+		io.Copy(ioutil.Discard, r)
+		return nil, err
+	}
+	return result, nil
+}
+
+// toResult builds the result of the command from the result message and the exit status of the plugin.
+func (p *Plugin) toResult(result *Message, waitErr error) *entities.CommandResult {
+	if waitErr != nil {
+		output := ""
+		if result != nil {
+			output = result.Output
+		}
+		return entities.NewCommandResult(false, output, derrors.NewInternalError(errors.OpFail, waitErr).WithParams(p.Plugin))
+	}
+	if result == nil {
+		return entities.NewCommandResult(false, "", derrors.NewInternalError(errors.PluginWithoutResult).WithParams(p.Plugin))
+	}
+	if !result.Success {
+		return entities.NewCommandResult(false, result.Output, derrors.NewInternalError(errors.OpFail).WithParams(p.Plugin, result.Error))
+	}
+	return entities.NewCommandResult(true, result.Output, nil)
+}
+
+func (p *Plugin) String() string {
+	return fmt.Sprintf("SYNC Plugin %s", p.Plugin)
+}
+
+func (p *Plugin) PrettyPrint(indentation int) string {
+	return strings.Repeat(" ", indentation) + p.String()
+}
+
+func (p *Plugin) UserString() string {
+	if p.Description != "" {
+		return p.Description
+	}
+	return fmt.Sprintf("Running plugin %s", p.Plugin)
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+	"github.com/nalej/installer/internal/pkg/workflow/handler"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+)
+
+// writePlugin creates an executable shell script in the plugins directory.
+func writePlugin(binaryPath string, name string, script string) {
+	err := os.MkdirAll(path.Join(binaryPath, PluginsDir), 0755)
+	gomega.Expect(err).To(gomega.Succeed())
+	err = ioutil.WriteFile(PluginPath(binaryPath, name), []byte("#!/bin/sh\n"+script), 0755)
+	gomega.Expect(err).To(gomega.Succeed())
+}
+
+// parsePlugin creates a plugin command from its JSON definition.
+func parsePlugin(binaryPath string, name string, params string) *Plugin {
+	raw, err := json.Marshal(map[string]interface{}{
+		"type": "sync", "name": "plugin", "binaryPath": binaryPath, "plugin": name,
+		"kubeConfigPath": "/kube/config", "params": json.RawMessage(params),
+	})
+	gomega.Expect(err).To(gomega.Succeed())
+	cmd, dErr := NewPluginFromJSON(raw)
+	gomega.Expect(dErr).To(gomega.BeNil())
+	return (*cmd).(*Plugin)
+}
+
+var _ = ginkgo.Describe("Plugin", func() {
+
+	var binaryPath string
+
+	ginkgo.BeforeEach(func() {
+		dir, err := ioutil.TempDir("", "plugins")
+		gomega.Expect(err).To(gomega.Succeed())
+		binaryPath = dir
+	})
+
+	ginkgo.AfterEach(func() {
+		os.RemoveAll(binaryPath)
+	})
+
+	ginkgo.It("must discover the executables of the plugins directory", func() {
+		plugins, err := DiscoverPlugins(binaryPath)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(plugins).To(gomega.BeEmpty())
+		writePlugin(binaryPath, "dns", "exit 0")
+		writePlugin(binaryPath, "backup", "exit 0")
+		writeErr := ioutil.WriteFile(path.Join(binaryPath, PluginsDir, "README"), []byte("plugins"), 0644)
+		gomega.Expect(writeErr).To(gomega.Succeed())
+		plugins, err = DiscoverPlugins(binaryPath)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(plugins).To(gomega.Equal([]string{"backup", "dns"}))
+	})
+
+	ginkgo.It("must reject invalid plugin names", func() {
+		for _, name := range []string{"", "../rke", "dir/plugin", ".hidden"} {
+			raw, _ := json.Marshal(map[string]string{"type": "sync", "name": "plugin", "plugin": name})
+			_, err := NewPluginFromJSON(raw)
+			gomega.Expect(err).ToNot(gomega.BeNil(), name)
+		}
+	})
+
+	ginkgo.It("must parse the messages of a plugin", func() {
+		gomega.Expect(ParseMessage("plain line")).To(gomega.BeNil())
+		gomega.Expect(ParseMessage(`{"other":"json"}`)).To(gomega.BeNil())
+		gomega.Expect(ParseMessage(` {"type":"log", "message":"hello"}`)).To(gomega.Equal(&Message{Type: LogMessage, Message: "hello"}))
+	})
+
+	ginkgo.It("must send the request and return the result of the plugin", func() {
+		writePlugin(binaryPath, "echo", `request=$(cat)
+echo '{"type":"log", "message":"starting"}'
+echo "plain output"
+echo "warning" >&2
+echo "{\"type\":\"result\", \"success\":true, \"output\":$(echo "$request" | sed 's/"/\\"/g; s/^/"/; s/$/"/')}"
+`)
+		cmd := parsePlugin(binaryPath, "echo", `{"zone":"nalej.tech"}`)
+
+		var lock sync.Mutex
+		entries := make([]string, 0)
+		h := handler.GetCommandHandler()
+		h.AddCommand(cmd.ID(), func(string, *entities.CommandResult, derrors.Error) {}, func(id string, entry string) {
+			lock.Lock()
+			defer lock.Unlock()
+			entries = append(entries, entry)
+		})
+		defer h.FinishCommand(cmd.ID(), nil, nil)
+
+		result, err := cmd.Run(context.Background(), "workflow")
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(result.Success).To(gomega.BeTrue())
+		request := &Request{}
+		gomega.Expect(json.Unmarshal([]byte(result.Output), request)).To(gomega.Succeed())
+		gomega.Expect(request.ProtocolVersion).To(gomega.Equal(ProtocolVersion))
+		gomega.Expect(request.WorkflowID).To(gomega.Equal("workflow"))
+		gomega.Expect(request.CommandID).To(gomega.Equal(cmd.ID()))
+		gomega.Expect(string(request.Params)).To(gomega.MatchJSON(`{"zone":"nalej.tech"}`))
+		gomega.Expect(request.Credentials.KubeConfigPath).To(gomega.Equal("/kube/config"))
+		gomega.Eventually(func() []string {
+			lock.Lock()
+			defer lock.Unlock()
+			return append([]string{}, entries...)
+		}).Should(gomega.ConsistOf("starting", "plain output", "warning"))
+	})
+
+	ginkgo.It("must fail if the plugin fails", func() {
+		writePlugin(binaryPath, "failed", `echo '{"type":"result", "success":false, "error":"zone not found"}'`)
+		result, err := parsePlugin(binaryPath, "failed", "{}").Run(context.Background(), "workflow")
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(result.Success).To(gomega.BeFalse())
+		gomega.Expect(result.Error).ToNot(gomega.BeNil())
+
+		writePlugin(binaryPath, "exit", `echo '{"type":"result", "success":true}'; exit 3`)
+		result, err = parsePlugin(binaryPath, "exit", "{}").Run(context.Background(), "workflow")
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(result.Success).To(gomega.BeFalse())
+
+		writePlugin(binaryPath, "silent", `exit 0`)
+		result, err = parsePlugin(binaryPath, "silent", "{}").Run(context.Background(), "workflow")
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(result.Success).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("must fail if the plugin writes a line that is too long", func() {
+		writePlugin(binaryPath, "long", `head -c 2000000 /dev/zero | tr '\0' 'a'
+echo
+echo '{"type":"result", "success":true}'
+`)
+		done := make(chan *entities.CommandResult, 1)
+		go func() {
+			defer ginkgo.GinkgoRecover()
+			result, err := parsePlugin(binaryPath, "long", "{}").Run(context.Background(), "workflow")
+			gomega.Expect(err).To(gomega.BeNil())
+			done <- result
+		}()
+		var result *entities.CommandResult
+		gomega.Eventually(done, 10).Should(gomega.Receive(&result))
+		gomega.Expect(result.Success).To(gomega.BeFalse())
+		gomega.Expect(result.Error.Error()).To(gomega.Equal(errors.PluginOutputUnreadable))
+	})
+
+	ginkgo.It("must fail if the plugin does not exist", func() {
+		_, err := parsePlugin(binaryPath, "missing", "{}").Run(context.Background(), "workflow")
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+})
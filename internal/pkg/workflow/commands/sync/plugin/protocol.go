@@ -0,0 +1,145 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the protocol between the installer and the plugins
+//
+// The installer writes a single Request in JSON to the standard input of the plugin and closes it. The plugin
+// writes one Message in JSON per line to its standard output: any number of log messages, and a final result
+// message. The lines that are not messages and the standard error are added to the log as they are.
+//
+// {"protocolVersion":"1", "workflowId":"...", "commandId":"...", "params":{...},
+//  "credentials":{"kubeConfigPath":"...", "privateKeyPath":"..."}}
+//
+// {"type":"log", "message":"Creating the DNS zone"}
+// {"type":"result", "success":true, "output":"zone created"}
+
+package plugin
+
+import (
+	"encoding/json"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ProtocolVersion is the version of the protocol sent to the plugins.
+const ProtocolVersion = "1"
+
+// PluginsDir is the directory of the binary path that contains the plugins.
+const PluginsDir = "plugins"
+
+// LogMessage is the type of the messages with a log line.
+const LogMessage = "log"
+
+// ResultMessage is the type of the message with the result of the plugin.
+const ResultMessage = "result"
+
+// Credentials structure with the paths of the credentials available to the plugin.
+type Credentials struct {
+	// KubeConfigPath with the path of the kubeconfig file of the target cluster.
+	KubeConfigPath string `json:"kubeConfigPath,omitempty"`
+	// PrivateKeyPath with the path of the private key to connect to the nodes.
+	PrivateKeyPath string `json:"privateKeyPath,omitempty"`
+}
+
+// Request structure sent to the standard input of the plugin.
+type Request struct {
+	// ProtocolVersion with the version of the protocol.
+	ProtocolVersion string `json:"protocolVersion"`
+	// WorkflowID with the identifier of the workflow being executed.
+	WorkflowID string `json:"workflowId"`
+	// CommandID with the identifier of the command.
+	CommandID string `json:"commandId"`
+	// Params with the parameters of the plugin as defined in the workflow.
+	Params json.RawMessage `json:"params,omitempty"`
+	// Credentials with the paths of the credentials.
+	Credentials Credentials `json:"credentials"`
+}
+
+// Message structure written by the plugin to its standard output, one per line.
+type Message struct {
+	// Type of the message, log or result.
+	Type string `json:"type"`
+	// Message with the line to be logged.
+	Message string `json:"message,omitempty"`
+	// Success determines if the plugin succeeded in a result message.
+	Success bool `json:"success,omitempty"`
+	// Output of the plugin in a result message.
+	Output string `json:"output,omitempty"`
+	// Error with the reason of the failure in a result message.
+	Error string `json:"error,omitempty"`
+}
+
+// ParseMessage parses a line of the output of a plugin.
+//
+//	params:
+//	  line The line written by the plugin.
+//	returns:
+//	  The message, or nil if the line is not a message.
+func ParseMessage(line string) *Message {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil
+	}
+	message := &Message{}
+	if err := json.Unmarshal([]byte(trimmed), message); err != nil {
+		return nil
+	}
+	if message.Type != LogMessage && message.Type != ResultMessage {
+		return nil
+	}
+	return message
+}
+
+// PluginPath returns the path of the executable of a plugin.
+func PluginPath(binaryPath string, name string) string {
+	return path.Join(binaryPath, PluginsDir, name)
+}
+
+// DiscoverPlugins retrieves the names of the executables of the plugins directory.
+//
+//	params:
+//	  binaryPath The directory of the binaries.
+//	returns:
+//	  The sorted names of the plugins, empty if the directory does not exist.
+//	  An error if the directory cannot be read.
+func DiscoverPlugins(binaryPath string) ([]string, derrors.Error) {
+	result := make([]string, 0)
+	files, err := ioutil.ReadDir(path.Join(binaryPath, PluginsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, derrors.AsError(err, errors.IOError)
+	}
+	for _, file := range files {
+		if isExecutable(file) {
+			result = append(result, file.Name())
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// isExecutable checks if a file is a regular file that can be executed.
+func isExecutable(file os.FileInfo) bool {
+	return file.Mode().IsRegular() && file.Mode().Perm()&0111 != 0
+}
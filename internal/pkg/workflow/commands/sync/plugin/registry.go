@@ -0,0 +1,41 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the plugin command.
+
+package plugin
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+func init() {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.Plugin,
+		Description: "Executes an external executable of the plugins directory",
+		FromJSON:    NewPluginFromJSON,
+		Parameters: entities.ParametersOf(&Plugin{}, map[string]string{
+			"binaryPath":     "Directory of the binaries that contains the plugins directory",
+			"plugin":         "Name of the executable in the plugins directory",
+			"description":    "Description of the step shown to the user",
+			"params":         "Parameters sent to the plugin",
+			"kubeConfigPath": "Path of the kubeconfig file sent to the plugin",
+			"privateKeyPath": "Path of the private key sent to the plugin",
+		}),
+	})
+}
@@ -26,6 +26,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -84,7 +85,7 @@ func (pc *ProcessCheck) getTargetPort() string {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (pc *ProcessCheck) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (pc *ProcessCheck) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 
 	conn, err := connection.NewSSHConnection(
 		pc.TargetHost, pc.getTargetPort(),
@@ -95,7 +96,10 @@ func (pc *ProcessCheck) Run(_ string) (*entities.CommandResult, derrors.Error) {
 	}
 	cmd := fmt.Sprintf("pgrep %s || echo nf", pc.Process)
 	log.Debug().Str("cmd", cmd).Msg("ProcessCheck exec")
-	output, err := conn.Execute(cmd)
+	output, err := conn.Execute(ctx, cmd)
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		log.Warn().Str("targetHost", pc.TargetHost).Err(err).Msg("Cannot execute command")
 		return nil, derrors.NewInternalError(errors.SSHConnectionError, err)
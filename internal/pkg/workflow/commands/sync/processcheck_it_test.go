@@ -33,6 +33,7 @@ IT_SSH_PORT=2222
 package sync
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
@@ -60,7 +61,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 	ginkgo.It("should be able to check that a process exists when expecting it to exist", func() {
 		credentials := entities.NewCredentials(testUsername, testPassword)
 		cmd := NewProcessCheck(targetHost, targetPort, *credentials, "sshd", true)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 		output := (*result).Output
@@ -71,7 +72,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 		privateKey := getUserPrivateKey()
 		credentials := entities.NewPKICredentials(testUsername, string(privateKey))
 		cmd := NewProcessCheck(targetHost, targetPort, *credentials, "sshd", true)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 		output := (*result).Output
@@ -81,7 +82,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 	ginkgo.It("should fail when a command does not exists and the process expects it to", func() {
 		credentials := entities.NewCredentials(testUsername, testPassword)
 		cmd := NewProcessCheck(targetHost, targetPort, *credentials, "notFound", true)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeFalse())
 		output := (*result).Output
@@ -91,7 +92,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 	ginkgo.It("should work when a command does not exists and the process does not expect it to", func() {
 		credentials := entities.NewCredentials(testUsername, testPassword)
 		cmd := NewProcessCheck(targetHost, targetPort, *credentials, "notFound", false)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeTrue())
 		output := (*result).Output
@@ -101,7 +102,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 	ginkgo.It("should fail when a process exists and the process does not expects it to", func() {
 		credentials := entities.NewCredentials(testUsername, testPassword)
 		cmd := NewProcessCheck(targetHost, targetPort, *credentials, "sshd", false)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result.Success).To(gomega.BeFalse())
 		output := (*result).Output
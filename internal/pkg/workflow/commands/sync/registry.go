@@ -0,0 +1,120 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the generic synchronous commands.
+
+package sync
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+// remoteParameters contains the descriptions of the parameters of the commands executed on a remote host.
+var remoteParameters = map[string]string{
+	"targetHost":  "Address of the remote host",
+	"targetPort":  "SSH port of the remote host",
+	"credentials": "SSH credentials with the username and either the password or the privateKey",
+}
+
+// withRemoteParameters returns the descriptions of a remote command including the ones of the connection.
+func withRemoteParameters(descriptions map[string]string) map[string]string {
+	result := make(map[string]string, len(remoteParameters)+len(descriptions))
+	for name, description := range remoteParameters {
+		result[name] = description
+	}
+	for name, description := range descriptions {
+		result[name] = description
+	}
+	return result
+}
+
+func init() {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.Exec,
+		Description: "Executes a local command",
+		FromJSON:    NewExecFromJSON,
+		Parameters: entities.ParametersOf(Exec{}, map[string]string{
+			"cmd":  "Command to be executed",
+			"args": "Arguments of the command",
+		}),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.SCP,
+		Description: "Copies a local file to a remote host",
+		FromJSON:    NewSCPFromJSON,
+		Parameters: entities.ParametersOf(SCP{}, withRemoteParameters(map[string]string{
+			"source":      "Path of the local file",
+			"destination": "Path of the file in the remote host",
+		})),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.SSH,
+		Description: "Executes a command on a remote host",
+		FromJSON:    NewSSHFromJSON,
+		Parameters: entities.ParametersOf(SSH{}, withRemoteParameters(map[string]string{
+			"cmd":  "Command to be executed",
+			"args": "Arguments of the command",
+		})),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.Logger,
+		Description: "Adds an entry to the workflow log",
+		FromJSON:    NewLoggerFromJSON,
+		Parameters: entities.ParametersOf(Logger{}, map[string]string{
+			"msg": "Message to be logged",
+		}),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.Sleep,
+		Description: "Sleeps for a number of seconds",
+		FromJSON:    NewSleepFromJSON,
+		Parameters: entities.ParametersOf(Sleep{}, map[string]string{
+			"time": "Number of seconds",
+		}),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.Fail,
+		Description: "Fails the execution of the workflow",
+		FromJSON:    NewFailFromJSON,
+		Parameters:  entities.ParametersOf(Fail{}, nil),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.ProcessCheck,
+		Description: "Checks if a process is running on a remote host",
+		FromJSON:    NewProcessCheckFromJSON,
+		Parameters: entities.ParametersOf(ProcessCheck{}, withRemoteParameters(map[string]string{
+			"process":      "Name of the process",
+			"shouldExists": "Whether the process is expected to be running",
+		})),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.CheckAsset,
+		Description: "Checks that a local file exists",
+		FromJSON:    NewCheckAssetFromJSON,
+		Parameters: entities.ParametersOf(CheckAsset{}, map[string]string{
+			"path": "Path of the file",
+		}),
+	})
+}
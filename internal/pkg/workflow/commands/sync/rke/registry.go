@@ -0,0 +1,56 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the RKE commands.
+
+package rke
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+// clusterParameters contains the descriptions of the parameters of the cluster configuration.
+var clusterParameters = map[string]string{
+	"rkeBinaryPath":  "Path of the rke binary",
+	"clusterName":    "Name of the cluster",
+	"targetNodes":    "Addresses of the nodes of the cluster",
+	"nodeUsername":   "Username to connect to the nodes",
+	"privateKeyPath": "Path of the private key to connect to the nodes",
+}
+
+func init() {
+	installParameters := map[string]string{
+		"kubeConfigOutputPath": "Path where the kubeconfig of the cluster is written",
+	}
+	for name, description := range clusterParameters {
+		installParameters[name] = description
+	}
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.RKEInstall,
+		Description: "Installs a Kubernetes cluster with RKE",
+		FromJSON:    NewRKEInstallFromJSON,
+		Parameters:  entities.ParametersOf(&RKEInstall{}, installParameters),
+	})
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.RKERemove,
+		Description: "Removes a Kubernetes cluster installed with RKE",
+		FromJSON:    NewRKERemoveFromJSON,
+		Parameters:  entities.ParametersOf(&RKERemove{}, clusterParameters),
+	})
+}
@@ -19,6 +19,7 @@ package rke
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -136,7 +137,7 @@ func (cmd *RKEInstall) copyKubeConfig(clusterConfigFile string) (*entities.Comma
 }
 
 // Run triggers the execution of the command.
-func (cmd *RKEInstall) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (cmd *RKEInstall) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 	clusterConfigPath, err := cmd.CreateClusterConfig()
 	if err != nil {
 		log.Warn().Err(err).Msg("unable to create cluster config")
@@ -144,7 +145,8 @@ func (cmd *RKEInstall) Run(workflowID string) (*entities.CommandResult, derrors.
 	}
 
 	log.Debug().Str("path", cmd.RkeBinaryPath).Msg("RKE binary")
-	rke := exec.Command(cmd.RkeBinaryPath, "up", "--config", clusterConfigPath)
+	// The rke process is killed if the context is done.
+	rke := exec.CommandContext(ctx, cmd.RkeBinaryPath, "up", "--config", clusterConfigPath)
 	rkeOut, pipeErr := rke.StdoutPipe()
 	if pipeErr != nil {
 		return nil, derrors.AsError(pipeErr, errors.IOError)
@@ -176,6 +178,9 @@ func (cmd *RKEInstall) Run(workflowID string) (*entities.CommandResult, derrors.
 	wg.Wait()
 	// Wait for the command itself to close.
 	if err := rke.Wait(); err != nil {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return entities.NewCommandResult(false, "rke failed", derrors.AsError(err, errors.OpFail)), nil
 	}
 	return cmd.copyKubeConfig(clusterConfigPath)
@@ -39,6 +39,7 @@ IT_RKE_BINARY=/Users/daniel/Downloads/rke_darwin-amd64
 package rke
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/nalej/installer/internal/pkg/workflow/handler"
 	"github.com/onsi/ginkgo"
@@ -97,7 +98,7 @@ var _ = ginkgo.Describe("RKE Install", func() {
 		helper := NewHandlerHelper()
 		err := commandHandler.AddCommand(cmd.ID(), helper.resultCallback, helper.logCallback)
 		gomega.Expect(err).To(gomega.BeNil())
-		result, err := cmd.Run("workflowID")
+		result, err := cmd.Run(context.Background(), "workflowID")
 		gomega.Expect(err).To(gomega.BeNil())
 		gomega.Expect(result).ToNot(gomega.BeNil())
 		log.Debug().Msg(result.String())
@@ -128,7 +129,7 @@ func installVagrant(t *testing.T) {
 	helper := NewHandlerHelper()
 	commandHandler.AddCommand(cmd.ID(), helper.resultCallback, helper.logCallback)
 
-	result, err := cmd.Run("workflowID")
+	result, err := cmd.Run(context.Background(), "workflowID")
 	assert.Nil(t, err, "expecting no error")
 	assert.NotNil(t, result, "expecting result")
 	log.Debug().Msg(result.String())
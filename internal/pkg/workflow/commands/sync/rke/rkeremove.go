@@ -19,6 +19,7 @@ package rke
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -106,14 +107,15 @@ func (cmd *RKERemove) copyToLog(commandHandler handler.CommandHandler, r io.Read
 }
 
 // Run triggers the execution of the command.
-func (cmd *RKERemove) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (cmd *RKERemove) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 	clusterConfigPath, err := cmd.CreateClusterConfig()
 	if err != nil {
 		return nil, err
 	}
 
 	log.Debug().Str("path", cmd.RkeBinaryPath).Msg("RKE binary")
-	rke := exec.Command(cmd.RkeBinaryPath, "remove", "--config", clusterConfigPath, "--force")
+	// The rke process is killed if the context is done.
+	rke := exec.CommandContext(ctx, cmd.RkeBinaryPath, "remove", "--config", clusterConfigPath, "--force")
 	rkeOut, pipeErr := rke.StdoutPipe()
 	if pipeErr != nil {
 		return nil, derrors.AsError(pipeErr, errors.IOError)
@@ -145,6 +147,9 @@ func (cmd *RKERemove) Run(workflowID string) (*entities.CommandResult, derrors.E
 	wg.Wait()
 	// Wait for the command itself to close.
 	if err := rke.Wait(); err != nil {
+		if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return entities.NewCommandResult(false, "rke failed", derrors.AsError(err, errors.OpFail)), nil
 	}
 	return entities.NewCommandResult(true, "rke finished successfully", nil), nil
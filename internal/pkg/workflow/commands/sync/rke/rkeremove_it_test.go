@@ -46,7 +46,7 @@ func removeVagrant(t *testing.T) {
 	helper := NewHandlerHelper()
 	commandHandler.AddCommand(cmd.ID(), helper.resultCallback, helper.logCallback)
 
-	result, err := cmd.Run("workflowID")
+	result, err := cmd.Run(context.Background(), "workflowID")
 	assert.Nil(t, err, "expecting no error")
 	assert.NotNil(t, result, "expecting result")
 	log.Debug().Msg(result.String())
@@ -25,6 +25,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -86,7 +87,7 @@ func (scp *SCP) getTargetPort() string {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (scp *SCP) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (scp *SCP) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 
 	conn, err := connection.NewSSHConnection(
 		scp.TargetHost, scp.getTargetPort(),
@@ -95,7 +96,10 @@ func (scp *SCP) Run(_ string) (*entities.CommandResult, derrors.Error) {
 		return nil, derrors.NewInternalError(errors.SSHConnectionError, err).WithParams(scp.TargetHost)
 	}
 	start := time.Now()
-	err = conn.Copy(scp.Source, scp.Destination, false)
+	err = conn.Copy(ctx, scp.Source, scp.Destination, false)
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		return nil, derrors.NewInternalError(errors.SSHConnectionError, err).WithParams(scp.TargetHost)
 	}
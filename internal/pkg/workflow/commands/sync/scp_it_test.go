@@ -33,6 +33,7 @@ IT_SSH_PORT=2222
 package sync
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
@@ -88,7 +89,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 
 		credentials := entities.NewCredentials(testUsername, testPassword)
 		cmd := NewSCP(targetHost, targetPort, *credentials, tmpfile.Name(), targetPath)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		log.Debug().Bool("result", (*result).Success).Str("output", (*result).Output).Msg("scp has been executed")
 	})
@@ -108,7 +109,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 
 		credentials := entities.NewPKICredentials(testUsername, string(privateKey))
 		cmd := NewSCP(targetHost, targetPort, *credentials, tmpfile.Name(), targetPath)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		log.Debug().Bool("result", (*result).Success).Str("output", (*result).Output).Msg("scp has been executed")
 	})
@@ -23,6 +23,7 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"strconv"
@@ -59,10 +60,14 @@ func NewSleepFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (s *Sleep) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (s *Sleep) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 	t, _ := strconv.Atoi(s.Time)
 	d := time.Duration(t)
-	time.Sleep(time.Second * d)
+	select {
+	case <-ctx.Done():
+		return nil, entities.ContextError(ctx)
+	case <-time.After(time.Second * d):
+	}
 	return entities.NewSuccessCommand([]byte("slept for " + s.Time)), nil
 }
 
@@ -80,3 +85,8 @@ func (s *Sleep) PrettyPrint(identation int) string {
 func (s *Sleep) UserString() string {
 	return "Sleeping for " + s.Time
 }
+
+// SupportsDryRun returns true as the command has no side effects.
+func (s *Sleep) SupportsDryRun() bool {
+	return true
+}
@@ -28,6 +28,7 @@ package sync
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -86,7 +87,7 @@ func (ssh *SSH) getTargetPort() string {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (ssh *SSH) Run(_ string) (*entities.CommandResult, derrors.Error) {
+func (ssh *SSH) Run(ctx context.Context, _ string) (*entities.CommandResult, derrors.Error) {
 
 	conn, err := connection.NewSSHConnection(
 		ssh.TargetHost, ssh.getTargetPort(),
@@ -102,7 +103,10 @@ func (ssh *SSH) Run(_ string) (*entities.CommandResult, derrors.Error) {
 	}
 	toExecute := buffer.String()
 	log.Debug().Str("toExecute", toExecute).Msg("SSH exec")
-	output, err := conn.Execute(toExecute)
+	output, err := conn.Execute(ctx, toExecute)
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		log.Warn().Str("targetHost", ssh.TargetHost).Err(err).Msg("Cannot execute command")
 		return nil, derrors.NewInternalError(errors.SSHConnectionError, err)
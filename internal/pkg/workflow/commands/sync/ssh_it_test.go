@@ -33,6 +33,7 @@ IT_SSH_PORT=2222
 package sync
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/utils"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
@@ -64,7 +65,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 		args[0] = "-lash"
 		args[1] = "/var/"
 		cmd := NewSSH(targetHost, targetPort, *credentials, "ls", args)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		output := (*result).Output
 		gomega.Expect(strings.Contains(output, "local")).To(gomega.BeTrue())
@@ -77,7 +78,7 @@ var _ = ginkgo.Describe("An SCP command", func() {
 		args[0] = "-lash"
 		args[1] = "/var/"
 		cmd := NewSSH(targetHost, targetPort, *credentials, "ls", args)
-		result, err := cmd.Run("w1")
+		result, err := cmd.Run(context.Background(), "w1")
 		gomega.Expect(err).To(gomega.BeNil())
 		output := (*result).Output
 		gomega.Expect(strings.Contains(output, "local")).To(gomega.BeTrue())
@@ -18,6 +18,7 @@
 package zerotier
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/derrors"
@@ -91,9 +92,9 @@ func NewCreateZTPlanetFilesFromJSON(raw []byte) (*entities.Command, derrors.Erro
 	return &r, nil
 }
 
-func (cmd *CreateZTPlanetFiles) generateZTIdentityFiles() derrors.Error {
+func (cmd *CreateZTPlanetFiles) generateZTIdentityFiles(ctx context.Context) derrors.Error {
 	// Generate ZT Planet IDs
-	generateIds := exec.Command(cmd.ZtIdToolBinaryPath, "generate", cmd.IdentitySecretPath, cmd.IdentityPublicPath)
+	generateIds := exec.CommandContext(ctx, cmd.ZtIdToolBinaryPath, "generate", cmd.IdentitySecretPath, cmd.IdentityPublicPath)
 	_, pipeErr := generateIds.StderrPipe()
 	if pipeErr != nil {
 		log.Error().Msg("Error while executing generate command")
@@ -107,14 +108,14 @@ func (cmd *CreateZTPlanetFiles) generateZTIdentityFiles() derrors.Error {
 	return nil
 }
 
-func (cmd *CreateZTPlanetFiles) initMoon() derrors.Error {
+func (cmd *CreateZTPlanetFiles) initMoon(ctx context.Context) derrors.Error {
 	// Init Moon
 	log.Debug().Msg(cmd.ZtIdToolBinaryPath + " initmoon " + "$(cat " + cmd.IdentityPublicPath + ")")
 	identityPublicRaw, err := ioutil.ReadFile(cmd.IdentityPublicPath)
 	if err != nil {
 		return derrors.NewGenericError("cannot read identity public", err)
 	}
-	initMoon := exec.Command(cmd.ZtIdToolBinaryPath, "initmoon", string(identityPublicRaw))
+	initMoon := exec.CommandContext(ctx, cmd.ZtIdToolBinaryPath, "initmoon", string(identityPublicRaw))
 	initMoonOut, err := initMoon.StdoutPipe()
 	if err != nil {
 		log.Error().Msg("Error obtaining stdout for initmoon")
@@ -169,9 +170,9 @@ func (cmd *CreateZTPlanetFiles) initMoon() derrors.Error {
 	return nil
 }
 
-func (cmd *CreateZTPlanetFiles) generatePlanet() derrors.Error {
+func (cmd *CreateZTPlanetFiles) generatePlanet(ctx context.Context) derrors.Error {
 	// Generate Planet file
-	generateMoon := exec.Command(cmd.ZtIdToolBinaryPath, "genmoon", cmd.PlanetJsonPath)
+	generateMoon := exec.CommandContext(ctx, cmd.ZtIdToolBinaryPath, "genmoon", cmd.PlanetJsonPath)
 	generateMoon.Dir = filepath.Dir(cmd.PlanetPath)
 	_, pipeErr := generateMoon.StderrPipe()
 	if pipeErr != nil {
@@ -198,111 +199,89 @@ func (cmd *CreateZTPlanetFiles) generatePlanet() derrors.Error {
 	return nil
 }
 
-func (cmd *CreateZTPlanetFiles) createKubernetesSecrets() derrors.Error {
-	// Planet Secret
-	planetData, err := ioutil.ReadFile(cmd.PlanetPath)
-	if err != nil {
-		log.Error().Msg("cannot read planet file")
-		return derrors.NewGenericError("cannot read planet file", err)
+// createSecret creates an opaque secret in the nalej namespace with the contents of a file. The file is not read in
+// dry-run mode as it is not generated.
+func (cmd *CreateZTPlanetFiles) createSecret(name string, key string, path string) derrors.Error {
+	data := []byte(nil)
+	if !cmd.DryRun() {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Error().Str("path", path).Msg("cannot read file")
+			return derrors.NewGenericError(fmt.Sprintf("cannot read %s file", filepath.Base(path)), err)
+		}
+		data = content
 	}
-	ztPlanetSecret := &v1.Secret{
+	secret := &v1.Secret{
 		TypeMeta: metaV1.TypeMeta{
 			Kind:       "Secret",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metaV1.ObjectMeta{
-			Name:         "zt-planet",
+			Name:         name,
 			GenerateName: "",
 			Namespace:    "nalej",
 		},
 		Data: map[string][]byte{
-			"planet": planetData,
+			key: data,
 		},
 		Type: v1.SecretTypeOpaque,
 	}
-	cmd.Connect()
-	client := cmd.Client.CoreV1().Secrets(ztPlanetSecret.Namespace)
-	created, err := client.Create(ztPlanetSecret)
+	// The secret is sent through Create so the apply mode, the ownership and the dry-run mode of the command apply.
+	err := cmd.Create(secret)
 	if err != nil {
-		log.Error().Msg("Error creating zt-planet secret")
-		return derrors.NewGenericError("Error creating zt-planet secret", err)
+		log.Error().Msgf("Error creating %s secret", name)
+		return derrors.NewGenericError(fmt.Sprintf("Error creating %s secret", name), err)
 	}
-	log.Debug().Interface("created", created).Msg("zt-planet secret has been created")
+	log.Debug().Msgf("%s secret has been created", name)
+	return nil
+}
 
-	// Identity Secret Secret
-	identitySecretData, err := ioutil.ReadFile(cmd.IdentitySecretPath)
+func (cmd *CreateZTPlanetFiles) createKubernetesSecrets() derrors.Error {
+	// Planet Secret
+	err := cmd.createSecret("zt-planet", "planet", cmd.PlanetPath)
 	if err != nil {
-		log.Error().Msg("cannot read identity.secret file")
-		return derrors.NewGenericError("cannot read identity.secret file", err)
+		return err
 	}
-	ztIdentitySecretSecret := &v1.Secret{
-		TypeMeta: metaV1.TypeMeta{
-			Kind:       "Secret",
-			APIVersion: "v1",
-		},
-		ObjectMeta: metaV1.ObjectMeta{
-			Name:         "zt-identity-secret",
-			GenerateName: "",
-			Namespace:    "nalej",
-		},
-		Data: map[string][]byte{
-			"identity.secret": identitySecretData,
-		},
-		Type: v1.SecretTypeOpaque,
-	}
-	created, err = client.Create(ztIdentitySecretSecret)
+	// Identity Secret Secret
+	err = cmd.createSecret("zt-identity-secret", "identity.secret", cmd.IdentitySecretPath)
 	if err != nil {
-		log.Error().Msg("Error creating zt-identity-secret secret")
-		return derrors.NewGenericError("Error creating zt-identity-secret secret", err)
+		return err
 	}
-	log.Debug().Interface("created", created).Msg("zt-identity-secret secret has been created")
-
 	// Identity Public Secret
-	identityPublicData, err := ioutil.ReadFile(cmd.IdentityPublicPath)
-	if err != nil {
-		log.Error().Msg("cannot read identity.public file")
-		return derrors.NewGenericError("cannot read identity.public file", err)
-	}
-	ztIdentityPublicSecret := &v1.Secret{
-		TypeMeta: metaV1.TypeMeta{
-			Kind:       "Secret",
-			APIVersion: "v1",
-		},
-		ObjectMeta: metaV1.ObjectMeta{
-			Name:         "zt-identity-public",
-			GenerateName: "",
-			Namespace:    "nalej",
-		},
-		Data: map[string][]byte{
-			"planet": identityPublicData,
-		},
-		Type: v1.SecretTypeOpaque,
-	}
-	created, err = client.Create(ztIdentityPublicSecret)
-	if err != nil {
-		log.Error().Msg("Error creating zt-identity-public secret")
-		return derrors.NewGenericError("Error creating zt-identity-public secret", err)
-	}
-	log.Debug().Interface("created", created).Msg("zt-identity-public secret has been created")
-
-	return nil
+	return cmd.createSecret("zt-identity-public", "planet", cmd.IdentityPublicPath)
 }
 
 // Run triggers the execution of the command.
-func (cmd *CreateZTPlanetFiles) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (cmd *CreateZTPlanetFiles) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 	log.Debug().Str("path", cmd.ZtIdToolBinaryPath).Msg("ZT ID Tool binary")
 
-	dErr := cmd.generateZTIdentityFiles()
+	dErr := cmd.ConnectWithContext(ctx)
+	if dErr != nil {
+		return nil, dErr
+	}
+
+	if cmd.DryRun() {
+		cmd.RecordExec(fmt.Sprintf("%s generate %s %s", cmd.ZtIdToolBinaryPath, cmd.IdentitySecretPath, cmd.IdentityPublicPath))
+		cmd.RecordExec(fmt.Sprintf("%s initmoon %s", cmd.ZtIdToolBinaryPath, cmd.IdentityPublicPath))
+		cmd.RecordExec(fmt.Sprintf("%s genmoon %s", cmd.ZtIdToolBinaryPath, cmd.PlanetJsonPath))
+		dErr = cmd.createKubernetesSecrets()
+		if dErr != nil {
+			return nil, dErr
+		}
+		return entities.NewSuccessCommand([]byte("ZT Planet files and secrets recorded in dry-run mode.")), nil
+	}
+
+	dErr = cmd.generateZTIdentityFiles(ctx)
 	if dErr != nil {
 		return nil, dErr
 	}
 
-	dErr = cmd.initMoon()
+	dErr = cmd.initMoon(ctx)
 	if dErr != nil {
 		return nil, dErr
 	}
 
-	dErr = cmd.generatePlanet()
+	dErr = cmd.generatePlanet(ctx)
 	if dErr != nil {
 		return nil, dErr
 	}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file registers the ZeroTier commands.
+
+package zerotier
+
+import (
+	"github.com/nalej/installer/internal/pkg/workflow/commands/sync/k8s"
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
+)
+
+func init() {
+	entities.RegisterCommand(entities.CommandDefinition{
+		Type:        entities.SyncCommandType,
+		Name:        entities.CreateZTPlanetFiles,
+		Description: "Creates the identity and the planet files of the ZeroTier network",
+		FromJSON:    NewCreateZTPlanetFilesFromJSON,
+		Parameters: entities.ParametersOf(&CreateZTPlanetFiles{}, k8s.WithKubernetesParameters(map[string]string{
+			"ztIdToolBinaryPath":     "Path of the zerotier-idtool binary",
+			"management_public_host": "Public host of the management cluster",
+			"identitySecretPath":     "Path of the secret identity file",
+			"identityPublicPath":     "Path of the public identity file",
+			"planetJsonPath":         "Path of the planet definition in JSON",
+			"planetPath":             "Path of the planet file",
+		})),
+	})
+}
@@ -22,6 +22,7 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
@@ -94,14 +95,24 @@ func NewTryFromJSON(raw []byte) (*entities.Command, derrors.Error) {
 //   returns:
 //     The CommandResult
 //     An error if the command execution fails
-func (t *Try) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
+func (t *Try) Run(ctx context.Context, workflowID string) (*entities.CommandResult, derrors.Error) {
 	t.commandHandler.AddLogEntry(t.CommandID, fmt.Sprintf("Try %s", t.TryCommand.Name()))
-	result, err := t.executeCommand(workflowID, t.TryCommand)
+	result, err := t.executeWithEvents(ctx, workflowID, t.TryCommand)
 	if err != nil {
 		log.Debug().Str("err", err.Error()).Msg("retry on cmd error")
 	}
+	// A canceled execution must not trigger the fallback command.
+	if ctxErr := entities.ContextError(ctx); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil || !result.Success {
-		result, err = t.executeCommand(workflowID, t.OnFailCommand)
+		result, err = t.executeWithEvents(ctx, workflowID, t.OnFailCommand)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		t.commandHandler.AddEvent(t.CommandID, *entities.NewExecutionEvent(workflowID, t.OnFailCommand, t.CommandID,
+			entities.SkippedPhase, "the try command succeeded"))
 	}
 	if result.Success {
 		return entities.NewCommandResultNoShow(true, result.Output, nil), nil
@@ -109,18 +120,28 @@ func (t *Try) Run(workflowID string) (*entities.CommandResult, derrors.Error) {
 	return result, nil
 }
 
-func (t *Try) executeCommand(workflowID string, cmd entities.Command) (*entities.CommandResult, derrors.Error) {
+// executeWithEvents executes a command reporting when it starts and finishes.
+func (t *Try) executeWithEvents(ctx context.Context, workflowID string, cmd entities.Command) (*entities.CommandResult, derrors.Error) {
+	started := entities.NewStartedEvent(workflowID, cmd, t.CommandID)
+	t.commandHandler.AddEvent(t.CommandID, *started)
+	result, err := t.executeCommand(ctx, workflowID, cmd)
+	t.commandHandler.AddEvent(t.CommandID,
+		*entities.NewFinishedEvent(workflowID, cmd, t.CommandID, started.Started, result, err))
+	return result, err
+}
+
+func (t *Try) executeCommand(ctx context.Context, workflowID string, cmd entities.Command) (*entities.CommandResult, derrors.Error) {
 	err := t.commandHandler.AddCommand(cmd.ID(), t.commandCallback, t.logCallback)
 	if err != nil {
 		return nil, err
 	}
-	if cmd.Name() != entities.Logger {
-		t.commandHandler.AddLogEntry(t.CommandID, "Executing: "+cmd.String()+" with Id: "+cmd.ID())
-	}
+	t.commandHandler.AttachEventCallback(cmd.ID(), t.eventCallback)
 
 	if cmd.Type() == entities.SyncCommandType {
 		log.Debug().Str("cmd", t.CommandID).Str("cmdID", cmd.ID()).Str("cmd", cmd.String()).Msg("SYNC")
-		result, err := cmd.(entities.SyncCommand).Run(workflowID)
+		result, err := entities.RunSyncCommand(ctx, cmd, workflowID, func(entry string) {
+			t.commandHandler.AddLogEntry(t.CommandID, entry)
+		})
 		if err != nil {
 			log.Warn().Str("cmd", t.CommandID).Str("cmdID", cmd.ID()).Str("err", err.DebugReport()).
 				Msg("error executing sync command on sequential group: ")
@@ -132,7 +153,9 @@ func (t *Try) executeCommand(workflowID string, cmd entities.Command) (*entities
 	// Assume async command.
 	log.Debug().Str("cmd", t.CommandID).Str("cmdID", cmd.ID()).Str("cmd", cmd.String()).Msg("ASYNC")
 	t.asyncCmdID = cmd.ID()
-	err = cmd.(entities.AsyncCommand).Run(workflowID)
+	stopWatch := handler.WatchTimeout(t.commandHandler, cmd)
+	defer stopWatch()
+	err = cmd.(entities.AsyncCommand).Run(ctx, workflowID)
 	if err != nil {
 		log.Warn().Str("cmd", t.CommandID).Str("cmdID", cmd.ID()).Str("err", err.DebugReport()).
 			Msg("error executing async command on sequential group: ")
@@ -179,6 +202,11 @@ func (t *Try) logCallback(cmdID string, logEntry string) {
 	t.commandHandler.AddLogEntry(t.CommandID, fmt.Sprintf("[%s] %s", t.Description, logEntry))
 }
 
+// eventCallback forwards the events of the nested commands to the parent of the try.
+func (t *Try) eventCallback(cmdID string, event entities.ExecutionEvent) {
+	t.commandHandler.AddEvent(t.CommandID, event)
+}
+
 // String obtains a string representation
 func (t *Try) String() string {
 	return fmt.Sprintf("SYNC Try %s execute: %s onFailure: %s", t.Description, t.TryCommand.Name(), t.OnFailCommand.Name())
@@ -196,3 +224,8 @@ func (t *Try) PrettyPrint(indentation int) string {
 func (t *Try) UserString() string {
 	return fmt.Sprintf("Try %s execute: %s onFailure: %s", t.Description, t.TryCommand.Name(), t.OnFailCommand.Name())
 }
+
+// SupportsDryRun returns true as the commands of the block are checked when they are executed.
+func (t *Try) SupportsDryRun() bool {
+	return true
+}
@@ -18,6 +18,7 @@
 package commands
 
 import (
+	"context"
 	"github.com/nalej/installer/internal/pkg/workflow/commands/async"
 	"github.com/nalej/installer/internal/pkg/workflow/commands/sync"
 	"github.com/onsi/ginkgo"
@@ -31,7 +32,7 @@ var _ = ginkgo.Describe("Try", func() {
 			cmd2 := sync.NewLogger("cmd2")
 			try := NewTry("test sync", cmd1, cmd2)
 			wID := "testWorkflow"
-			result, err := try.Run(wID)
+			result, err := try.Run(context.Background(), wID)
 			gomega.Expect(err).To(gomega.BeNil())
 			gomega.Expect(result.Success).To(gomega.BeTrue())
 			gomega.Expect(result.Output).To(gomega.Equal("cmd1"))
@@ -41,7 +42,7 @@ var _ = ginkgo.Describe("Try", func() {
 			cmd2 := sync.NewLogger("cmd2")
 			try := NewTry("test sync fail", cmd1, cmd2)
 			wID := "testWorkflow"
-			result, err := try.Run(wID)
+			result, err := try.Run(context.Background(), wID)
 			gomega.Expect(err).To(gomega.BeNil())
 			gomega.Expect(result.Success).To(gomega.BeTrue())
 			gomega.Expect(result.Output).To(gomega.Equal("cmd2"))
@@ -54,7 +55,7 @@ var _ = ginkgo.Describe("Try", func() {
 			cmd2 := sync.NewLogger("cmd2")
 			try := NewTry("test async", cmd1, cmd2)
 			wID := "testWorkflow"
-			result, err := try.Run(wID)
+			result, err := try.Run(context.Background(), wID)
 			gomega.Expect(err).To(gomega.BeNil())
 			gomega.Expect(result.Success).To(gomega.BeTrue())
 			gomega.Expect(result.Output).To(gomega.Equal("Slept for 0"))
@@ -64,7 +65,7 @@ var _ = ginkgo.Describe("Try", func() {
 			cmd2 := async.NewSleep("0")
 			try := NewTry("test async", cmd1, cmd2)
 			wID := "testWorkflow"
-			result, err := try.Run(wID)
+			result, err := try.Run(context.Background(), wID)
 			gomega.Expect(err).To(gomega.BeNil())
 			gomega.Expect(result.Success).To(gomega.BeTrue())
 			gomega.Expect(result.Output).To(gomega.Equal("Slept for 0"))
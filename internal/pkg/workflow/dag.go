@@ -0,0 +1,151 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the dependencies between the commands of a workflow
+//
+// A command may define an identifier and the list of commands that must finish before it is launched:
+//
+// {"type":"sync", "name": "...", "id": "dns", "dependsOn": ["config"]}
+//
+// Commands without a dependsOn list depend on the previous command of the workflow, so workflows that do not
+// use dependencies are executed sequentially. Use "dependsOn": [] for commands that can be launched right away.
+
+package workflow
+
+import (
+	"encoding/json"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+)
+
+// nodeFromJSON is used to extract the optional dependency attributes of a command.
+type nodeFromJSON struct {
+	ID        string    `json:"id"`
+	DependsOn *[]string `json:"dependsOn"`
+}
+
+// parseDependencies extracts the identifiers and dependencies of the commands of a workflow.
+//
+//	params:
+//	  raws The raw commands of the workflow.
+//	returns:
+//	  The identifiers of the commands indexed by position.
+//	  The dependencies of the commands that define them indexed by position.
+//	  An error if a command has a duplicated identifier or depends on an unknown one.
+func parseDependencies(raws []json.RawMessage) (map[int]string, map[int][]int, derrors.Error) {
+	nodes := make([]nodeFromJSON, len(raws))
+	ids := make(map[int]string, 0)
+	indexes := make(map[string]int, 0)
+	for index, raw := range raws {
+		if err := json.Unmarshal(raw, &nodes[index]); err != nil {
+			return nil, nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+		}
+		id := nodes[index].ID
+		if id == "" {
+			continue
+		}
+		if _, exists := indexes[id]; exists {
+			return nil, nil, derrors.NewInvalidArgumentError(errors.DuplicatedNodeID).WithParams(id)
+		}
+		indexes[id] = index
+		ids[index] = id
+	}
+	dependsOn := make(map[int][]int, 0)
+	for index, node := range nodes {
+		if node.DependsOn == nil {
+			continue
+		}
+		deps := make([]int, 0, len(*node.DependsOn))
+		for _, dep := range *node.DependsOn {
+			depIndex, exists := indexes[dep]
+			if !exists {
+				return nil, nil, derrors.NewInvalidArgumentError(errors.UnknownDependency).WithParams(index, dep)
+			}
+			deps = append(deps, depIndex)
+		}
+		dependsOn[index] = deps
+	}
+	return ids, dependsOn, nil
+}
+
+// Dependencies returns the indexes of the commands that must finish before a given command is launched.
+func (w *Workflow) Dependencies(index int) []int {
+	if deps, exists := w.DependsOn[index]; exists {
+		return deps
+	}
+	if index == 0 {
+		return []int{}
+	}
+	return []int{index - 1}
+}
+
+// ValidateDependencies checks that the dependencies of the commands do not contain cycles.
+func (w *Workflow) ValidateDependencies() derrors.Error {
+	if w.MaxParallelism < 0 {
+		return derrors.NewInvalidArgumentError(errors.InvalidMaxParallelism).WithParams(w.MaxParallelism)
+	}
+	pending := make(map[int]int, len(w.Commands))
+	dependants := make(map[int][]int, len(w.Commands))
+	for index := range w.Commands {
+		deps := w.Dependencies(index)
+		for _, dep := range deps {
+			if dep < 0 || dep >= len(w.Commands) {
+				return derrors.NewInvalidArgumentError(errors.UnknownDependency).WithParams(index, dep)
+			}
+			dependants[dep] = append(dependants[dep], index)
+		}
+		pending[index] = len(deps)
+	}
+	// Remove the commands without pending dependencies until none is left. The remaining commands are part of
+	// a cycle.
+	ready := make([]int, 0)
+	for index := range w.Commands {
+		if pending[index] == 0 {
+			ready = append(ready, index)
+		}
+	}
+	visited := 0
+	for len(ready) > 0 {
+		next := ready[0]
+		ready = ready[1:]
+		visited++
+		for _, dependant := range dependants[next] {
+			pending[dependant]--
+			if pending[dependant] == 0 {
+				ready = append(ready, dependant)
+			}
+		}
+	}
+	if visited != len(w.Commands) {
+		cycle := make([]string, 0)
+		for index := range w.Commands {
+			if pending[index] > 0 {
+				cycle = append(cycle, w.nodeName(index))
+			}
+		}
+		return derrors.NewInvalidArgumentError(errors.DependencyCycle).WithParams(cycle)
+	}
+	return nil
+}
+
+// nodeName returns the identifier of a command, or its name if it does not define one.
+func (w *Workflow) nodeName(index int) string {
+	if id, exists := w.NodeIDs[index]; exists {
+		return id
+	}
+	return w.Commands[index].Name()
+}
@@ -20,8 +20,11 @@
 package entities
 
 import (
+	"context"
 	"fmt"
+	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/satori/go.uuid"
+	"time"
 
 	"github.com/nalej/derrors"
 )
@@ -67,6 +70,14 @@ type Command interface {
 	PrettyPrint(indentation int) string
 	// UserString returns a simple string representation of the command for the user.
 	UserString() string
+	// RetryPolicy returns the retry policy of the command, nil if the command is executed once.
+	RetryPolicy() *RetryPolicy
+	// SetRetryPolicy attaches a retry policy to the command.
+	SetRetryPolicy(policy *RetryPolicy)
+	// Timeout returns the maximum duration of the command, zero if the command has no deadline.
+	Timeout() time.Duration
+	// SetTimeout sets the maximum duration of the command.
+	SetTimeout(timeout time.Duration)
 }
 
 // GenericCommand providing a type and name.
@@ -77,6 +88,10 @@ type GenericCommand struct {
 	CommandType CommandType `json:"type"`
 	// CommandName with the command name.a
 	CommandName string `json:"name"`
+	// retryPolicy with the retries of the command.
+	retryPolicy *RetryPolicy
+	// timeout with the maximum duration of the command.
+	timeout time.Duration
 }
 
 //ID is the internal command identification.
@@ -94,10 +109,30 @@ func (gc *GenericCommand) Name() string {
 	return gc.CommandName
 }
 
+// RetryPolicy returns the retry policy of the command, nil if the command is executed once.
+func (gc *GenericCommand) RetryPolicy() *RetryPolicy {
+	return gc.retryPolicy
+}
+
+// SetRetryPolicy attaches a retry policy to the command.
+func (gc *GenericCommand) SetRetryPolicy(policy *RetryPolicy) {
+	gc.retryPolicy = policy
+}
+
+// Timeout returns the maximum duration of the command, zero if the command has no deadline.
+func (gc *GenericCommand) Timeout() time.Duration {
+	return gc.timeout
+}
+
+// SetTimeout sets the maximum duration of the command.
+func (gc *GenericCommand) SetTimeout(timeout time.Duration) {
+	gc.timeout = timeout
+}
+
 // NewGenericCommand creates a basic GenericCommand.
 func NewGenericCommand(commandType CommandType, name string) GenericCommand {
 	id := GenerateCommandID(name)
-	return GenericCommand{id, commandType, name}
+	return GenericCommand{id, commandType, name, nil, 0}
 }
 
 // CommandResult structure defines the elements of a command result.
@@ -175,10 +210,29 @@ func (cr *CommandResult) ShowResult() bool {
 	return cr.showResult
 }
 
+// ContextError transforms the reason of a finished context into a derrors.Error. It returns nil if the
+// context is still active.
+func ContextError(ctx context.Context) derrors.Error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return derrors.NewDeadlineExceededError(errors.CommandDeadlineExceeded)
+	default:
+		return derrors.NewCanceledError(errors.CommandCanceled)
+	}
+}
+
+// IsDeadlineExceeded checks if an error has been produced by reaching a timeout.
+func IsDeadlineExceeded(err derrors.Error) bool {
+	return err != nil && err.Type() == derrors.NewDeadlineExceededError(errors.CommandDeadlineExceeded).Type()
+}
+
 // SyncCommand interface defines the functions synchronous commands need to implement.
 type SyncCommand interface {
-	// Run the current command returning the result or an error.
-	Run(workflowID string) (*CommandResult, derrors.Error)
+	// Run the current command returning the result or an error. The execution must be aborted when
+	// the context is done.
+	Run(ctx context.Context, workflowID string) (*CommandResult, derrors.Error)
 }
 
 // GenericSyncCommand is a basic synchronous command.
@@ -195,10 +249,10 @@ func NewSyncCommand(name string) *GenericSyncCommand {
 type AsyncCommand interface {
 	// Actions return the array of actions in the command.
 	Actions() []Action
-	// Run the current command.
+	// Run the current command. The execution must be aborted when the context is done.
 	//   returns:
 	//     An error if the command execution fails
-	Run(workflowID string) derrors.Error
+	Run(ctx context.Context, workflowID string) derrors.Error
 }
 
 // GenericAsyncCommand structure with the command actions.
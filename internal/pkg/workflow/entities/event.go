@@ -0,0 +1,155 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the events produced during the execution of a workflow
+
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExecutionPhase defines the different phases of the execution of a command.
+type ExecutionPhase string
+
+// StartedPhase is reported when a command is launched.
+const StartedPhase ExecutionPhase = "started"
+
+// OutputPhase is reported for each log entry produced by a running command.
+const OutputPhase ExecutionPhase = "output"
+
+// SucceededPhase is reported when a command finishes successfully.
+const SucceededPhase ExecutionPhase = "succeeded"
+
+// FailedPhase is reported when a command returns an error or an unsuccessful result.
+const FailedPhase ExecutionPhase = "failed"
+
+// SkippedPhase is reported for commands that are not executed.
+const SkippedPhase ExecutionPhase = "skipped"
+
+// ExecutionEvent structure with an update of the execution of a command.
+type ExecutionEvent struct {
+	// WorkflowID with the identifier of the workflow being executed.
+	WorkflowID string `json:"workflowId"`
+	// CommandID with the identifier of the command. Empty for the messages of the workflow.
+	CommandID string `json:"commandId,omitempty"`
+	// CommandName with the name of the command.
+	CommandName string `json:"commandName,omitempty"`
+	// ParentCommandID with the identifier of the group, parallel or try command that launched the command. Empty
+	// for the commands of the workflow.
+	ParentCommandID string `json:"parentCommandId,omitempty"`
+	// Phase of the execution.
+	Phase ExecutionPhase `json:"phase"`
+	// Msg with the description of the command, its output, or the failure reason depending on the phase.
+	Msg string `json:"msg,omitempty"`
+	// Timestamp of the event.
+	Timestamp time.Time `json:"timestamp"`
+	// Started with the time when the command was launched. Zero for skipped commands.
+	Started time.Time `json:"started,omitempty"`
+	// Duration of the execution of the command once it finishes.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// NewExecutionEvent creates a new ExecutionEvent.
+//
+//	params:
+//	  workflowID The workflow identifier.
+//	  cmd The command that produces the event.
+//	  parentCommandID The identifier of the command that launched it, empty for workflow commands.
+//	  phase The execution phase.
+//	  msg The message of the event.
+//	returns:
+//	  A new ExecutionEvent.
+func NewExecutionEvent(workflowID string, cmd Command, parentCommandID string, phase ExecutionPhase, msg string) *ExecutionEvent {
+	return &ExecutionEvent{
+		WorkflowID:      workflowID,
+		CommandID:       cmd.ID(),
+		CommandName:     cmd.Name(),
+		ParentCommandID: parentCommandID,
+		Phase:           phase,
+		Msg:             msg,
+		Timestamp:       time.Now(),
+	}
+}
+
+// NewStartedEvent creates the event reported when a command is launched.
+func NewStartedEvent(workflowID string, cmd Command, parentCommandID string) *ExecutionEvent {
+	event := NewExecutionEvent(workflowID, cmd, parentCommandID, StartedPhase, cmd.UserString())
+	event.Started = event.Timestamp
+	return event
+}
+
+// NewFinishedEvent creates the event reported when a command finishes.
+//
+//	params:
+//	  workflowID The workflow identifier.
+//	  cmd The command that finished.
+//	  parentCommandID The identifier of the command that launched it, empty for workflow commands.
+//	  started The time when the command was launched.
+//	  result The result of the command, if any.
+//	  err The error returned by the command, if any.
+//	returns:
+//	  An event with the SucceededPhase or the FailedPhase.
+func NewFinishedEvent(workflowID string, cmd Command, parentCommandID string, started time.Time,
+	result *CommandResult, err error) *ExecutionEvent {
+	phase := SucceededPhase
+	msg := ""
+	if err != nil {
+		phase = FailedPhase
+		msg = err.Error()
+	} else if result == nil || !result.Success {
+		phase = FailedPhase
+		if result != nil {
+			msg = result.UserString()
+		}
+	} else if result.HasOutput() && result.ShowResult() {
+		msg = result.Output
+	}
+	event := NewExecutionEvent(workflowID, cmd, parentCommandID, phase, msg)
+	event.Started = started
+	event.Duration = event.Timestamp.Sub(started)
+	return event
+}
+
+// Finished checks if the event is reported once the command is done.
+func (ee *ExecutionEvent) Finished() bool {
+	return ee.Phase == SucceededPhase || ee.Phase == FailedPhase || ee.Phase == SkippedPhase
+}
+
+// String returns the log entry associated with the event, empty if the event is not logged.
+func (ee *ExecutionEvent) String() string {
+	switch ee.Phase {
+	case StartedPhase:
+		if ee.CommandName == Logger {
+			return ""
+		}
+		return "Executing: " + ee.Msg
+	case OutputPhase:
+		return ee.Msg
+	case SucceededPhase:
+		if ee.Msg == "" || ee.CommandName == Logger {
+			return ee.Msg
+		}
+		return fmt.Sprintf("Command %s:\n%s", ee.CommandID, ee.Msg)
+	case FailedPhase:
+		return fmt.Sprintf("Command %s failed after %s: %s", ee.CommandName, ee.Duration.String(), ee.Msg)
+	case SkippedPhase:
+		return fmt.Sprintf("Skipping %s: %s", ee.CommandName, ee.Msg)
+	}
+	return ee.Msg
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the execution of synchronous commands applying their retry policy and timeout.
+
+package entities
+
+import (
+	"context"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// RunSyncCommand executes a synchronous command applying its retry policy and timeout. In dry-run mode, the commands
+// that do not support it are recorded in the manifest and skipped.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  cmd The command to be executed.
+//	  workflowID The identifier of the workflow.
+//	  logEntry Optional function that receives the log entries of the retries.
+//	returns:
+//	  The CommandResult of the last attempt.
+//	  An error if the last attempt failed.
+func RunSyncCommand(ctx context.Context, cmd Command, workflowID string, logEntry func(entry string)) (*CommandResult, derrors.Error) {
+	if manifest := ManifestFromContext(ctx); manifest != nil && !SupportsDryRun(cmd) {
+		log.Debug().Str("cmdID", cmd.ID()).Msg("command skipped in dry-run mode")
+		manifest.RecordCommand(cmd)
+		return NewCommandResult(true, "skipped in dry-run mode", nil), nil
+	}
+	policy := cmd.RetryPolicy()
+	if policy == nil || policy.Retries == 0 {
+		return runWithTimeout(ctx, cmd, workflowID)
+	}
+	return policy.Run(ctx, func() (*CommandResult, derrors.Error) {
+		return runWithTimeout(ctx, cmd, workflowID)
+	}, func(retry int, delay time.Duration) {
+		log.Warn().Str("cmdID", cmd.ID()).Int("retry", retry).Str("delay", delay.String()).Msg("retrying command")
+		if logEntry != nil {
+			logEntry(fmt.Sprintf("Retrying %s in %s (%d of %d)", cmd.Name(), delay.String(), retry, policy.Retries))
+		}
+	})
+}
+
+// syncOutcome structure with the values returned by a synchronous command.
+type syncOutcome struct {
+	result *CommandResult
+	err    derrors.Error
+}
+
+// runWithTimeout executes a synchronous command, failing with a DeadlineExceeded error if the command does not
+// finish before its timeout. Commands that do not honor the context are left running on the background.
+func runWithTimeout(ctx context.Context, cmd Command, workflowID string) (*CommandResult, derrors.Error) {
+	toRun := cmd.(SyncCommand)
+	timeout := cmd.Timeout()
+	if timeout == 0 {
+		return toRun.Run(ctx, workflowID)
+	}
+	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan syncOutcome, 1)
+	go func() {
+		result, err := toRun.Run(cmdCtx, workflowID)
+		done <- syncOutcome{result, err}
+	}()
+	select {
+	case outcome := <-done:
+		if ctx.Err() == nil && cmdCtx.Err() == context.DeadlineExceeded {
+			return nil, commandTimeoutError(cmd)
+		}
+		return outcome.result, outcome.err
+	case <-cmdCtx.Done():
+		if ctxErr := ContextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		log.Warn().Str("cmdID", cmd.ID()).Str("timeout", timeout.String()).Msg("command timeout reached")
+		return nil, commandTimeoutError(cmd)
+	}
+}
+
+// commandTimeoutError creates the error returned when a command reaches its timeout.
+func commandTimeoutError(cmd Command) derrors.Error {
+	return derrors.NewDeadlineExceededError(errors.CommandDeadlineExceeded).WithParams(cmd.Name(), cmd.Timeout().String())
+}
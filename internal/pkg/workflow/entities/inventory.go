@@ -0,0 +1,238 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the inventory of the objects applied by an install
+//
+// The commands that interact with Kubernetes add the objects they apply to the inventory of the execution context.
+// The inventory is stored in the target cluster at the end of the install, so the uninstall, the upgrades and the
+// drift detection know what was installed, by which workflow and with which parameters.
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InventoryName is the name of the config map that stores the inventory in the target cluster.
+const InventoryName = "nalej-installer-inventory"
+
+// InventoryNamespace is the default namespace of the inventory config map.
+const InventoryNamespace = "nalej"
+
+// InventoryKey is the key of the config map data with the inventory in JSON.
+const InventoryKey = "inventory.json"
+
+// InventoryObject structure with an object applied by the installer.
+type InventoryObject struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+	// Kind of the object.
+	Kind string `json:"kind"`
+	// Namespace of the object, empty for cluster wide objects.
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object.
+	Name string `json:"name"`
+	// Component that contains the object.
+	Component string `json:"component,omitempty"`
+	// Hash with the SHA-256 of the definition sent to the cluster.
+	Hash string `json:"hash"`
+}
+
+// Key returns the identifier of the object in the inventory.
+func (o *InventoryObject) Key() string {
+	return fmt.Sprintf("%s/%s/%s/%s", o.APIVersion, o.Kind, o.Namespace, o.Name)
+}
+
+// String returns a single line description of the object.
+func (o *InventoryObject) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s/%s %s", o.APIVersion, o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s/%s %s/%s", o.APIVersion, o.Kind, o.Namespace, o.Name)
+}
+
+// Inventory structure with the record of an install. It is safe for concurrent use as commands may run in parallel.
+type Inventory struct {
+	lock sync.Mutex
+	// Workflow with the name of the workflow that performed the install.
+	Workflow string `json:"workflow"`
+	// Description with the description of the workflow.
+	Description string `json:"description,omitempty"`
+	// InstallerVersion with the version of the installer.
+	InstallerVersion string `json:"installerVersion"`
+	// RequestID with the identifier of the install request.
+	RequestID string `json:"requestId,omitempty"`
+	// ClusterID with the identifier of the installed cluster.
+	ClusterID string `json:"clusterId,omitempty"`
+	// ComponentsVersion with the version of the bundle of components launched.
+	ComponentsVersion string `json:"componentsVersion,omitempty"`
+	// Parameters with the parameters chosen for the install. Credentials and secrets are not included.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Started with the time the install started.
+	Started time.Time `json:"started"`
+	// Finished with the time the inventory was written.
+	Finished time.Time `json:"finished"`
+	// Objects with the objects applied sorted by key.
+	Objects []InventoryObject `json:"objects"`
+}
+
+// NewInventory creates an empty Inventory.
+//   params:
+//     workflow The name of the install workflow.
+//     description The description of the install workflow.
+//     ownership The ownership of the objects, with the installer version, the request and the cluster.
+//     parameters The parameters of the install that can be disclosed.
+//   returns:
+//     A new inventory started now.
+func NewInventory(workflow string, description string, ownership *Ownership, parameters map[string]string) *Inventory {
+	inventory := &Inventory{
+		Workflow:    workflow,
+		Description: description,
+		Parameters:  parameters,
+		Started:     time.Now().UTC(),
+		Objects:     make([]InventoryObject, 0),
+	}
+	if ownership != nil {
+		inventory.InstallerVersion = ownership.InstallerVersion
+		inventory.RequestID = ownership.RequestID
+		inventory.ClusterID = ownership.ClusterID
+	}
+	return inventory
+}
+
+// NewInventoryFromJSON creates an Inventory from its JSON representation.
+func NewInventoryFromJSON(raw []byte) (*Inventory, derrors.Error) {
+	inventory := &Inventory{}
+	if err := json.Unmarshal(raw, inventory); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.InvalidInventory, err)
+	}
+	if inventory.Objects == nil {
+		inventory.Objects = make([]InventoryObject, 0)
+	}
+	return inventory, nil
+}
+
+// Record adds an object to the inventory, replacing the previous record of the same object.
+func (i *Inventory) Record(obj InventoryObject) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.unsafeRecord(obj)
+}
+
+func (i *Inventory) unsafeRecord(obj InventoryObject) {
+	key := obj.Key()
+	index := sort.Search(len(i.Objects), func(index int) bool { return i.Objects[index].Key() >= key })
+	if index < len(i.Objects) && i.Objects[index].Key() == key {
+		i.Objects[index] = obj
+		return
+	}
+	i.Objects = append(i.Objects, InventoryObject{})
+	copy(i.Objects[index+1:], i.Objects[index:])
+	i.Objects[index] = obj
+}
+
+// Merge adds the objects of a previous inventory that have not been recorded in this one. It is used when an
+// install is resumed, as the objects applied before the restart are not recorded again.
+func (i *Inventory) Merge(previous *Inventory) {
+	previousObjects := previous.Entries()
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	for _, obj := range previousObjects {
+		if i.unsafeFind(obj.Key()) == nil {
+			i.unsafeRecord(obj)
+		}
+	}
+	if previous.Started.Before(i.Started) {
+		i.Started = previous.Started
+	}
+	if i.ComponentsVersion == "" {
+		i.ComponentsVersion = previous.ComponentsVersion
+	}
+}
+
+func (i *Inventory) unsafeFind(key string) *InventoryObject {
+	index := sort.Search(len(i.Objects), func(index int) bool { return i.Objects[index].Key() >= key })
+	if index < len(i.Objects) && i.Objects[index].Key() == key {
+		return &i.Objects[index]
+	}
+	return nil
+}
+
+// Find returns the record of an object, or nil if it is not in the inventory.
+func (i *Inventory) Find(apiVersion string, kind string, namespace string, name string) *InventoryObject {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	key := (&InventoryObject{APIVersion: apiVersion, Kind: kind, Namespace: namespace, Name: name}).Key()
+	found := i.unsafeFind(key)
+	if found == nil {
+		return nil
+	}
+	result := *found
+	return &result
+}
+
+// SetComponentsVersion sets the version of the bundle of components launched.
+func (i *Inventory) SetComponentsVersion(componentsVersion string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.ComponentsVersion = componentsVersion
+}
+
+// Entries retrieves a copy of the recorded objects sorted by key.
+func (i *Inventory) Entries() []InventoryObject {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	result := make([]InventoryObject, len(i.Objects))
+	copy(result, i.Objects)
+	return result
+}
+
+// ToJSON returns the JSON representation of the inventory setting the finished time.
+func (i *Inventory) ToJSON(finished time.Time) ([]byte, derrors.Error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.Finished = finished.UTC()
+	raw, err := json.Marshal(i)
+	if err != nil {
+		return nil, derrors.NewInternalError(errors.InvalidInventory, err)
+	}
+	return raw, nil
+}
+
+// inventoryKey is the key of the inventory in the execution context.
+type inventoryKey struct{}
+
+// WithInventory returns a context whose commands record the objects they apply in the given inventory.
+func WithInventory(ctx context.Context, inventory *Inventory) context.Context {
+	return context.WithValue(ctx, inventoryKey{}, inventory)
+}
+
+// InventoryFromContext returns the inventory of the execution, or nil if the objects are not recorded.
+func InventoryFromContext(ctx context.Context) *Inventory {
+	if ctx == nil {
+		return nil
+	}
+	inventory, _ := ctx.Value(inventoryKey{}).(*Inventory)
+	return inventory
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package entities
+
+import (
+	"context"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"time"
+)
+
+func inventoryObject(kind string, namespace string, name string, hash string) InventoryObject {
+	return InventoryObject{APIVersion: "v1", Kind: kind, Namespace: namespace, Name: name, Hash: hash}
+}
+
+var _ = ginkgo.Describe("Inventory", func() {
+
+	ginkgo.It("must record the objects sorted and without duplicates", func() {
+		inventory := NewInventory("install", "Install management cluster", NewOwnership("v1", "request", "cluster"),
+			map[string]string{"appCluster": "false"})
+		gomega.Expect(inventory.RequestID).To(gomega.Equal("request"))
+		gomega.Expect(inventory.ClusterID).To(gomega.Equal("cluster"))
+		inventory.Record(inventoryObject("Service", "nalej", "dns", "1"))
+		inventory.Record(inventoryObject("ConfigMap", "nalej", "config", "2"))
+		inventory.Record(inventoryObject("Service", "nalej", "dns", "3"))
+		entries := inventory.Entries()
+		gomega.Expect(len(entries)).To(gomega.Equal(2))
+		gomega.Expect(entries[0].Name).To(gomega.Equal("config"))
+		gomega.Expect(entries[1].Hash).To(gomega.Equal("3"))
+		gomega.Expect(inventory.Find("v1", "Service", "nalej", "dns")).ToNot(gomega.BeNil())
+		gomega.Expect(inventory.Find("v1", "Service", "default", "dns")).To(gomega.BeNil())
+	})
+
+	ginkgo.It("must be stored as JSON", func() {
+		inventory := NewInventory("install", "", nil, map[string]string{"appCluster": "true"})
+		inventory.SetComponentsVersion("v0.4.0")
+		inventory.Record(inventoryObject("Namespace", "", "nalej", "1"))
+		finished := time.Now()
+		raw, err := inventory.ToJSON(finished)
+		gomega.Expect(err).To(gomega.BeNil())
+		retrieved, err := NewInventoryFromJSON(raw)
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(retrieved.Workflow).To(gomega.Equal("install"))
+		gomega.Expect(retrieved.ComponentsVersion).To(gomega.Equal("v0.4.0"))
+		gomega.Expect(retrieved.Parameters).To(gomega.Equal(inventory.Parameters))
+		gomega.Expect(retrieved.Finished.Equal(finished.UTC())).To(gomega.BeTrue())
+		gomega.Expect(retrieved.Entries()).To(gomega.Equal(inventory.Entries()))
+		_, err = NewInventoryFromJSON([]byte("{"))
+		gomega.Expect(err).ToNot(gomega.BeNil())
+	})
+
+	ginkgo.It("must keep the objects of a resumed install", func() {
+		previous := NewInventory("install", "", nil, nil)
+		previous.Started = time.Now().Add(-time.Hour)
+		previous.SetComponentsVersion("v1")
+		previous.Record(inventoryObject("Namespace", "", "nalej", "1"))
+		previous.Record(inventoryObject("Service", "nalej", "dns", "1"))
+		current := NewInventory("install", "", nil, nil)
+		current.Record(inventoryObject("Service", "nalej", "dns", "2"))
+		current.Merge(previous)
+		gomega.Expect(len(current.Entries())).To(gomega.Equal(2))
+		gomega.Expect(current.Find("v1", "Service", "nalej", "dns").Hash).To(gomega.Equal("2"))
+		gomega.Expect(current.Started).To(gomega.Equal(previous.Started))
+		gomega.Expect(current.ComponentsVersion).To(gomega.Equal("v1"))
+	})
+
+	ginkgo.It("must be carried by the context", func() {
+		gomega.Expect(InventoryFromContext(context.Background())).To(gomega.BeNil())
+		inventory := NewInventory("install", "", nil, nil)
+		gomega.Expect(InventoryFromContext(WithInventory(context.Background(), inventory))).To(gomega.Equal(inventory))
+	})
+
+})
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the manifest of changes recorded when a workflow is executed in dry-run mode
+//
+// In dry-run mode the commands that interact with Kubernetes read the cluster as usual, but the objects they would
+// create, update or delete are added to the manifest of the execution context instead. Commands that cannot be
+// executed in dry-run mode are recorded with the exec action and skipped.
+
+package entities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ManifestAction defines the type of change recorded in a manifest.
+type ManifestAction string
+
+// CreateAction for objects that would be created.
+const CreateAction ManifestAction = "create"
+
+// UpdateAction for objects that would be updated.
+const UpdateAction ManifestAction = "update"
+
+// PatchAction for objects that would be patched.
+const PatchAction ManifestAction = "patch"
+
+// DeleteAction for objects that would be deleted.
+const DeleteAction ManifestAction = "delete"
+
+// ExecAction for commands and external tools that would be executed.
+const ExecAction ManifestAction = "exec"
+
+// ManifestEntry structure with a change that would be applied by a command.
+type ManifestEntry struct {
+	// CommandID with the identifier of the command that produced the change.
+	CommandID string `json:"commandId,omitempty"`
+	// Action to be performed.
+	Action ManifestAction `json:"action"`
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the object.
+	Kind string `json:"kind,omitempty"`
+	// Resource with the name of the resource for entities deleted by resource, e.g. clusterroles.
+	Resource string `json:"resource,omitempty"`
+	// Namespace of the object, empty for cluster wide objects.
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object, or the description of the command for the exec action.
+	Name string `json:"name,omitempty"`
+	// Definition with the YAML of the object for create, update and patch actions.
+	Definition string `json:"definition,omitempty"`
+}
+
+// String returns a single line description of the entry.
+func (me *ManifestEntry) String() string {
+	if me.Action == ExecAction {
+		return fmt.Sprintf("%s %s", me.Action, me.Name)
+	}
+	target := me.Name
+	if me.Namespace != "" {
+		target = fmt.Sprintf("%s/%s", me.Namespace, me.Name)
+	}
+	kind := me.Kind
+	if kind == "" {
+		kind = me.Resource
+	}
+	if me.APIVersion != "" {
+		kind = fmt.Sprintf("%s/%s", me.APIVersion, kind)
+	}
+	return fmt.Sprintf("%s %s %s", me.Action, kind, target)
+}
+
+// Manifest structure with the changes recorded during a dry-run execution. It is safe for concurrent use as
+// commands may run in parallel.
+type Manifest struct {
+	sync.Mutex
+	entries []ManifestEntry
+}
+
+// NewManifest creates an empty Manifest.
+func NewManifest() *Manifest {
+	return &Manifest{entries: make([]ManifestEntry, 0)}
+}
+
+// Record adds a new entry to the manifest.
+func (m *Manifest) Record(entry ManifestEntry) {
+	m.Lock()
+	defer m.Unlock()
+	m.entries = append(m.entries, entry)
+}
+
+// RecordCommand adds an exec entry for a command that is skipped in dry-run mode.
+func (m *Manifest) RecordCommand(cmd Command) {
+	m.Record(ManifestEntry{
+		CommandID: cmd.ID(),
+		Action:    ExecAction,
+		Name:      cmd.UserString(),
+	})
+}
+
+// Entries retrieves a copy of the recorded entries in order.
+func (m *Manifest) Entries() []ManifestEntry {
+	m.Lock()
+	defer m.Unlock()
+	result := make([]ManifestEntry, len(m.entries))
+	copy(result, m.entries)
+	return result
+}
+
+// Contains checks if a change has been recorded for a given object.
+func (m *Manifest) Contains(action ManifestAction, kind string, namespace string, name string) bool {
+	m.Lock()
+	defer m.Unlock()
+	for _, entry := range m.entries {
+		if entry.Action == action && entry.Kind == kind && entry.Namespace == namespace && entry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns the number of entries of a given action.
+func (m *Manifest) Count(action ManifestAction) int {
+	m.Lock()
+	defer m.Unlock()
+	count := 0
+	for _, entry := range m.entries {
+		if entry.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// String returns the manifest as a YAML stream. Each entry starts with a comment describing the change followed by
+// the definition of the object, if any.
+func (m *Manifest) String() string {
+	entries := m.Entries()
+	var sb strings.Builder
+	for index, entry := range entries {
+		if index > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.WriteString(fmt.Sprintf("# %s\n", entry.String()))
+		if entry.Definition != "" {
+			sb.WriteString(entry.Definition)
+			if !strings.HasSuffix(entry.Definition, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// DryRunCommand is implemented by the commands that support the dry-run mode. Those commands either have no side
+// effects, or record their changes in the manifest of the context. The remaining commands are skipped.
+type DryRunCommand interface {
+	SupportsDryRun() bool
+}
+
+// SupportsDryRun checks if a command can be executed in dry-run mode.
+func SupportsDryRun(cmd Command) bool {
+	target, ok := cmd.(DryRunCommand)
+	return ok && target.SupportsDryRun()
+}
+
+// manifestKey is the key of the manifest in the execution context.
+type manifestKey struct{}
+
+// WithManifest returns a context that executes the commands in dry-run mode recording their changes in a manifest.
+func WithManifest(ctx context.Context, manifest *Manifest) context.Context {
+	return context.WithValue(ctx, manifestKey{}, manifest)
+}
+
+// ManifestFromContext returns the manifest of a dry-run execution, or nil if the changes must be applied.
+func ManifestFromContext(ctx context.Context) *Manifest {
+	if ctx == nil {
+		return nil
+	}
+	manifest, _ := ctx.Value(manifestKey{}).(*Manifest)
+	return manifest
+}
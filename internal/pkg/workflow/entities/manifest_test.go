@@ -0,0 +1,70 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package entities
+
+import (
+	"context"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Manifest", func() {
+
+	ginkgo.It("must record the entries in order", func() {
+		manifest := NewManifest()
+		manifest.Record(ManifestEntry{Action: CreateAction, APIVersion: "v1", Kind: "Namespace", Name: "nalej",
+			Definition: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: nalej\n"})
+		manifest.Record(ManifestEntry{Action: DeleteAction, APIVersion: "v1", Kind: "Service", Namespace: "nalej", Name: "dns"})
+		manifest.Record(ManifestEntry{Action: ExecAction, Name: "Installing Kubernetes"})
+
+		entries := manifest.Entries()
+		gomega.Expect(len(entries)).To(gomega.Equal(3))
+		gomega.Expect(entries[0].String()).To(gomega.Equal("create v1/Namespace nalej"))
+		gomega.Expect(entries[1].String()).To(gomega.Equal("delete v1/Service nalej/dns"))
+		gomega.Expect(entries[2].String()).To(gomega.Equal("exec Installing Kubernetes"))
+		gomega.Expect(manifest.Count(CreateAction)).To(gomega.Equal(1))
+		gomega.Expect(manifest.Count(UpdateAction)).To(gomega.Equal(0))
+		gomega.Expect(manifest.Contains(CreateAction, "Namespace", "", "nalej")).To(gomega.BeTrue())
+		gomega.Expect(manifest.Contains(DeleteAction, "Namespace", "", "nalej")).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("must describe the deleted resources without a kind", func() {
+		entry := ManifestEntry{Action: DeleteAction, APIVersion: "rbac.authorization.k8s.io/v1", Resource: "clusterroles", Name: "prometheus"}
+		gomega.Expect(entry.String()).To(gomega.Equal("delete rbac.authorization.k8s.io/v1/clusterroles prometheus"))
+		entry.Kind = "ClusterRole"
+		gomega.Expect(entry.String()).To(gomega.Equal("delete rbac.authorization.k8s.io/v1/ClusterRole prometheus"))
+	})
+
+	ginkgo.It("must render a YAML stream", func() {
+		manifest := NewManifest()
+		manifest.Record(ManifestEntry{Action: CreateAction, APIVersion: "v1", Kind: "Namespace", Name: "nalej",
+			Definition: "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: nalej"})
+		manifest.Record(ManifestEntry{Action: ExecAction, Name: "Installing Kubernetes"})
+		expected := "# create v1/Namespace nalej\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: nalej\n" +
+			"---\n# exec Installing Kubernetes\n"
+		gomega.Expect(manifest.String()).To(gomega.Equal(expected))
+	})
+
+	ginkgo.It("must be carried by the context", func() {
+		gomega.Expect(ManifestFromContext(context.Background())).To(gomega.BeNil())
+		manifest := NewManifest()
+		ctx := WithManifest(context.Background(), manifest)
+		gomega.Expect(ManifestFromContext(ctx)).To(gomega.Equal(manifest))
+	})
+
+})
@@ -147,3 +147,21 @@ const DeletePodSecurityPolicy = "deletePodSecurityPolicy"
 
 // InstallIstio command to run the istio installation process.
 const InstallIstio = "installIstio"
+
+// WaitFor command to wait until a set of Kubernetes resources satisfy a condition.
+const WaitFor = "waitFor"
+
+// ApplyManifest command to create a set of Kubernetes objects defined in the workflow.
+const ApplyManifest = "applyManifest"
+
+// DeleteResources command to delete a set of Kubernetes resources selected by name or labels.
+const DeleteResources = "deleteResources"
+
+// DeleteOwnedResources command to delete all the Kubernetes resources created by the installer.
+const DeleteOwnedResources = "deleteOwnedResources"
+
+// WriteInventory command to store the inventory of the installed objects in the target cluster.
+const WriteInventory = "writeInventory"
+
+// Plugin command to execute an external executable of the plugins directory.
+const Plugin = "plugin"
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the ownership of the objects created by the installer
+//
+// Every object created in Kubernetes is labelled as managed by the installer with the version of the installer, the
+// cluster and the component, and annotated with the request that created it. The uninstall uses those labels to
+// find the objects owned by the installer.
+
+package entities
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ManagedByLabel is the label that identifies the objects created by the installer.
+const ManagedByLabel = "app.kubernetes.io/managed-by"
+
+// ManagedByValue is the value of the ManagedByLabel in the objects created by the installer.
+const ManagedByValue = "nalej-installer"
+
+// InstallerVersionLabel is the label with the version of the installer that created the object.
+const InstallerVersionLabel = "installer.nalej.com/version"
+
+// ClusterIDLabel is the label with the identifier of the cluster where the object is installed.
+const ClusterIDLabel = "installer.nalej.com/cluster-id"
+
+// ComponentLabel is the label with the component that contains the object.
+const ComponentLabel = "installer.nalej.com/component"
+
+// RequestIDAnnotation is the annotation with the identifier of the request that created the object.
+const RequestIDAnnotation = "installer.nalej.com/request-id"
+
+// MaxLabelValueLength is the maximum length of a label value in Kubernetes.
+const MaxLabelValueLength = 63
+
+// invalidLabelChars matches the characters that are not allowed in a label value.
+var invalidLabelChars = regexp.MustCompile("[^A-Za-z0-9_.-]+")
+
+// Ownership structure with the information that identifies the objects created by an install.
+type Ownership struct {
+	// InstallerVersion with the version of the installer.
+	InstallerVersion string `json:"installer_version"`
+	// RequestID with the identifier of the install request.
+	RequestID string `json:"request_id"`
+	// ClusterID with the identifier of the cluster being installed.
+	ClusterID string `json:"cluster_id"`
+}
+
+// NewOwnership creates a new Ownership.
+func NewOwnership(installerVersion string, requestID string, clusterID string) *Ownership {
+	return &Ownership{
+		InstallerVersion: installerVersion,
+		RequestID:        requestID,
+		ClusterID:        clusterID,
+	}
+}
+
+// Labels returns the labels of an object of a given component. The empty values are not included.
+func (o *Ownership) Labels(component string) map[string]string {
+	labels := map[string]string{ManagedByLabel: ManagedByValue}
+	addLabel(labels, InstallerVersionLabel, o.InstallerVersion)
+	addLabel(labels, ClusterIDLabel, o.ClusterID)
+	addLabel(labels, ComponentLabel, component)
+	return labels
+}
+
+// Annotations returns the annotations of an object. The empty values are not included.
+func (o *Ownership) Annotations() map[string]string {
+	annotations := make(map[string]string, 0)
+	if o.RequestID != "" {
+		annotations[RequestIDAnnotation] = o.RequestID
+	}
+	return annotations
+}
+
+func addLabel(labels map[string]string, key string, value string) {
+	sanitized := LabelValue(value)
+	if sanitized != "" {
+		labels[key] = sanitized
+	}
+}
+
+// LabelValue transforms a string into a valid label value replacing the invalid characters with dashes.
+func LabelValue(value string) string {
+	result := invalidLabelChars.ReplaceAllString(value, "-")
+	if len(result) > MaxLabelValueLength {
+		result = result[:MaxLabelValueLength]
+	}
+	// Label values must begin and end with an alphanumeric character.
+	return strings.Trim(result, "-_.")
+}
+
+// OwnedSelector returns the label selector of the objects created by the installer, optionally restricted to a
+// cluster.
+func OwnedSelector(clusterID string) string {
+	selector := fmt.Sprintf("%s=%s", ManagedByLabel, ManagedByValue)
+	if value := LabelValue(clusterID); value != "" {
+		selector = fmt.Sprintf("%s,%s=%s", selector, ClusterIDLabel, value)
+	}
+	return selector
+}
+
+// ownershipKey is the key of the ownership in the execution context.
+type ownershipKey struct{}
+
+// WithOwnership returns a context whose commands label the objects they create with the given ownership.
+func WithOwnership(ctx context.Context, ownership *Ownership) context.Context {
+	return context.WithValue(ctx, ownershipKey{}, ownership)
+}
+
+// OwnershipFromContext returns the ownership of the objects created in the execution, or nil if not set.
+func OwnershipFromContext(ctx context.Context) *Ownership {
+	ownership, _ := ctx.Value(ownershipKey{}).(*Ownership)
+	return ownership
+}
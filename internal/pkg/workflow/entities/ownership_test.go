@@ -0,0 +1,62 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package entities
+
+import (
+	"context"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = ginkgo.Describe("Ownership", func() {
+
+	ginkgo.It("must generate the labels and annotations", func() {
+		ownership := NewOwnership("v0.4.0+dev", "request", "cluster")
+		gomega.Expect(ownership.Labels("ingress")).To(gomega.Equal(map[string]string{
+			ManagedByLabel:        ManagedByValue,
+			InstallerVersionLabel: "v0.4.0-dev",
+			ClusterIDLabel:        "cluster",
+			ComponentLabel:        "ingress",
+		}))
+		gomega.Expect(ownership.Annotations()).To(gomega.Equal(map[string]string{RequestIDAnnotation: "request"}))
+		empty := NewOwnership("", "", "")
+		gomega.Expect(empty.Labels("")).To(gomega.Equal(map[string]string{ManagedByLabel: ManagedByValue}))
+		gomega.Expect(empty.Annotations()).To(gomega.BeEmpty())
+	})
+
+	ginkgo.It("must generate valid label values", func() {
+		gomega.Expect(LabelValue("system:nginx ingress")).To(gomega.Equal("system-nginx-ingress"))
+		gomega.Expect(LabelValue("-value-")).To(gomega.Equal("value"))
+		gomega.Expect(len(LabelValue(strings.Repeat("a", 100)))).To(gomega.Equal(MaxLabelValueLength))
+	})
+
+	ginkgo.It("must select the owned objects", func() {
+		gomega.Expect(OwnedSelector("")).To(gomega.Equal("app.kubernetes.io/managed-by=nalej-installer"))
+		gomega.Expect(OwnedSelector("cluster")).To(gomega.Equal(
+			"app.kubernetes.io/managed-by=nalej-installer,installer.nalej.com/cluster-id=cluster"))
+	})
+
+	ginkgo.It("must be carried by the context", func() {
+		gomega.Expect(OwnershipFromContext(context.Background())).To(gomega.BeNil())
+		ownership := NewOwnership("v1", "request", "cluster")
+		ctx := WithOwnership(context.Background(), ownership)
+		gomega.Expect(OwnershipFromContext(ctx)).To(gomega.Equal(ownership))
+	})
+
+})
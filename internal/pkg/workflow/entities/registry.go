@@ -0,0 +1,251 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the registry of the commands that can be used in a workflow
+//
+// Each command package registers the definitions of its commands when it is loaded, with the JSON constructor and
+// the documentation of the parameters. The command parser resolves the commands through the registry, so a new
+// command of the installer only needs to be registered in its package. The registry is internal to the installer:
+// external steps are added with the plugin command instead.
+
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CommandFactory creates a command from its JSON definition.
+type CommandFactory func(raw []byte) (*Command, derrors.Error)
+
+// CommandParameter structure with the documentation of a parameter of a command.
+type CommandParameter struct {
+	// Name of the parameter in the JSON definition of the command.
+	Name string `json:"name"`
+	// Type of the value, such as string, bool or list of string.
+	Type string `json:"type"`
+	// Description of the parameter.
+	Description string `json:"description,omitempty"`
+}
+
+// CommandDefinition structure with a command that can be used in a workflow.
+type CommandDefinition struct {
+	// Type of the command.
+	Type CommandType `json:"type"`
+	// Name of the command in the workflow.
+	Name string `json:"name"`
+	// Description of the command.
+	Description string `json:"description"`
+	// Parameters with the specific parameters of the command.
+	Parameters []CommandParameter `json:"parameters"`
+	// FromJSON creates the command from its JSON definition.
+	FromJSON CommandFactory `json:"-"`
+}
+
+// CommonParameters contains the parameters accepted by all the commands.
+var CommonParameters = []CommandParameter{
+	{Name: "id", Type: "string", Description: "Identifier of the command referenced by the dependsOn lists"},
+	{Name: "dependsOn", Type: "list of string", Description: "Commands that must finish before this one; the previous command if not set"},
+	{Name: "timeout", Type: "duration", Description: "Maximum duration of the command, such as 5m"},
+	{Name: "retries", Type: "int", Description: "Number of retries of a synchronous command that fails"},
+	{Name: "backoff", Type: "duration or object", Description: "Delay between retries, or an object with delay, factor and max"},
+	{Name: "retryOn", Type: "list of string", Description: "Results that are retried: error and/or failure"},
+	{Name: "undo", Type: "object", Description: "Command that reverts this one when the workflow is rolled back"},
+}
+
+// Help returns the documentation of the command with a line per parameter.
+func (cd *CommandDefinition) Help() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s (%s)\n", cd.Name, cd.Type))
+	if cd.Description != "" {
+		sb.WriteString(fmt.Sprintf("  %s\n", cd.Description))
+	}
+	for _, parameter := range cd.Parameters {
+		sb.WriteString(fmt.Sprintf("    %-24s %-26s %s\n", parameter.Name, parameter.Type, parameter.Description))
+	}
+	return sb.String()
+}
+
+// ParametersOf obtains the parameters of a command from the JSON fields of its structure, including the embedded
+// ones. The fields of the GenericCommand and the actions of the GenericAsyncCommand are not included as they are not
+// specific to the command. The fields without a json tag are internal to the command, such as the clients.
+//   params:
+//     command The structure of the command, or a pointer to it.
+//     descriptions The descriptions of the parameters indexed by name.
+//   returns:
+//     The parameters in the order of the fields.
+func ParametersOf(command interface{}, descriptions map[string]string) []CommandParameter {
+	result := make([]CommandParameter, 0)
+	seen := make(map[string]bool, 0)
+	collectParameters(reflect.TypeOf(command), descriptions, seen, &result)
+	return result
+}
+
+var genericCommandType = reflect.TypeOf(GenericCommand{})
+var genericAsyncCommandType = reflect.TypeOf(GenericAsyncCommand{})
+
+func collectParameters(t reflect.Type, descriptions map[string]string, seen map[string]bool, result *[]CommandParameter) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		if field.Type == genericCommandType || field.Type == genericAsyncCommandType {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			collectParameters(field.Type, descriptions, seen, result)
+			continue
+		}
+		if field.PkgPath != "" || tag == "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		*result = append(*result, CommandParameter{Name: name, Type: parameterType(field.Type), Description: descriptions[name]})
+	}
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// parameterType returns the name of the type of a parameter as written in the JSON definition.
+func parameterType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == rawMessageType {
+		return "object"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return "list of " + parameterType(t.Elem())
+	case reflect.Map:
+		return fmt.Sprintf("map of %s to %s", parameterType(t.Key()), parameterType(t.Elem()))
+	default:
+		return "object"
+	}
+}
+
+// CommandRegistry structure with the commands available to the workflows. It is safe for concurrent use.
+type CommandRegistry struct {
+	sync.RWMutex
+	definitions map[CommandType]map[string]*CommandDefinition
+}
+
+// NewCommandRegistry creates an empty CommandRegistry.
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{definitions: make(map[CommandType]map[string]*CommandDefinition, 0)}
+}
+
+// DefaultRegistry is the registry used by the command parser. The command packages register their commands in it
+// when they are loaded.
+var DefaultRegistry = NewCommandRegistry()
+
+// RegisterCommand adds a command to the DefaultRegistry. It panics if the definition is not valid, as it is called
+// when the command packages are loaded.
+func RegisterCommand(definition CommandDefinition) {
+	if err := DefaultRegistry.Register(definition); err != nil {
+		panic(err.DebugReport())
+	}
+}
+
+// Register adds a command to the registry.
+//   params:
+//     definition The definition of the command.
+//   returns:
+//     An error if the definition is incomplete or the command is already registered.
+func (cr *CommandRegistry) Register(definition CommandDefinition) derrors.Error {
+	if definition.Name == "" || definition.FromJSON == nil {
+		return derrors.NewInvalidArgumentError(errors.InvalidCommandDefinition).WithParams(definition.Type, definition.Name)
+	}
+	if definition.Type != SyncCommandType && definition.Type != AsyncCommandType {
+		return derrors.NewInvalidArgumentError(errors.UnsupportedCommandType).WithParams(definition.Type, definition.Name)
+	}
+	cr.Lock()
+	defer cr.Unlock()
+	byName, found := cr.definitions[definition.Type]
+	if !found {
+		byName = make(map[string]*CommandDefinition, 0)
+		cr.definitions[definition.Type] = byName
+	}
+	if _, exists := byName[definition.Name]; exists {
+		return derrors.NewAlreadyExistsError(errors.DuplicatedCommand).WithParams(definition.Type, definition.Name)
+	}
+	toAdd := definition
+	byName[definition.Name] = &toAdd
+	return nil
+}
+
+// Get retrieves the definition of a command.
+//   params:
+//     commandType The type of the command.
+//     name The name of the command.
+//   returns:
+//     The definition, or nil if the command is not registered.
+func (cr *CommandRegistry) Get(commandType CommandType, name string) *CommandDefinition {
+	cr.RLock()
+	defer cr.RUnlock()
+	definition, found := cr.definitions[commandType][name]
+	if !found {
+		return nil
+	}
+	return definition
+}
+
+// List retrieves the registered commands sorted by type and name.
+func (cr *CommandRegistry) List() []CommandDefinition {
+	cr.RLock()
+	defer cr.RUnlock()
+	result := make([]CommandDefinition, 0)
+	for _, byName := range cr.definitions {
+		for _, definition := range byName {
+			result = append(result, *definition)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type > result[j].Type
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
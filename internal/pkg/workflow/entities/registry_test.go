@@ -0,0 +1,91 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package entities
+
+import (
+	"encoding/json"
+	"github.com/nalej/derrors"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+)
+
+// registryTestCommand is a command structure used to check the parameters obtained from the JSON fields.
+type registryTestCommand struct {
+	GenericSyncCommand
+	registryTestEmbedded
+	Path     string            `json:"path"`
+	Retries  *int              `json:"retries,omitempty"`
+	Names    []string          `json:"names"`
+	Params   map[string]string `json:"params"`
+	Objects  []json.RawMessage `json:"objects"`
+	Internal string            `json:"-"`
+	Client   *Manifest
+	hidden   string
+}
+
+type registryTestEmbedded struct {
+	KubeConfigPath string `json:"kubeConfigPath"`
+}
+
+func registryTestFactory(raw []byte) (*Command, derrors.Error) {
+	return nil, nil
+}
+
+var _ = ginkgo.Describe("Command registry", func() {
+
+	ginkgo.It("must obtain the parameters of a command", func() {
+		parameters := ParametersOf(&registryTestCommand{}, map[string]string{"path": "Path of the file"})
+		gomega.Expect(parameters).To(gomega.Equal([]CommandParameter{
+			{Name: "kubeConfigPath", Type: "string"},
+			{Name: "path", Type: "string", Description: "Path of the file"},
+			{Name: "retries", Type: "int"},
+			{Name: "names", Type: "list of string"},
+			{Name: "params", Type: "map of string to string"},
+			{Name: "objects", Type: "list of object"},
+		}))
+	})
+
+	ginkgo.It("must register and retrieve commands", func() {
+		registry := NewCommandRegistry()
+		err := registry.Register(CommandDefinition{Type: SyncCommandType, Name: "b", FromJSON: registryTestFactory})
+		gomega.Expect(err).To(gomega.BeNil())
+		err = registry.Register(CommandDefinition{Type: AsyncCommandType, Name: "a", FromJSON: registryTestFactory})
+		gomega.Expect(err).To(gomega.BeNil())
+		err = registry.Register(CommandDefinition{Type: SyncCommandType, Name: "a", FromJSON: registryTestFactory})
+		gomega.Expect(err).To(gomega.BeNil())
+
+		gomega.Expect(registry.Get(SyncCommandType, "b")).ToNot(gomega.BeNil())
+		gomega.Expect(registry.Get(AsyncCommandType, "b")).To(gomega.BeNil())
+		names := make([]string, 0)
+		for _, definition := range registry.List() {
+			names = append(names, string(definition.Type)+"/"+definition.Name)
+		}
+		gomega.Expect(names).To(gomega.Equal([]string{"sync/a", "sync/b", "async/a"}))
+	})
+
+	ginkgo.It("must reject invalid and duplicated commands", func() {
+		registry := NewCommandRegistry()
+		gomega.Expect(registry.Register(CommandDefinition{Type: SyncCommandType, Name: "a"})).ToNot(gomega.BeNil())
+		gomega.Expect(registry.Register(CommandDefinition{Type: SyncCommandType, FromJSON: registryTestFactory})).ToNot(gomega.BeNil())
+		gomega.Expect(registry.Register(CommandDefinition{Type: "other", Name: "a", FromJSON: registryTestFactory})).ToNot(gomega.BeNil())
+		gomega.Expect(registry.Register(CommandDefinition{Type: SyncCommandType, Name: "a", FromJSON: registryTestFactory})).To(gomega.BeNil())
+		gomega.Expect(registry.Register(CommandDefinition{Type: SyncCommandType, Name: "a", FromJSON: registryTestFactory})).ToNot(gomega.BeNil())
+		gomega.Expect(registry.List()).To(gomega.HaveLen(1))
+	})
+
+})
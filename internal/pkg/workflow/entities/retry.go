@@ -0,0 +1,250 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the retry policy definition
+//
+// Any command may define a retry policy with the following fields:
+//
+// {"type":"sync", "name": "...", "retries": 3, "backoff": "10s", "retryOn": ["Unavailable", "failure"]}
+//
+// The backoff accepts a fixed delay, or an exponential definition:
+//
+// "backoff": {"delay": "1s", "factor": 2, "max": "1m"}
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"time"
+)
+
+// RetryOnError retries the execution if the command returns any error.
+const RetryOnError = "error"
+
+// RetryOnFailure retries the execution if the command returns an unsuccessful result.
+const RetryOnFailure = "failure"
+
+// Backoff structure with the delay between attempts.
+type Backoff struct {
+	// Delay before the first retry.
+	Delay time.Duration
+	// Factor applied to the delay after each retry. A factor of 0 or 1 produces a fixed delay.
+	Factor float64
+	// Max delay between attempts. No limit is applied if zero.
+	Max time.Duration
+}
+
+// NewConstantBackoff creates a Backoff with a fixed delay.
+func NewConstantBackoff(delay time.Duration) Backoff {
+	return Backoff{delay, 1, 0}
+}
+
+// NewExponentialBackoff creates a Backoff whose delay is multiplied by a factor after each retry.
+func NewExponentialBackoff(delay time.Duration, factor float64, max time.Duration) Backoff {
+	return Backoff{delay, factor, max}
+}
+
+// backoffFromJSON structure with the exponential definition of a backoff.
+type backoffFromJSON struct {
+	Delay  string  `json:"delay"`
+	Factor float64 `json:"factor"`
+	Max    string  `json:"max"`
+}
+
+// UnmarshalJSON parses a backoff defined as a duration string or as an exponential definition.
+func (b *Backoff) UnmarshalJSON(data []byte) error {
+	var delay string
+	if err := json.Unmarshal(data, &delay); err == nil {
+		parsed, err := time.ParseDuration(delay)
+		if err != nil {
+			return err
+		}
+		*b = NewConstantBackoff(parsed)
+		return nil
+	}
+	var bfj backoffFromJSON
+	if err := json.Unmarshal(data, &bfj); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(bfj.Delay)
+	if err != nil {
+		return err
+	}
+	max := time.Duration(0)
+	if bfj.Max != "" {
+		max, err = time.ParseDuration(bfj.Max)
+		if err != nil {
+			return err
+		}
+	}
+	factor := bfj.Factor
+	if factor == 0 {
+		factor = 1
+	}
+	*b = NewExponentialBackoff(parsed, factor, max)
+	return nil
+}
+
+// Next returns the delay to wait before a given retry, starting at zero.
+func (b *Backoff) Next(retry int) time.Duration {
+	if b.Factor <= 1 {
+		return b.Delay
+	}
+	delay := float64(b.Delay)
+	for i := 0; i < retry; i++ {
+		delay = delay * b.Factor
+		if b.Max > 0 && delay >= float64(b.Max) {
+			return b.Max
+		}
+	}
+	return time.Duration(delay)
+}
+
+// String returns a string representation
+func (b *Backoff) String() string {
+	if b.Factor <= 1 {
+		return b.Delay.String()
+	}
+	return fmt.Sprintf("%s x%.1f max %s", b.Delay.String(), b.Factor, b.Max.String())
+}
+
+// RetryPolicy structure that defines how the execution of a command is repeated if it does not succeed.
+type RetryPolicy struct {
+	// Retries is the number of attempts after the first execution.
+	Retries int `json:"retries"`
+	// Backoff with the delay between attempts.
+	Backoff Backoff `json:"backoff"`
+	// RetryOn with the conditions that trigger a new attempt. The values are RetryOnError, RetryOnFailure or
+	// the type of a derrors.Error. Any error or unsuccessful result is retried if empty.
+	RetryOn []string `json:"retryOn"`
+}
+
+// NewRetryPolicy creates a RetryPolicy.
+func NewRetryPolicy(retries int, backoff Backoff, retryOn ...string) *RetryPolicy {
+	return &RetryPolicy{retries, backoff, retryOn}
+}
+
+// Validate checks the values of the policy.
+func (rp *RetryPolicy) Validate() derrors.Error {
+	if rp.Retries < 0 {
+		return derrors.NewInvalidArgumentError(errors.InvalidRetryPolicy).WithParams("retries", rp.Retries)
+	}
+	if rp.Backoff.Delay < 0 || rp.Backoff.Max < 0 || (rp.Backoff.Factor != 0 && rp.Backoff.Factor < 1) {
+		return derrors.NewInvalidArgumentError(errors.InvalidRetryPolicy).WithParams("backoff", rp.Backoff.String())
+	}
+	for _, condition := range rp.RetryOn {
+		if condition == "" {
+			return derrors.NewInvalidArgumentError(errors.InvalidRetryPolicy).WithParams("retryOn", rp.RetryOn)
+		}
+	}
+	return nil
+}
+
+// ShouldRetry checks if the outcome of an attempt matches the retry conditions of the policy.
+func (rp *RetryPolicy) ShouldRetry(result *CommandResult, err derrors.Error) bool {
+	if err == nil && result != nil && result.Success {
+		return false
+	}
+	if len(rp.RetryOn) == 0 {
+		return true
+	}
+	reason := err
+	if reason == nil && result != nil {
+		reason = result.Error
+	}
+	for _, condition := range rp.RetryOn {
+		switch condition {
+		case RetryOnError:
+			if err != nil {
+				return true
+			}
+		case RetryOnFailure:
+			if err == nil {
+				return true
+			}
+		default:
+			if reason != nil && string(reason.Type()) == condition {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Run executes an attempt function until it succeeds, the outcome does not match the retry conditions, the
+// retries are exhausted, or the context is done. The outcome of the last attempt is returned.
+//
+//	params:
+//	  ctx The context of the execution.
+//	  attempt The function to be executed.
+//	  onRetry Optional function called before waiting for a new attempt.
+//	returns:
+//	  The result of the last attempt.
+//	  The error of the last attempt, or the context error if it was done while waiting.
+func (rp *RetryPolicy) Run(ctx context.Context,
+	attempt func() (*CommandResult, derrors.Error),
+	onRetry func(retry int, delay time.Duration)) (*CommandResult, derrors.Error) {
+	for retry := 0; ; retry++ {
+		result, err := attempt()
+		if ctx.Err() != nil || retry >= rp.Retries || !rp.ShouldRetry(result, err) {
+			return result, err
+		}
+		delay := rp.Backoff.Next(retry)
+		if onRetry != nil {
+			onRetry(retry+1, delay)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ContextError(ctx)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Poll executes a condition function until it returns true applying the retry policy.
+//
+//	returns:
+//	  The context error if the context is done before the condition is satisfied.
+//	  An error if the condition is not satisfied once the retries are exhausted.
+func (rp *RetryPolicy) Poll(ctx context.Context, condition func() (bool, derrors.Error)) derrors.Error {
+	result, err := rp.Run(ctx, func() (*CommandResult, derrors.Error) {
+		done, err := condition()
+		if err != nil {
+			return nil, err
+		}
+		return NewCommandResultNoShow(done, "", nil), nil
+	}, nil)
+	if ctx.Err() != nil {
+		return ContextError(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return derrors.NewDeadlineExceededError(errors.RetriesExhausted).WithParams(rp.Retries)
+	}
+	return nil
+}
+
+// String returns a string representation
+func (rp *RetryPolicy) String() string {
+	return fmt.Sprintf("retries: %d backoff: %s retryOn: %v", rp.Retries, rp.Backoff.String(), rp.RetryOn)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"time"
+)
+
+var _ = ginkgo.Describe("Retry policy", func() {
+
+	ginkgo.It("must parse a fixed backoff", func() {
+		policy := &RetryPolicy{}
+		err := json.Unmarshal([]byte(`{"retries": 3, "backoff": "10s"}`), policy)
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(policy.Validate()).To(gomega.BeNil())
+		gomega.Expect(policy.Backoff.Next(0)).To(gomega.Equal(time.Second * 10))
+		gomega.Expect(policy.Backoff.Next(5)).To(gomega.Equal(time.Second * 10))
+	})
+
+	ginkgo.It("must parse an exponential backoff", func() {
+		policy := &RetryPolicy{}
+		err := json.Unmarshal([]byte(`{"retries": 5, "backoff": {"delay": "1s", "factor": 2, "max": "5s"}}`), policy)
+		gomega.Expect(err).To(gomega.Succeed())
+		gomega.Expect(policy.Validate()).To(gomega.BeNil())
+		gomega.Expect(policy.Backoff.Next(0)).To(gomega.Equal(time.Second))
+		gomega.Expect(policy.Backoff.Next(2)).To(gomega.Equal(time.Second * 4))
+		gomega.Expect(policy.Backoff.Next(3)).To(gomega.Equal(time.Second * 5))
+	})
+
+	ginkgo.It("must only retry on the selected conditions", func() {
+		policy := NewRetryPolicy(1, NewConstantBackoff(0), "Unavailable")
+		gomega.Expect(policy.ShouldRetry(nil, derrors.NewUnavailableError("unavailable"))).To(gomega.BeTrue())
+		gomega.Expect(policy.ShouldRetry(nil, derrors.NewInvalidArgumentError("invalid"))).To(gomega.BeFalse())
+		gomega.Expect(policy.ShouldRetry(NewErrCommand("", nil), nil)).To(gomega.BeFalse())
+		gomega.Expect(policy.ShouldRetry(NewSuccessCommand([]byte("")), nil)).To(gomega.BeFalse())
+		failure := NewRetryPolicy(1, NewConstantBackoff(0), RetryOnFailure)
+		gomega.Expect(failure.ShouldRetry(NewErrCommand("", nil), nil)).To(gomega.BeTrue())
+		gomega.Expect(failure.ShouldRetry(nil, derrors.NewUnavailableError("unavailable"))).To(gomega.BeFalse())
+	})
+
+	ginkgo.It("must repeat the attempts until success", func() {
+		policy := NewRetryPolicy(3, NewConstantBackoff(time.Millisecond))
+		attempts := 0
+		retries := 0
+		result, err := policy.Run(context.Background(), func() (*CommandResult, derrors.Error) {
+			attempts++
+			if attempts < 3 {
+				return nil, derrors.NewUnavailableError("unavailable")
+			}
+			return NewSuccessCommand([]byte("done")), nil
+		}, func(retry int, delay time.Duration) {
+			retries++
+		})
+		gomega.Expect(err).To(gomega.BeNil())
+		gomega.Expect(result.Success).To(gomega.BeTrue())
+		gomega.Expect(attempts).To(gomega.Equal(3))
+		gomega.Expect(retries).To(gomega.Equal(2))
+	})
+
+	ginkgo.It("must fail once the retries are exhausted", func() {
+		policy := NewRetryPolicy(2, NewConstantBackoff(time.Millisecond))
+		attempts := 0
+		err := policy.Poll(context.Background(), func() (bool, derrors.Error) {
+			attempts++
+			return false, nil
+		})
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		gomega.Expect(attempts).To(gomega.Equal(3))
+	})
+
+	ginkgo.It("must stop waiting when the context is canceled", func() {
+		policy := NewRetryPolicy(10, NewConstantBackoff(time.Minute))
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(time.Millisecond * 100)
+			cancel()
+		}()
+		start := time.Now()
+		err := policy.Poll(ctx, func() (bool, derrors.Error) {
+			return false, nil
+		})
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		gomega.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second*5))
+	})
+
+	ginkgo.It("must return the context error when the context is canceled between attempts", func() {
+		policy := NewRetryPolicy(10, NewConstantBackoff(time.Millisecond))
+		ctx, cancel := context.WithCancel(context.Background())
+		err := policy.Poll(ctx, func() (bool, derrors.Error) {
+			cancel()
+			return false, nil
+		})
+		gomega.Expect(err).ToNot(gomega.BeNil())
+		gomega.Expect(err.Error()).To(gomega.Equal(errors.CommandCanceled))
+	})
+})
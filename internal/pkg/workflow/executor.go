@@ -18,11 +18,14 @@
 package workflow
 
 import (
+	"context"
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/rs/zerolog/log"
-	"strings"
+	"sync"
+	"time"
 
+	"github.com/nalej/installer/internal/pkg/workflow/commands"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/nalej/installer/internal/pkg/workflow/handler"
 
@@ -49,6 +52,38 @@ type Executor struct {
 	State            WorkflowState `json:"state"`
 	workflowCallback func(workflowID string, error derrors.Error, state WorkflowState)
 	Parameters       map[string]string `json:"parameters"`
+	// Checkpoints with the commands that have been successfully executed.
+	Checkpoints        []Checkpoint `json:"checkpoints"`
+	checkpointListener func(checkpoint Checkpoint)
+	// ctx is passed to the running commands, and cancel aborts them.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// rollbackOnFailure determines if the undo commands of the finished commands are executed when the workflow fails.
+	rollbackOnFailure bool
+	// rollbackReason contains the error that triggered the rollback.
+	rollbackReason derrors.Error
+	// rollbackPending is set when the workflow fails while other commands are running. The rollback starts once
+	// they finish, so the undo commands do not run at the same time as the commands they revert.
+	rollbackPending bool
+	// ExecutionEvents contains the events of all the commands in the workflow. The ExecutionLog is derived from them.
+	ExecutionEvents []entities.ExecutionEvent `json:"executionEvents"`
+	eventListener   func(event entities.ExecutionEvent)
+	// eventLock protects the execution events and log as commands report them from different goroutines.
+	eventLock sync.Mutex
+	// maxParallelism limits the number of commands running at the same time. Zero means no limit.
+	maxParallelism int
+	// schedLock protects the scheduling state as commands finish from different goroutines.
+	schedLock sync.Mutex
+	// running contains the time when each running command was launched indexed by position.
+	running map[int]time.Time
+	// asyncWatches contains the functions that stop the timeout watch of the running asynchronous commands.
+	asyncWatches map[int]func() bool
+	// dryRun contains the manifest where the changes are recorded when the workflow is executed in dry-run mode.
+	dryRun *entities.Manifest
+	// ownership contains the labels of the objects created by the commands.
+	ownership *entities.Ownership
+	// inventory receives the objects applied by the commands.
+	inventory *entities.Inventory
 }
 
 // NewWorkflowExecutor creates a new executor
@@ -59,7 +94,13 @@ func NewWorkflowExecutor(workflow *Workflow,
 	workflowCallback func(workflowID string, error derrors.Error, state WorkflowState)) *Executor {
 	return &Executor{workflow, handler.GetCommandHandler(),
 		0, make([]string, 0), nil,
-		InitState, workflowCallback, make(map[string]string, 0)}
+		InitState, workflowCallback, make(map[string]string, 0),
+		make([]Checkpoint, 0), nil,
+		context.Background(), func() {},
+		false, nil, false,
+		make([]entities.ExecutionEvent, 0), nil,
+		sync.Mutex{}, workflow.MaxParallelism, sync.Mutex{},
+		make(map[int]time.Time, 0), make(map[int]func() bool, 0), nil, nil, nil}
 }
 
 // SetLogListener attaches a given function as the log listener for input log entries.
@@ -67,84 +108,245 @@ func (e *Executor) SetLogListener(f func(msg string)) {
 	e.logListener = f
 }
 
-func (e *Executor) executeCommand(index int) derrors.Error {
-	if index >= len(e.Workflow.Commands) {
-		return derrors.NewInternalError(errors.InvalidCommandIndex).WithParams(index, e.Workflow)
+// SetEventListener attaches a given function to be notified of each execution event.
+func (e *Executor) SetEventListener(f func(event entities.ExecutionEvent)) {
+	e.eventListener = f
+}
+
+// SetCheckpointListener attaches a given function to be notified each time a command finishes successfully.
+func (e *Executor) SetCheckpointListener(f func(checkpoint Checkpoint)) {
+	e.checkpointListener = f
+}
+
+// SetMaxParallelism limits the number of commands that are executed at the same time. If the workflow defines
+// its own limit, the lowest one is applied.
+func (e *Executor) SetMaxParallelism(maxParallelism int) {
+	if maxParallelism > 0 && (e.maxParallelism == 0 || maxParallelism < e.maxParallelism) {
+		e.maxParallelism = maxParallelism
 	}
-	e.currentCommand = index
-	go func() {
-		toExecuted := e.Workflow.Commands[index]
-		e.execOnBackground(index, toExecuted)
-	}()
+}
+
+// SetDryRun executes the workflow in dry-run mode. The changes of the commands are recorded in the given manifest
+// instead of being applied, and the commands that do not support the dry-run mode are skipped.
+func (e *Executor) SetDryRun(manifest *entities.Manifest) {
+	e.dryRun = manifest
+}
 
+// SetOwnership labels the objects created by the commands as owned by the installer for the given request.
+func (e *Executor) SetOwnership(ownership *entities.Ownership) {
+	e.ownership = ownership
+}
+
+// SetInventory records the objects applied by the commands in the given inventory.
+func (e *Executor) SetInventory(inventory *entities.Inventory) {
+	e.inventory = inventory
+}
+
+// SetRollbackOnFailure enables or disables the execution of the undo commands when the workflow fails.
+func (e *Executor) SetRollbackOnFailure(enabled bool) {
+	e.rollbackOnFailure = enabled
+}
+
+// RestoreCheckpoints loads a set of checkpoints from a previous execution of the workflow. Command identifiers
+// change each time a workflow is parsed, so checkpoints are matched by index and command name.
+func (e *Executor) RestoreCheckpoints(checkpoints []Checkpoint) derrors.Error {
+	for _, cp := range checkpoints {
+		if cp.Index < 0 || cp.Index >= len(e.Workflow.Commands) {
+			return derrors.NewInvalidArgumentError(errors.InvalidCheckpoint).WithParams(cp.Index, cp.Name)
+		}
+		if e.Workflow.Commands[cp.Index].Name() != cp.Name {
+			return derrors.NewInvalidArgumentError(errors.InvalidCheckpoint).WithParams(cp.Index, cp.Name)
+		}
+	}
+	e.Checkpoints = make([]Checkpoint, len(checkpoints))
+	copy(e.Checkpoints, checkpoints)
 	return nil
 }
 
+// unsafeAddCheckpoint records that a command has finished. The schedLock must be held by the caller.
+func (e *Executor) unsafeAddCheckpoint(index int, result *entities.CommandResult) Checkpoint {
+	cmd := e.Workflow.Commands[index]
+	cp := NewCheckpoint(index, cmd.ID(), cmd.Name(), result.Output)
+	e.Checkpoints = append(e.Checkpoints, *cp)
+	return *cp
+}
+
+// FirstUnfinishedCommand returns the index of the first command without a checkpoint.
+func (e *Executor) FirstUnfinishedCommand() int {
+	finished := make(map[int]bool, len(e.Checkpoints))
+	for _, cp := range e.Checkpoints {
+		finished[cp.Index] = true
+	}
+	index := 0
+	for index < len(e.Workflow.Commands) && finished[index] {
+		index++
+	}
+	return index
+}
+
+// unsafeReadyCommands returns the commands whose dependencies have finished, and marks them as running. The
+// number of running commands never exceeds the parallelism limit. The schedLock must be held by the caller.
+func (e *Executor) unsafeReadyCommands() []int {
+	ready := make([]int, 0)
+	if e.State != InProgressState {
+		return ready
+	}
+	finished := make(map[int]bool, len(e.Checkpoints))
+	for _, cp := range e.Checkpoints {
+		finished[cp.Index] = true
+	}
+	for index := range e.Workflow.Commands {
+		if e.maxParallelism > 0 && len(e.running) >= e.maxParallelism {
+			break
+		}
+		if _, running := e.running[index]; running || finished[index] {
+			continue
+		}
+		launch := true
+		for _, dep := range e.Workflow.Dependencies(index) {
+			if !finished[dep] {
+				launch = false
+				break
+			}
+		}
+		if launch {
+			e.running[index] = time.Now()
+			e.currentCommand = index
+			ready = append(ready, index)
+		}
+	}
+	return ready
+}
+
+// launchReadyCommands executes on background the commands whose dependencies have finished.
+func (e *Executor) launchReadyCommands() {
+	e.schedLock.Lock()
+	ready := e.unsafeReadyCommands()
+	e.schedLock.Unlock()
+	for _, index := range ready {
+		go e.execOnBackground(index, e.Workflow.Commands[index])
+	}
+}
+
+// commandIndex returns the position of a command of the workflow, or -1 if it is not found.
+func (e *Executor) commandIndex(cmdID string) int {
+	for index, cmd := range e.Workflow.Commands {
+		if cmd.ID() == cmdID {
+			return index
+		}
+	}
+	return -1
+}
+
 func (e *Executor) execOnBackground(index int, cmd entities.Command) {
 	err := e.handler.AddCommand(cmd.ID(), e.commandCallback, e.logCallback)
 	if err != nil {
 		// If the executor cannot allocate the callback the workflow fails.
-		e.failed(err)
+		e.aborted(index, err)
 		return
 	}
+	e.handler.AttachEventCallback(cmd.ID(), e.eventCallback)
 
-	if cmd.Name() != entities.Logger {
-		e.AddLogEntry("Executing: " + cmd.UserString())
-	}
+	started := entities.NewStartedEvent(e.Workflow.WorkflowID, cmd, "")
+	e.schedLock.Lock()
+	e.running[index] = started.Started
+	ctx := e.ctx
+	e.schedLock.Unlock()
+	e.AddEvent(*started)
 	if cmd.Type() == entities.SyncCommandType {
 		executorLogger.Debug().Str("cmd", cmd.String()).Msg("Executing sync command")
-		result, err := cmd.(entities.SyncCommand).Run(e.Workflow.WorkflowID)
+		result, err := entities.RunSyncCommand(ctx, cmd, e.Workflow.WorkflowID, e.AddLogEntry)
 
 		err = e.handler.FinishCommand(cmd.ID(), result, err)
 		if err != nil {
-			e.failed(err)
+			e.aborted(index, err)
 		}
 	} else {
 		executorLogger.Debug().Str("cmd", cmd.String()).Msg("Executing async command")
-		err := cmd.(entities.AsyncCommand).Run(e.Workflow.WorkflowID)
+		stopWatch := handler.WatchTimeout(e.handler, cmd)
+		e.schedLock.Lock()
+		e.asyncWatches[index] = stopWatch
+		e.schedLock.Unlock()
+		err := cmd.(entities.AsyncCommand).Run(ctx, e.Workflow.WorkflowID)
 		if err != nil {
 			//If the execution return errors, the executor call to the commandHandler with the error.
 			err = e.handler.FinishCommand(cmd.ID(), nil, err)
 			if err != nil {
-				e.failed(err)
+				e.aborted(index, err)
 			}
 		}
 	}
 }
 
 func (e *Executor) commandCallback(cmdID string, result *entities.CommandResult, error derrors.Error) {
-	// To support parallel execution of commands, we can implement a barrier command that will make commandCallback
-	// not to launch more commands until all pending commands have finished.
+	index := e.commandIndex(cmdID)
+	e.schedLock.Lock()
+	started := e.running[index]
+	stopWatch, watched := e.asyncWatches[index]
+	delete(e.asyncWatches, index)
+	state := e.State
+	var cp *Checkpoint
+	if e.rollbackPending && index >= 0 && error == nil && result != nil && result.Success {
+		// The command finished after the workflow failed, so it must be reverted as well.
+		finished := e.unsafeAddCheckpoint(index, result)
+		cp = &finished
+	}
+	startRollback := e.unsafeStopRunning(index)
+	e.schedLock.Unlock()
+	if watched {
+		stopWatch()
+	}
+	if cp != nil && e.checkpointListener != nil {
+		e.checkpointListener(*cp)
+	}
+	if startRollback {
+		e.rollback()
+		return
+	}
+	if state != InProgressState {
+		executorLogger.Debug().Str("workflowID", e.WorkflowID).Str("cmdID", cmdID).Interface("state", state).
+			Msg("ignoring result of a workflow that is not running")
+		return
+	}
+	if index < 0 {
+		e.failed(derrors.NewInternalError(errors.InvalidCommandIndex).WithParams(cmdID, e.Workflow.WorkflowID))
+		return
+	}
+
+	cmd := e.Workflow.Commands[index]
+	e.AddEvent(*entities.NewFinishedEvent(e.Workflow.WorkflowID, cmd, "", started, result, error))
 
 	if error != nil {
 		// Stop workflow execution
+		if entities.IsDeadlineExceeded(error) {
+			e.failed(derrors.NewDeadlineExceededError(errors.WorkflowExecutionFailed).CausedBy(error))
+			return
+		}
 		e.failed(derrors.NewInternalError(errors.WorkflowExecutionFailed).CausedBy(error))
 		return
 	}
 
 	if result != nil {
-		//e.AddLogEntry("Success: " + strconv.FormatBool((*result).Success))
-		if (*result).HasOutput() && (*result).ShowResult() {
-			if strings.Contains(cmdID, entities.Logger) {
-				e.AddLogEntry((*result).Output)
-			} else {
-				e.AddLogEntry(fmt.Sprintf("Command %s:\n%s", cmdID, (*result).Output))
-			}
-		}
-
 		if (*result).Success {
-			if e.currentCommand == len(e.Workflow.Commands)-1 {
-				executorLogger.Debug().Interface("workflowState", e.State).Msg("all commands have been executed")
-				e.AddLogEntry("All commands have been executed")
+			e.schedLock.Lock()
+			cp := e.unsafeAddCheckpoint(index, result)
+			done := len(e.Checkpoints) == len(e.Workflow.Commands) && e.State == InProgressState
+			if done {
 				e.State = FinishedState
-				e.workflowCallback(e.Workflow.WorkflowID, nil, e.State)
-				return
 			}
-
-			err := e.executeCommand(e.currentCommand + 1)
-			if err != nil {
-				e.failed(err)
+			cancel := e.cancel
+			e.schedLock.Unlock()
+			if e.checkpointListener != nil {
+				e.checkpointListener(cp)
 			}
+			if done {
+				executorLogger.Debug().Str("workflowID", e.WorkflowID).Msg("all commands have been executed")
+				e.AddLogEntry("All commands have been executed")
+				cancel()
+				e.workflowCallback(e.Workflow.WorkflowID, nil, FinishedState)
+				return
+			}
+			e.launchReadyCommands()
 		} else {
 			log.Warn().Str("workflowID", e.WorkflowID).Msg(result.String())
 			e.failed(derrors.NewInternalError(errors.WorkflowExecutionFailed).WithParams(result.String()))
@@ -156,7 +358,24 @@ func (e *Executor) commandCallback(cmdID string, result *entities.CommandResult,
 }
 
 func (e *Executor) logCallback(id string, logEntry string) {
-	e.AddLogEntry(logEntry)
+	event := entities.ExecutionEvent{
+		WorkflowID: e.Workflow.WorkflowID,
+		CommandID:  id,
+		Phase:      entities.OutputPhase,
+		Msg:        logEntry,
+		Timestamp:  time.Now(),
+	}
+	for _, cmd := range e.Workflow.Commands {
+		if cmd.ID() == id {
+			event.CommandName = cmd.Name()
+		}
+	}
+	e.AddEvent(event)
+}
+
+// eventCallback receives the events of the commands launched by the commands of the workflow.
+func (e *Executor) eventCallback(id string, event entities.ExecutionEvent) {
+	e.AddEvent(event)
 }
 
 // Exec starts the execution of the target workflow.
@@ -164,48 +383,267 @@ func (e *Executor) Exec() {
 	if len(e.Workflow.Commands) > 0 {
 		executorLogger.Debug().Str("workflowID", e.WorkflowID).Int("numCommands", len(e.Workflow.Commands)).
 			Msg("Executing workflow")
+		e.schedLock.Lock()
 		e.State = InProgressState
-		err := e.executeCommand(0)
-		if err != nil {
-			e.failed(err)
-		}
+		e.Checkpoints = make([]Checkpoint, 0)
+		e.unsafeStartContext()
+		e.schedLock.Unlock()
+		e.launchReadyCommands()
 		return
 	}
 	e.failed(derrors.NewInternalError(errors.WorkflowWithoutCommands))
 }
 
+// Resume continues the execution of the target workflow from the first command that has not finished.
+func (e *Executor) Resume() {
+	if len(e.Workflow.Commands) == 0 {
+		e.failed(derrors.NewInternalError(errors.WorkflowWithoutCommands))
+		return
+	}
+	next := e.FirstUnfinishedCommand()
+	if next == len(e.Workflow.Commands) {
+		e.AddLogEntry("All commands have been executed")
+		e.schedLock.Lock()
+		e.State = FinishedState
+		e.schedLock.Unlock()
+		e.workflowCallback(e.Workflow.WorkflowID, nil, FinishedState)
+		return
+	}
+	executorLogger.Debug().Str("workflowID", e.WorkflowID).Int("numCommands", len(e.Workflow.Commands)).
+		Int("next", next).Msg("Resuming workflow")
+	e.AddLogEntry(fmt.Sprintf("Resuming workflow from command %d", next))
+	for _, cp := range e.Checkpoints {
+		e.AddEvent(*entities.NewExecutionEvent(e.Workflow.WorkflowID, e.Workflow.Commands[cp.Index], "",
+			entities.SkippedPhase, "finished on a previous execution"))
+	}
+	e.schedLock.Lock()
+	e.State = InProgressState
+	e.unsafeStartContext()
+	e.schedLock.Unlock()
+	e.launchReadyCommands()
+}
+
+// unsafeStartContext creates the context of a new execution. If the workflow defines a timeout, the execution fails
+// once the deadline is reached. The schedLock must be held by the caller.
+func (e *Executor) unsafeStartContext() {
+	if e.Workflow.Timeout == 0 {
+		e.ctx, e.cancel = context.WithCancel(e.baseContext())
+		return
+	}
+	ctx, cancel := context.WithTimeout(e.baseContext(), e.Workflow.Timeout)
+	e.ctx, e.cancel = ctx, cancel
+	go func() {
+		<-ctx.Done()
+		if ctx.Err() == context.DeadlineExceeded {
+			e.failed(derrors.NewDeadlineExceededError(errors.WorkflowDeadlineExceeded).WithParams(e.Workflow.Timeout.String()))
+		}
+	}()
+}
+
+// baseContext returns the root context of the executions, carrying the manifest in dry-run mode, the ownership
+// of the objects and the inventory of the install.
+func (e *Executor) baseContext() context.Context {
+	ctx := context.Background()
+	if e.ownership != nil {
+		ctx = entities.WithOwnership(ctx, e.ownership)
+	}
+	if e.inventory != nil {
+		ctx = entities.WithInventory(ctx, e.inventory)
+	}
+	if e.dryRun != nil {
+		ctx = entities.WithManifest(ctx, e.dryRun)
+	}
+	return ctx
+}
+
 func (e *Executor) failed(reason derrors.Error) {
+	// Several running commands may fail at the same time, so the state is checked and updated atomically.
+	e.schedLock.Lock()
+	if e.State == FinishedState || e.State == CanceledState || e.State == ErrorState ||
+		e.State == RollingBackState || e.State == RolledBackState {
+		e.schedLock.Unlock()
+		return
+	}
+	e.State = ErrorState
+	cancel := e.cancel
+	startRollback := false
+	if e.rollbackOnFailure {
+		e.rollbackReason = reason
+		e.rollbackPending = len(e.running) > 0
+		startRollback = !e.rollbackPending
+	}
+	e.schedLock.Unlock()
+	// Canceling the context aborts the running commands. If the rollback is pending, it is started by the callback
+	// of the last one.
+	cancel()
 	e.AddLogEntry(reason.Error())
 	e.AddLogEntry(Fail)
-	e.State = ErrorState
-	e.workflowCallback(e.Workflow.WorkflowID, reason, e.State)
+	if startRollback {
+		e.rollback()
+		return
+	}
+	if !e.rollbackOnFailure {
+		e.workflowCallback(e.Workflow.WorkflowID, reason, ErrorState)
+	}
 }
 
-func (e *Executor) commandLogListener(logEntry string) {
-	e.ExecutionLog = append(e.ExecutionLog, "commandLogListener: "+logEntry)
-	if e.logListener != nil {
-		e.logListener("commandLogListener: " + logEntry)
+// aborted fails the workflow when a running command cannot report its result through the callback.
+func (e *Executor) aborted(index int, reason derrors.Error) {
+	e.schedLock.Lock()
+	startRollback := e.unsafeStopRunning(index)
+	e.schedLock.Unlock()
+	if startRollback {
+		e.rollback()
+		return
+	}
+	e.failed(reason)
+}
+
+// unsafeStopRunning removes a command from the running ones. It returns true if the workflow is waiting for the
+// running commands to start the rollback, and the command was the last one. The schedLock must be held by the caller.
+func (e *Executor) unsafeStopRunning(index int) bool {
+	delete(e.running, index)
+	if e.rollbackPending && len(e.running) == 0 {
+		e.rollbackPending = false
+		return true
 	}
+	return false
 }
 
-// AddLogEntry adds a new line to the log.
+// unsafeFinishedCommands returns the indexes of the commands that have been executed in order of execution. The
+// schedLock must be held by the caller.
+func (e *Executor) unsafeFinishedCommands() []int {
+	result := make([]int, 0, len(e.Checkpoints))
+	for _, cp := range e.Checkpoints {
+		result = append(result, cp.Index)
+	}
+	return result
+}
+
+// rollback executes the undo commands of the finished commands as a sequential group once no command of the
+// workflow is running. The group stops on the first failure as the remaining undo commands may depend on it.
+func (e *Executor) rollback() {
+	e.schedLock.Lock()
+	if e.State != ErrorState {
+		// The workflow has been canceled while waiting for the running commands.
+		e.schedLock.Unlock()
+		return
+	}
+	reason := e.rollbackReason
+	undo := e.Workflow.UndoCommands(e.unsafeFinishedCommands())
+	if len(undo) == 0 {
+		e.schedLock.Unlock()
+		e.workflowCallback(e.Workflow.WorkflowID, reason, ErrorState)
+		return
+	}
+	e.State = RollingBackState
+	e.ctx, e.cancel = context.WithCancel(e.baseContext())
+	ctx := e.ctx
+	e.schedLock.Unlock()
+	e.AddLogEntry(fmt.Sprintf("Rolling back %d finished commands", len(undo)))
+	group := commands.NewGroup("rollback", undo)
+	err := e.handler.AddCommand(group.ID(), e.rollbackCallback, e.logCallback)
+	if err != nil {
+		e.rollbackCallback(group.ID(), nil, err)
+		return
+	}
+	e.handler.AttachEventCallback(group.ID(), e.eventCallback)
+	go func() {
+		result, err := group.Run(ctx, e.Workflow.WorkflowID)
+		err = e.handler.FinishCommand(group.ID(), result, err)
+		if err != nil {
+			e.rollbackCallback(group.ID(), nil, err)
+		}
+	}()
+}
+
+func (e *Executor) rollbackCallback(cmdID string, result *entities.CommandResult, error derrors.Error) {
+	e.schedLock.Lock()
+	state := e.State
+	e.schedLock.Unlock()
+	if state == CanceledState {
+		executorLogger.Debug().Str("workflowID", e.WorkflowID).Msg("ignoring rollback result of canceled workflow")
+		return
+	}
+	if error == nil && (result == nil || !result.Success) {
+		error = derrors.NewInternalError(errors.InvalidWorkflowState)
+		if result != nil {
+			error = derrors.NewInternalError(errors.WorkflowExecutionFailed).WithParams(result.String())
+		}
+	}
+	if error != nil {
+		e.AddLogEntry(error.Error())
+		e.AddLogEntry("Rollback failed")
+		e.schedLock.Lock()
+		e.State = ErrorState
+		e.schedLock.Unlock()
+		e.workflowCallback(e.Workflow.WorkflowID,
+			derrors.NewInternalError(errors.WorkflowRollbackFailed, error).WithParams(e.rollbackReason.Error()), ErrorState)
+		return
+	}
+	e.AddLogEntry("Rollback completed")
+	e.schedLock.Lock()
+	// The undone commands must be executed again if the workflow is resumed.
+	e.Checkpoints = make([]Checkpoint, 0)
+	e.State = RolledBackState
+	e.schedLock.Unlock()
+	e.workflowCallback(e.Workflow.WorkflowID, derrors.NewAbortedError(errors.WorkflowRolledBack, e.rollbackReason), RolledBackState)
+}
+
+func (e *Executor) commandLogListener(logEntry string) {
+	e.AddLogEntry("commandLogListener: " + logEntry)
+}
+
+// AddLogEntry adds a new line to the log as an output event of the workflow.
 func (e *Executor) AddLogEntry(line string) {
-	e.ExecutionLog = append(e.ExecutionLog, line)
-	if e.logListener != nil {
-		e.logListener(line)
+	e.AddEvent(entities.ExecutionEvent{
+		WorkflowID: e.Workflow.WorkflowID,
+		Phase:      entities.OutputPhase,
+		Msg:        line,
+		Timestamp:  time.Now(),
+	})
+}
+
+// AddEvent records an execution event, and adds its log entry to the log.
+func (e *Executor) AddEvent(event entities.ExecutionEvent) {
+	entry := event.String()
+	e.eventLock.Lock()
+	e.ExecutionEvents = append(e.ExecutionEvents, event)
+	if entry != "" {
+		e.ExecutionLog = append(e.ExecutionLog, entry)
+	}
+	e.eventLock.Unlock()
+	if entry != "" && e.logListener != nil {
+		e.logListener(entry)
+	}
+	if e.eventListener != nil {
+		e.eventListener(event)
 	}
 }
 
 // Log retrieves the execution log of the current workflow.
 func (e *Executor) Log() []string {
+	e.eventLock.Lock()
+	defer e.eventLock.Unlock()
 	logCopy := make([]string, len(e.ExecutionLog))
 	copy(logCopy, e.ExecutionLog)
 	return logCopy
 }
 
+// Events retrieves the execution events of the current workflow.
+func (e *Executor) Events() []entities.ExecutionEvent {
+	e.eventLock.Lock()
+	defer e.eventLock.Unlock()
+	eventsCopy := make([]entities.ExecutionEvent, len(e.ExecutionEvents))
+	copy(eventsCopy, e.ExecutionEvents)
+	return eventsCopy
+}
+
 // CurrentCommand returns the index of the command being executed and the total of commands to be executed in
 // in the workflow.
 func (e *Executor) CurrentCommand() (int, int) {
+	e.schedLock.Lock()
+	defer e.schedLock.Unlock()
 	return e.currentCommand, len(e.Commands)
 }
 
@@ -223,8 +661,20 @@ func (e *Executor) ParameterGet(key string) (*WorkflowParameter, derrors.Error)
 	return nil, derrors.NewNotFoundError(errors.ParameterDoesNotExists).WithParams(key)
 }
 
+// Stop cancels the execution of the workflow. The running command receives the cancellation through its context,
+// and no further commands are launched.
 func (e *Executor) Stop() {
-	log.Debug().Msg("Stopping after last command is executed")
-	e.currentCommand = len(e.Workflow.Commands) - 1
-	e.workflowCallback(e.Workflow.WorkflowID, nil, e.State)
+	log.Debug().Str("workflowID", e.WorkflowID).Msg("Canceling workflow execution")
+	e.schedLock.Lock()
+	e.cancel()
+	state := e.State
+	if state == FinishedState || state == ErrorState || state == CanceledState || state == RolledBackState {
+		e.schedLock.Unlock()
+		e.workflowCallback(e.Workflow.WorkflowID, nil, state)
+		return
+	}
+	e.State = CanceledState
+	e.schedLock.Unlock()
+	e.AddLogEntry("Workflow execution has been canceled")
+	e.workflowCallback(e.Workflow.WorkflowID, nil, CanceledState)
 }
@@ -95,6 +95,8 @@ func (handler *executorHandler) Stop(workflowID string) derrors.Error {
 		return err
 	}
 	exe.Stop()
+	handler.Lock()
 	delete(handler.executorMap, workflowID)
+	handler.Unlock()
 	return nil
 }
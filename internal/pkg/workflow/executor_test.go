@@ -18,8 +18,10 @@
 package workflow
 
 import (
+	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
+	"os"
 	"time"
 )
 
@@ -84,6 +86,127 @@ const parallelMaxParallelismWorkflow = `
 }
 `
 
+const resumeWorkflow = `
+{
+ "description": "resumeWorkflow",
+ "commands": [
+  {"type":"sync", "name": "logger", "msg": "first"},
+  {"type":"sync", "name": "logger", "msg": "second"},
+  {"type":"sync", "name": "exec", "cmd": "echo", "args":["third"]}
+ ]
+}
+`
+
+const dryRunWorkflow = `
+{
+ "description": "dryRunWorkflow",
+ "commands": [
+  {"type":"sync", "name": "logger", "msg": "dry-run logger"},
+  {"type":"sync", "name": "exec", "cmd": "mkdir", "args":["/tmp/dryRunWorkflow"]}
+ ]
+}
+`
+
+const cancelWorkflow = `
+{
+ "description": "cancelWorkflow",
+ "commands": [
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["30"]},
+  {"type":"sync", "name": "logger", "msg": "must not be executed"}
+ ]
+}
+`
+
+const rollbackWorkflow = `
+{
+ "description": "rollbackWorkflow",
+ "commands": [
+  {"type":"sync", "name": "exec", "cmd": "mkdir", "args":["-p", "/tmp/rollbackWorkflow"],
+    "undo": {"type":"sync", "name": "exec", "cmd": "rm", "args":["-r", "/tmp/rollbackWorkflow"]}},
+  {"type":"sync", "name": "logger", "msg": "no undo required"},
+  {"type":"sync", "name": "exec", "cmd": "touch", "args":["/tmp/rollbackWorkflow/file"],
+    "undo": {"type":"sync", "name": "exec", "cmd": "rm", "args":["/tmp/rollbackWorkflow/file"]}},
+  {"type":"sync", "name": "fail"}
+ ]
+}
+`
+
+const failedRollbackWorkflow = `
+{
+ "description": "failedRollbackWorkflow",
+ "commands": [
+  {"type":"sync", "name": "logger", "msg": "first",
+    "undo": {"type":"sync", "name": "fail"}},
+  {"type":"sync", "name": "fail"}
+ ]
+}
+`
+
+const eventsWorkflow = `
+{
+ "description": "eventsWorkflow",
+ "commands": [
+  {"type":"sync", "name": "group", "description": "nested", "commands": [
+    {"type":"sync", "name": "logger", "msg": "nested logger"},
+    {"type":"sync", "name": "exec", "cmd": "true"}
+  ]},
+  {"type":"sync", "name": "try", "description": "alternative",
+    "cmd": {"type":"sync", "name": "logger", "msg": "try logger"},
+    "onFail": {"type":"sync", "name": "logger", "msg": "must not be executed"}}
+ ]
+}
+`
+
+const workflowTimeoutWorkflow = `
+{
+ "description": "workflowTimeoutWorkflow",
+ "timeout": "500ms",
+ "commands": [
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["30"]},
+  {"type":"sync", "name": "logger", "msg": "must not be executed"}
+ ]
+}
+`
+
+const commandTimeoutWorkflow = `
+{
+ "description": "commandTimeoutWorkflow",
+ "commands": [
+  {"type":"sync", "name": "logger", "msg": "first"},
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["30"], "timeout": "500ms"},
+  {"type":"sync", "name": "logger", "msg": "must not be executed"}
+ ]
+}
+`
+
+const dependenciesWorkflow = `
+{
+ "description": "dependenciesWorkflow",
+ "commands": [
+  {"type":"sync", "name": "logger", "msg": "first", "id": "first"},
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["1"], "id": "a", "dependsOn": ["first"]},
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["1"], "id": "b", "dependsOn": ["first"]},
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["1"], "id": "c", "dependsOn": ["first"]},
+  {"type":"sync", "name": "logger", "msg": "last", "dependsOn": ["a", "b", "c"]}
+ ]
+}
+`
+
+// The undo command of the first command only succeeds if the slow command has finished. The slow command keeps
+// running in background once canceled, and reports its result when the file is created.
+const parallelRollbackWorkflow = `
+{
+ "description": "parallelRollbackWorkflow",
+ "commands": [
+  {"type":"sync", "name": "exec", "cmd": "mkdir", "args":["-p", "/tmp/parallelRollback"], "id": "dir",
+    "undo": {"type":"sync", "name": "exec", "cmd": "sh", "args":["-c", "test -f /tmp/parallelRollback/slow && rm -r /tmp/parallelRollback"]}},
+  {"type":"sync", "name": "exec", "cmd": "sh", "args":["-c", "(sleep 2; touch /tmp/parallelRollback/slow) & wait"], "dependsOn": ["dir"]},
+  {"type":"sync", "name": "exec", "cmd": "sleep", "args":["0.5"], "id": "wait", "dependsOn": ["dir"]},
+  {"type":"sync", "name": "fail", "dependsOn": ["wait"]}
+ ]
+}
+`
+
 func getWorkflow(name string, template string) *Workflow {
 	p := NewParser()
 	workflow, err := p.ParseWorkflow(name, template, name, EmptyParameters)
@@ -146,6 +269,281 @@ var _ = ginkgo.Describe("Executor", func() {
 		})
 	})
 
+	ginkgo.Context("with nested commands", func() {
+		w := getWorkflow("TestEvents", eventsWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		received := make(chan entities.ExecutionEvent, 100)
+		exec.SetEventListener(func(event entities.ExecutionEvent) {
+			received <- event
+		})
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		expectSuccess(wr)
+		ginkgo.It("must report the events of the nested commands", func() {
+			group := w.Commands[0]
+			phases := make(map[string][]entities.ExecutionPhase, 0)
+			parents := make(map[string]string, 0)
+			for _, event := range exec.Events() {
+				if event.Phase == entities.OutputPhase {
+					continue
+				}
+				phases[event.CommandName] = append(phases[event.CommandName], event.Phase)
+				parents[event.CommandName] = event.ParentCommandID
+				if event.Phase == entities.SucceededPhase {
+					gomega.Expect(event.Started.IsZero()).To(gomega.BeFalse())
+					gomega.Expect(event.Duration).To(gomega.BeNumerically(">=", 0))
+				}
+			}
+			gomega.Expect(phases[entities.GroupCmd]).To(gomega.Equal(
+				[]entities.ExecutionPhase{entities.StartedPhase, entities.SucceededPhase}))
+			gomega.Expect(parents[entities.GroupCmd]).To(gomega.BeEmpty())
+			gomega.Expect(phases[entities.Exec]).To(gomega.Equal(
+				[]entities.ExecutionPhase{entities.StartedPhase, entities.SucceededPhase}))
+			gomega.Expect(parents[entities.Exec]).To(gomega.Equal(group.ID()))
+			gomega.Expect(phases[entities.Logger]).To(gomega.ContainElement(entities.SkippedPhase))
+			gomega.Expect(len(received)).To(gomega.Equal(len(exec.Events())))
+			gomega.Expect(exec.Log()).To(gomega.ContainElement("Executing: " + group.UserString()))
+			gomega.Expect(exec.Log()).To(gomega.ContainElement("try logger"))
+		})
+	})
+
+	ginkgo.Context("with independent commands", func() {
+		w := getWorkflow("TestDependencies", dependenciesWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		start := time.Now()
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Millisecond * 100)
+		}
+		elapsed := time.Since(start)
+		expectSuccess(wr)
+		ginkgo.It("must execute them at the same time after their dependencies", func() {
+			gomega.Expect(elapsed).To(gomega.BeNumerically("<", time.Second*2))
+			gomega.Expect(exec.Checkpoints).To(gomega.HaveLen(5))
+			gomega.Expect(exec.Checkpoints[0].Index).To(gomega.Equal(0))
+			gomega.Expect(exec.Checkpoints[4].Index).To(gomega.Equal(4))
+		})
+	})
+
+	ginkgo.Context("with a parallelism limit", func() {
+		w := getWorkflow("TestMaxParallelism", dependenciesWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		exec.SetMaxParallelism(2)
+		start := time.Now()
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait*10 && !wr.Finished(); i++ {
+			time.Sleep(time.Millisecond * 100)
+		}
+		elapsed := time.Since(start)
+		expectSuccess(wr)
+		ginkgo.It("must not exceed the limit", func() {
+			gomega.Expect(elapsed).To(gomega.BeNumerically(">=", time.Second*2))
+			gomega.Expect(exec.Checkpoints).To(gomega.HaveLen(5))
+			gomega.Expect(exec.Checkpoints[4].Index).To(gomega.Equal(4))
+		})
+	})
+
+	ginkgo.Context("when resuming a workflow", func() {
+		w := getWorkflow("TestResume", resumeWorkflow)
+		wr := &WorkflowResult{}
+		notified := make([]Checkpoint, 0)
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		exec.SetCheckpointListener(func(checkpoint Checkpoint) {
+			notified = append(notified, checkpoint)
+		})
+		restoreErr := exec.RestoreCheckpoints([]Checkpoint{
+			*NewCheckpoint(0, "previous-0", "logger", "first"),
+			*NewCheckpoint(1, "previous-1", "logger", "second"),
+		})
+		next := exec.FirstUnfinishedCommand()
+		exec.Resume()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		expectSuccess(wr)
+		ginkgo.It("must skip the finished commands", func() {
+			gomega.Expect(restoreErr).To(gomega.BeNil())
+			gomega.Expect(next).To(gomega.Equal(2))
+			gomega.Expect(exec.Log()).ToNot(gomega.ContainElement("first"))
+			gomega.Expect(exec.Checkpoints).To(gomega.HaveLen(3))
+			gomega.Expect(notified).To(gomega.HaveLen(1))
+			gomega.Expect(notified[0].Index).To(gomega.Equal(2))
+			gomega.Expect(notified[0].Name).To(gomega.Equal("exec"))
+		})
+	})
+
+	ginkgo.Context("when restoring checkpoints from a different workflow", func() {
+		w := getWorkflow("TestInvalidCheckpoint", resumeWorkflow)
+		exec := NewWorkflowExecutor(w, NewWorkflowResult().Callback)
+		restoreErr := exec.RestoreCheckpoints([]Checkpoint{*NewCheckpoint(2, "previous-2", "logger", "")})
+		ginkgo.It("must fail", func() {
+			gomega.Expect(restoreErr).ToNot(gomega.BeNil())
+			gomega.Expect(exec.FirstUnfinishedCommand()).To(gomega.Equal(0))
+		})
+	})
+
+	ginkgo.Context("when executed in dry-run mode", func() {
+		w := getWorkflow("TestDryRun", dryRunWorkflow)
+		wr := &WorkflowResult{}
+		manifest := entities.NewManifest()
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		exec.SetDryRun(manifest)
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Millisecond * 100)
+		}
+		expectSuccess(wr)
+		_, statErr := os.Stat("/tmp/dryRunWorkflow")
+		ginkgo.It("must record the commands that are not supported", func() {
+			gomega.Expect(os.IsNotExist(statErr)).To(gomega.BeTrue())
+			gomega.Expect(exec.Log()).To(gomega.ContainElement("dry-run logger"))
+			entries := manifest.Entries()
+			gomega.Expect(entries).To(gomega.HaveLen(1))
+			gomega.Expect(entries[0].Action).To(gomega.Equal(entities.ExecAction))
+			gomega.Expect(entries[0].CommandID).To(gomega.Equal(w.Commands[1].ID()))
+		})
+	})
+
+	ginkgo.Context("when the workflow timeout is reached", func() {
+		w := getWorkflow("TestWorkflowTimeout", workflowTimeoutWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		start := time.Now()
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		elapsed := time.Since(start)
+		ginkgo.It("must fail", func() {
+			gomega.Expect(w.Timeout).To(gomega.Equal(time.Millisecond * 500))
+			gomega.Expect(wr.Called).To(gomega.BeTrue())
+			gomega.Expect(wr.Error).ToNot(gomega.BeNil())
+			gomega.Expect(entities.IsDeadlineExceeded(wr.Error)).To(gomega.BeTrue())
+			gomega.Expect(wr.State).To(gomega.Equal(ErrorState))
+			gomega.Expect(elapsed).To(gomega.BeNumerically("<", time.Second*maxWait))
+			gomega.Expect(exec.Checkpoints).To(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.Context("when a command timeout is reached", func() {
+		w := getWorkflow("TestCommandTimeout", commandTimeoutWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		start := time.Now()
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		elapsed := time.Since(start)
+		ginkgo.It("must fail", func() {
+			gomega.Expect(wr.Called).To(gomega.BeTrue())
+			gomega.Expect(wr.Error).ToNot(gomega.BeNil())
+			gomega.Expect(entities.IsDeadlineExceeded(wr.Error)).To(gomega.BeTrue())
+			gomega.Expect(wr.State).To(gomega.Equal(ErrorState))
+			gomega.Expect(elapsed).To(gomega.BeNumerically("<", time.Second*maxWait))
+			gomega.Expect(exec.Checkpoints).To(gomega.HaveLen(1))
+		})
+	})
+
+	ginkgo.Context("when a command fails while other commands are running", func() {
+		w := getWorkflow("TestParallelRollback", parallelRollbackWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		exec.SetRollbackOnFailure(true)
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		ginkgo.It("must wait for the running commands before undoing the finished ones", func() {
+			gomega.Expect(wr.Called).To(gomega.BeTrue())
+			gomega.Expect(wr.State).To(gomega.Equal(RolledBackState))
+			_, err := os.Stat("/tmp/parallelRollback")
+			gomega.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+		})
+	})
+
+	ginkgo.Context("when the workflow is stopped", func() {
+		w := getWorkflow("TestCancel", cancelWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		start := time.Now()
+		exec.Exec()
+		time.Sleep(time.Millisecond * 500)
+		exec.Stop()
+		// Wait for the running command to be killed
+		time.Sleep(time.Millisecond * 500)
+		ginkgo.It("must abort the running command", func() {
+			gomega.Expect(wr.Called).To(gomega.BeTrue())
+			gomega.Expect(wr.Error).To(gomega.BeNil())
+			gomega.Expect(wr.State).To(gomega.Equal(CanceledState))
+			gomega.Expect(time.Since(start)).To(gomega.BeNumerically("<", time.Second*maxWait))
+			current, _ := exec.CurrentCommand()
+			gomega.Expect(current).To(gomega.Equal(0))
+			gomega.Expect(exec.Checkpoints).To(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.Context("when a workflow with undo commands fails", func() {
+		w := getWorkflow("TestRollback", rollbackWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		exec.SetRollbackOnFailure(true)
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		ginkgo.It("must undo the finished commands", func() {
+			gomega.Expect(wr.Called).To(gomega.BeTrue())
+			gomega.Expect(wr.Error).ToNot(gomega.BeNil())
+			gomega.Expect(wr.State).To(gomega.Equal(RolledBackState))
+			gomega.Expect(exec.Checkpoints).To(gomega.BeEmpty())
+			_, err := os.Stat("/tmp/rollbackWorkflow")
+			gomega.Expect(os.IsNotExist(err)).To(gomega.BeTrue())
+		})
+	})
+
+	ginkgo.Context("when an undo command fails", func() {
+		w := getWorkflow("TestFailedRollback", failedRollbackWorkflow)
+		wr := &WorkflowResult{}
+
+		exec := NewWorkflowExecutor(w, wr.Callback)
+		exec.SetRollbackOnFailure(true)
+		exec.Exec()
+		// Wait for the workflow to finish
+		for i := 0; i < maxWait && !wr.Finished(); i++ {
+			time.Sleep(time.Second * 1)
+		}
+		ginkgo.It("must fail", func() {
+			gomega.Expect(wr.Called).To(gomega.BeTrue())
+			gomega.Expect(wr.Error).ToNot(gomega.BeNil())
+			gomega.Expect(wr.State).To(gomega.Equal(ErrorState))
+		})
+	})
+
 	ginkgo.Context("with a max parallelism spec", func() {
 		w := getWorkflow("TestMaxParallel", parallelMaxParallelismWorkflow)
 		wr := &WorkflowResult{}
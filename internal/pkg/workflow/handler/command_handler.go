@@ -20,6 +20,7 @@ package handler
 import (
 	"github.com/nalej/installer/internal/pkg/errors"
 	"sync"
+	"time"
 
 	"github.com/nalej/derrors"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
@@ -37,6 +38,11 @@ type CommandHandler interface {
 	) derrors.Error
 	AddLogEntry(id string, logEntry string) derrors.Error
 	AttachLogListener(id string, f func(logEntry string))
+	// AttachEventCallback registers the function that receives the execution events sent to a command.
+	AttachEventCallback(id string, f func(id string, event entities.ExecutionEvent))
+	// AddEvent sends an execution event to a command. If the command does not have an event callback, its log
+	// callback receives the log entry of the event.
+	AddEvent(id string, event entities.ExecutionEvent) derrors.Error
 	FinishCommand(id string, result *entities.CommandResult, error derrors.Error) derrors.Error
 }
 
@@ -54,6 +60,7 @@ type commandHandler struct {
 	resultCallbacks map[string]func(id string, result *entities.CommandResult, error derrors.Error)
 	logCallbacks    map[string]func(id string, logEntry string)
 	logListeners    map[string]func(logEntry string)
+	eventCallbacks  map[string]func(id string, event entities.ExecutionEvent)
 }
 
 // NewCommandHandler creates a new CommandHandler initializing the internal structures.
@@ -62,6 +69,7 @@ func NewCommandHandler() CommandHandler {
 		resultCallbacks: make(map[string]func(id string, result *entities.CommandResult, error derrors.Error)),
 		logCallbacks:    make(map[string]func(id string, logEntry string)),
 		logListeners:    make(map[string]func(logEntry string)),
+		eventCallbacks:  make(map[string]func(id string, event entities.ExecutionEvent)),
 	}
 }
 
@@ -102,6 +110,32 @@ func (h *commandHandler) AttachLogListener(id string, f func(logEntry string)) {
 	h.logListeners[id] = f
 }
 
+func (h *commandHandler) AttachEventCallback(id string, f func(id string, event entities.ExecutionEvent)) {
+	h.Lock()
+	defer h.Unlock()
+	h.eventCallbacks[id] = f
+}
+
+func (h *commandHandler) AddEvent(id string, event entities.ExecutionEvent) derrors.Error {
+	h.Lock()
+	logCallback, exist := h.logCallbacks[id]
+	eventCallback, existEvent := h.eventCallbacks[id]
+	h.Unlock()
+	if !exist {
+		return derrors.NewNotFoundError(errors.NotExistCommand).WithParams(id)
+	}
+	// Events are delivered in order, so the callback is invoked without holding the lock as it may forward the
+	// event to the parent command.
+	if existEvent {
+		eventCallback(id, event)
+		return nil
+	}
+	if entry := event.String(); entry != "" {
+		logCallback(id, entry)
+	}
+	return nil
+}
+
 func (h *commandHandler) FinishCommand(id string, result *entities.CommandResult,
 	error derrors.Error) derrors.Error {
 	h.Lock()
@@ -118,6 +152,20 @@ func (h *commandHandler) FinishCommand(id string, result *entities.CommandResult
 	delete(h.logCallbacks, id)
 	delete(h.resultCallbacks, id)
 	delete(h.logListeners, id)
+	delete(h.eventCallbacks, id)
 	return nil
 
 }
+
+// WatchTimeout finishes an asynchronous command with a DeadlineExceeded error if the command does not finish
+// before its timeout. The returned function stops the watch, and returns false if the timeout was already reached.
+func WatchTimeout(h CommandHandler, cmd entities.Command) func() bool {
+	if cmd.Timeout() == 0 {
+		return func() bool { return true }
+	}
+	timer := time.AfterFunc(cmd.Timeout(), func() {
+		h.FinishCommand(cmd.ID(), nil,
+			derrors.NewDeadlineExceededError(errors.CommandDeadlineExceeded).WithParams(cmd.Name(), cmd.Timeout().String()))
+	})
+	return timer.Stop
+}
@@ -124,6 +124,36 @@ var _ = ginkgo.Describe("Handler", func() {
 		})
 	})
 
+	ginkgo.Context("when adding events", func() {
+		handler := NewCommandHandler().(*commandHandler)
+		lines := make([]string, 0)
+		events := make([]entities.ExecutionEvent, 0)
+		handler.AddCommand("id1",
+			func(id string, result *entities.CommandResult, error derrors.Error) {},
+			func(id string, logEntry string) {
+				lines = append(lines, logEntry)
+			},
+		)
+		handler.AddCommand("id2",
+			func(id string, result *entities.CommandResult, error derrors.Error) {},
+			func(id string, logEntry string) {},
+		)
+		handler.AttachEventCallback("id2", func(id string, event entities.ExecutionEvent) {
+			events = append(events, event)
+		})
+		event := entities.ExecutionEvent{Phase: entities.OutputPhase, Msg: "hello world!"}
+		err1 := handler.AddEvent("id1", event)
+		err2 := handler.AddEvent("id2", event)
+		err3 := handler.AddEvent("id3", event)
+		ginkgo.It("must deliver them in order", func() {
+			gomega.Expect(err1).To(gomega.BeNil())
+			gomega.Expect(err2).To(gomega.BeNil())
+			gomega.Expect(err3).ToNot(gomega.BeNil())
+			gomega.Expect(lines).To(gomega.Equal([]string{"hello world!"}))
+			gomega.Expect(events).To(gomega.Equal([]entities.ExecutionEvent{event}))
+		})
+	})
+
 	ginkgo.Context("receiving a finish callback on a non registered cmd", func() {
 		handler := NewCommandHandler().(*commandHandler)
 		err := handler.FinishCommand("id1", entities.NewSuccessCommand([]byte("OK")), nil)
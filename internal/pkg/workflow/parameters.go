@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"github.com/nalej/installer/internal/pkg/entities"
 	"io/ioutil"
+	"strconv"
 
 	"github.com/nalej/installer/internal/pkg/errors"
 
@@ -60,6 +61,8 @@ type Parameters struct {
 	AuthSecret string `json:"auth_secret"`
 	// CACertPath contains the path to the certificate of a TLS secret
 	CACertPath string `json:"ca_cert_path"`
+	// Lists contains named lists of values that the templates iterate with the List method.
+	Lists map[string][]string `json:"lists,omitempty"`
 }
 
 var EmptyNetworkConfig = &NetworkConfig{}
@@ -175,6 +178,44 @@ func NewParametersFromFile(filePath string) (*Parameters, derrors.Error) {
 	return parameters, nil
 }
 
+// List returns a named list of the parameters so a template can iterate it, e.g.
+// {{range $i, $name := $.List "clusterRoles" (list "prometheus")}}.
+//   params:
+//     name The name of the list.
+//     defaults The values returned if the parameters do not contain the list.
+//   returns:
+//     The values of the list.
+func (p Parameters) List(name string, defaults []string) []string {
+	if values, exists := p.Lists[name]; exists {
+		return values
+	}
+	return defaults
+}
+
+// InventoryParameters returns the parameters of an install that are recorded in its inventory. The credentials,
+// the secrets and the local paths are not included.
+func (p *Parameters) InventoryParameters() map[string]string {
+	result := map[string]string{
+		"targetEnvironment": p.TargetEnvironment,
+		"appCluster":        strconv.FormatBool(p.AppCluster),
+		"networkingMode":    p.NetworkConfig.NetworkingMode,
+	}
+	if !p.AppCluster {
+		result["managementClusterHost"] = p.ManagementClusterHost
+		result["dnsClusterHost"] = p.DNSClusterHost
+	}
+	if p.InstallRequest != nil {
+		result["organizationId"] = p.InstallRequest.OrganizationId
+		result["hostname"] = p.InstallRequest.Hostname
+		result["targetPlatform"] = p.InstallRequest.TargetPlatform.String()
+		result["installBaseSystem"] = strconv.FormatBool(p.InstallRequest.InstallBaseSystem)
+		if p.InstallRequest.StaticIpAddresses != nil {
+			result["useStaticIp"] = strconv.FormatBool(p.InstallRequest.StaticIpAddresses.UseStaticIp)
+		}
+	}
+	return result
+}
+
 // Validate checks the parameters to determine if the workflow can be executed.
 func (p *Parameters) Validate() derrors.Error {
 	if p.Credentials.Username == "" && p.Credentials.PrivateKeyPath == "" && p.Credentials.KubeConfigPath == "" {
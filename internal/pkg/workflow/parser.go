@@ -20,10 +20,12 @@ package workflow
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"io/ioutil"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -33,18 +35,45 @@ import (
 )
 
 type rawWorkflow struct {
-	Description string            `json:"description"`
-	Commands    []json.RawMessage `json:"commands"`
+	Description    string            `json:"description"`
+	Commands       []json.RawMessage `json:"commands"`
+	Timeout        string            `json:"timeout"`
+	MaxParallelism int               `json:"maxParallelism"`
 }
 
+// commentsRegex matches the lines of a template starting with //
+var commentsRegex = regexp.MustCompile("(?m)[\r\n]+^[[:blank:]]*//.*$")
+
 // Parser structure with the required parameters.
 type Parser struct {
 	cmdParser commands.CmdParser
+	// fragments with the named templates that can be included by the workflows.
+	fragments map[string]string
+	// mode with the render mode of the templates.
+	mode RenderMode
 }
 
-// NewParser creates a new parser.
+// NewParser creates a new parser that renders the templates in the JSON render mode.
 func NewParser() *Parser {
-	return &Parser{*commands.NewCmdParser()}
+	return &Parser{cmdParser: *commands.NewCmdParser(), fragments: make(map[string]string, 0), mode: JSONRenderMode}
+}
+
+// SetRenderMode sets how the values of the actions of the templates are written. The JSON render mode escapes
+// them so they can be written inside JSON strings, and the raw mode writes them as they are.
+func (p *Parser) SetRenderMode(mode RenderMode) {
+	p.mode = mode
+}
+
+// SetFragments sets the named templates that can be included by the workflows with the template action or the
+// include function. A template defined by the workflow takes precedence over a fragment with the same name. The
+// fragments must be set before the parser is shared.
+//   params:
+//     fragments The content of the fragments indexed by name.
+func (p *Parser) SetFragments(fragments map[string]string) {
+	p.fragments = make(map[string]string, len(fragments))
+	for name, content := range fragments {
+		p.fragments[name] = content
+	}
 }
 
 // ReadWorkflow reads a workflow from a file, parsing the data and applying the template.
@@ -72,27 +101,66 @@ func (p *Parser) ReadWorkflow(workflowID string, filePath string, name string, p
 //     A Workflow structure.
 //     An error if the workflow cannot be generated.
 func (p *Parser) ParseWorkflow(workflowID string, content string, name string, params Parameters) (*Workflow, derrors.Error) {
-	ft := template.New("Workflow: " + name).Funcs(template.FuncMap{
-		"joinStringArray": func(elements []string) string {
-			return "\"" + strings.Join(elements, "\",\"") + "\""
-		},
-	})
-	commentsRegex := regexp.MustCompile("(?m)[\r\n]+^[[:blank:]]*//.*$")
+	jsonPayload, err := p.render(content, name, params)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseJSON(workflowID, jsonPayload, name)
+}
+
+// render applies the parameters to a workflow template with the fragments of the parser.
+func (p *Parser) render(content string, name string, params Parameters) (string, derrors.Error) {
+	ft := template.New("Workflow: " + name)
+	ft.Funcs(templateFuncs(ft))
+	// The fragments are parsed first so the templates defined by the workflow replace them.
+	fragmentNames := make([]string, 0, len(p.fragments))
+	for fragmentName := range p.fragments {
+		fragmentNames = append(fragmentNames, fragmentName)
+	}
+	sort.Strings(fragmentNames)
+	for _, fragmentName := range fragmentNames {
+		_, err := ft.New(fragmentName).Parse(commentsRegex.ReplaceAllString(p.fragments[fragmentName], ""))
+		if err != nil {
+			return "", derrors.NewInternalError(errors.CannotParseTemplate, err).WithParams(fragmentName)
+		}
+	}
 	// remove comments stating with //
 	templateToParse := commentsRegex.ReplaceAllString(content, "")
 	ft, err := ft.Parse(templateToParse)
 	if err != nil {
-		return nil, derrors.NewInternalError(errors.CannotParseTemplate, err)
+		return "", derrors.NewInternalError(errors.CannotParseTemplate, err)
+	}
+	if p.mode != RawRenderMode {
+		escapeActions(ft)
 	}
-	log.Debug().Str("template", ft.Name()).Msg("Executing template")
+	log.Debug().Str("template", ft.Name()).Str("mode", string(p.mode)).Msg("Executing template")
 	// output buffer for the JSON content
 	buf := new(bytes.Buffer)
 	err = ft.Execute(buf, params)
 	if err != nil {
-		return nil, derrors.NewInternalError(errors.CannotApplyTemplate, err)
+		return "", derrors.NewInternalError(errors.CannotApplyTemplate, err)
 	}
-	jsonPayload := buf.String()
-	return p.ParseJSON(workflowID, jsonPayload, name)
+	return buf.String(), nil
+}
+
+// invalidJSONError creates an error with the position and the redacted line of the JSON payload that cannot be
+// unmarshalled.
+func invalidJSONError(jsonPayload string, err error, redact ...*regexp.Regexp) derrors.Error {
+	var offset int64
+	switch jsonErr := err.(type) {
+	case *json.SyntaxError:
+		offset = jsonErr.Offset
+	case *json.UnmarshalTypeError:
+		offset = jsonErr.Offset
+	default:
+		return derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	line, column, content := syntaxErrorContext(jsonPayload, offset)
+	for _, regex := range redact {
+		content = regex.ReplaceAllString(content, "REDACTED")
+	}
+	return derrors.NewInvalidArgumentError(errors.InvalidWorkflowJSON, err).
+		WithParams(fmt.Sprintf("line %d, column %d", line, column), content)
 }
 
 // ParseJSON reads a workflow from a JSON string, parsing the data and applying the template.
@@ -113,10 +181,11 @@ func (p *Parser) ParseJSON(workflowID string, jsonPayload string, name string) (
 
 	var aux rawWorkflow
 	if err := json.Unmarshal([]byte(jsonPayload), &aux); err != nil {
-		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err).WithParams(jsonPayload)
+		return nil, invalidJSONError(jsonPayload, err, passwordRegex, privateKeyRegex)
 	}
 
 	result := make([]entities.Command, 0)
+	undo := make(map[int]entities.Command, 0)
 	for index, raw := range aux.Commands {
 		toShow := string(raw)
 		toShow = passwordRegex.ReplaceAllString(toShow, "\"password\":\"REDACTED\",")
@@ -127,7 +196,34 @@ func (p *Parser) ParseJSON(workflowID string, jsonPayload string, name string) (
 			return nil, err
 		}
 		result = append(result, *cmd)
+		undoCmd, err := parseUndo(&p.cmdParser, raw)
+		if err != nil {
+			return nil, err
+		}
+		if undoCmd != nil {
+			undo[index] = *undoCmd
+		}
 	}
 
-	return NewWorkflow(workflowID, name, aux.Description, result), nil
+	timeout, err := parseWorkflowTimeout(aux.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIDs, dependsOn, err := parseDependencies(aux.Commands)
+	if err != nil {
+		return nil, err
+	}
+
+	wf := NewWorkflow(workflowID, name, aux.Description, result)
+	wf.Undo = undo
+	wf.Timeout = timeout
+	wf.NodeIDs = nodeIDs
+	wf.DependsOn = dependsOn
+	wf.MaxParallelism = aux.MaxParallelism
+	err = wf.ValidateDependencies()
+	if err != nil {
+		return nil, err
+	}
+	return wf, nil
 }
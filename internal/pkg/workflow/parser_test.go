@@ -19,6 +19,7 @@ package workflow
 
 import (
 	"fmt"
+	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/nalej/installer/internal/pkg/workflow/commands/sync"
 	"github.com/nalej/installer/internal/pkg/workflow/entities"
 	"github.com/onsi/ginkgo"
@@ -58,6 +59,111 @@ const basicTemplateIteration = `
 }
 `
 
+const basicDefinitionUndo = `
+{
+  "description": "Test undo",
+  "commands": [
+    {"type":"sync", "name": "exec", "cmd": "mkdir", "args":["/tmp/undo"],
+      "undo": {"type":"sync", "name": "exec", "cmd": "rm", "args":["-r", "/tmp/undo"]}},
+    {"type":"sync", "name": "logger", "msg": "no undo"}
+  ]
+}
+`
+
+const nestedUndoDefinition = `
+{
+  "description": "Test nested undo",
+  "commands": [
+    {"type":"sync", "name": "group", "description": "group", "commands": [
+      {"type":"sync", "name": "exec", "cmd": "mkdir", "args":["/tmp/undo"],
+        "undo": {"type":"sync", "name": "exec", "cmd": "rm", "args":["-r", "/tmp/undo"]}}
+    ]}
+  ]
+}
+`
+
+const nestedTryUndoDefinition = `
+{
+  "description": "Test nested undo in a try",
+  "commands": [
+    {"type":"sync", "name": "try", "description": "try",
+      "cmd": {"type":"sync", "name": "logger", "msg": "try"},
+      "onFail": {"type":"sync", "name": "parallel", "description": "parallel", "commands": [
+        {"type":"sync", "name": "logger", "msg": "fail", "undo": {"type":"sync", "name": "logger", "msg": "undo"}}
+      ]}}
+  ]
+}
+`
+
+const basicDefinitionDependencies = `
+{
+  "description": "Test dependencies",
+  "maxParallelism": 2,
+  "commands": [
+    {"type":"sync", "name": "logger", "msg": "config", "id": "config"},
+    {"type":"sync", "name": "logger", "msg": "dns", "id": "dns", "dependsOn": ["config"]},
+    {"type":"sync", "name": "logger", "msg": "cert", "id": "cert", "dependsOn": ["config"]},
+    {"type":"sync", "name": "logger", "msg": "ingress", "dependsOn": ["dns", "cert"]},
+    {"type":"sync", "name": "logger", "msg": "components"}
+  ]
+}
+`
+
+const dependencyCycleDefinition = `
+{
+  "description": "Test dependency cycle",
+  "commands": [
+    {"type":"sync", "name": "logger", "msg": "first", "id": "first", "dependsOn": ["second"]},
+    {"type":"sync", "name": "logger", "msg": "second", "id": "second", "dependsOn": ["first"]}
+  ]
+}
+`
+
+const unknownDependencyDefinition = `
+{
+  "description": "Test unknown dependency",
+  "commands": [
+    {"type":"sync", "name": "logger", "msg": "first", "id": "first", "dependsOn": ["unknown"]}
+  ]
+}
+`
+
+const duplicatedIDDefinition = `
+{
+  "description": "Test duplicated identifier",
+  "commands": [
+    {"type":"sync", "name": "logger", "msg": "first", "id": "first"},
+    {"type":"sync", "name": "logger", "msg": "second", "id": "first"}
+  ]
+}
+`
+
+const composedDefinition = `
+{{define "exec"}}{"type":"sync", "name": "exec", "cmd": "{{.cmd}}", "args":[{{joinStringArray .args}}]}{{end}}
+{
+  "description": "Test composition",
+  "commands": [
+    {{template "logger" "first"}},
+    {{template "exec" dict "cmd" "mkdir" "args" (list "-p" "/tmp/composed")}}
+    {{range $index, $node := $.List "targets" (list "default")}}
+    ,{{include "exec" (dict "cmd" (printf "cmd%d" $index) "args" (list $node))}}
+    {{end}}
+  ]
+}
+`
+
+const escapedDefinition = `
+{
+  "description": "Test escaped values",
+  "commands": [
+    {"type":"sync", "name": "logger", "msg": "{{$.AuthSecret}}"},
+    {"type":"sync", "name": "logger", "msg": {{quote $.CACertPath}}},
+    {"type":"sync", "name": "exec", "cmd": "{{$.ManagementClusterHost | default "localhost"}}", "args":{{json $.InstallRequest.Nodes}}},
+    {"type":"sync", "name": "exec", "cmd": "echo", "args":["{{b64enc $.AuthSecret}}", "{{toYaml (list "a" "b")}}"]}
+  ]
+}
+`
+
 var _ = ginkgo.Describe("Parser", func() {
 	var parser = NewParser()
 
@@ -105,4 +211,139 @@ var _ = ginkgo.Describe("Parser", func() {
 			gomega.Expect(cmd2.(*sync.SCP).TargetHost).To(gomega.Equal("127.0.0.1"))
 		})
 	})
+
+	ginkgo.Context("parses a workflow with undo commands", func() {
+		workflow, err := parser.ParseWorkflow("test", basicDefinitionUndo, "TestParseWorkflow_Undo", EmptyParameters)
+		ginkgo.It("must attach the undo command to its command", func() {
+			gomega.Expect(err).To(gomega.BeNil())
+			gomega.Expect(workflow.Commands).To(gomega.HaveLen(2))
+			gomega.Expect(workflow.Undo).To(gomega.HaveLen(1))
+			undo, exists := workflow.Undo[0]
+			gomega.Expect(exists).To(gomega.BeTrue())
+			gomega.Expect(undo.(*sync.Exec).Cmd).To(gomega.Equal("rm"))
+			gomega.Expect(workflow.UndoCommands([]int{0, 1})).To(gomega.HaveLen(1))
+		})
+	})
+
+	ginkgo.Context("parses a workflow with nested undo commands", func() {
+		ginkgo.It("must reject the undo blocks of the nested commands", func() {
+			_, err := parser.ParseWorkflow("test", nestedUndoDefinition, "TestParseWorkflow_NestedUndo", EmptyParameters)
+			gomega.Expect(err).ToNot(gomega.BeNil())
+			gomega.Expect(err.Error()).To(gomega.Equal(errors.NestedUndo))
+			_, err = parser.ParseWorkflow("test", nestedTryUndoDefinition, "TestParseWorkflow_NestedTryUndo", EmptyParameters)
+			gomega.Expect(err).ToNot(gomega.BeNil())
+			gomega.Expect(err.Error()).To(gomega.Equal(errors.NestedUndo))
+		})
+	})
+
+	ginkgo.Context("parses a workflow with dependencies", func() {
+		workflow, err := parser.ParseWorkflow("test", basicDefinitionDependencies, "TestParseWorkflow_Dependencies", EmptyParameters)
+		ginkgo.It("must resolve the dependencies of the commands", func() {
+			gomega.Expect(err).To(gomega.BeNil())
+			gomega.Expect(workflow.MaxParallelism).To(gomega.Equal(2))
+			gomega.Expect(workflow.Dependencies(0)).To(gomega.BeEmpty())
+			gomega.Expect(workflow.Dependencies(1)).To(gomega.Equal([]int{0}))
+			gomega.Expect(workflow.Dependencies(2)).To(gomega.Equal([]int{0}))
+			gomega.Expect(workflow.Dependencies(3)).To(gomega.Equal([]int{1, 2}))
+			gomega.Expect(workflow.Dependencies(4)).To(gomega.Equal([]int{3}))
+		})
+	})
+
+	ginkgo.Context("parses a workflow composed of named templates", func() {
+		composedParser := NewParser()
+		composedParser.SetFragments(map[string]string{
+			"logger": `{"type":"sync", "name": "logger", "msg": "{{.}}"}`,
+			"exec":   `{"type":"sync", "name": "exec", "cmd": "replaced by the workflow"}`,
+		})
+		params := EmptyParameters
+		params.Lists = map[string][]string{"targets": {"node0", "node1"}}
+		workflow, err := composedParser.ParseWorkflow("test", composedDefinition, "TestParseWorkflow_Composed", params)
+		withDefaults, defaultsErr := composedParser.ParseWorkflow("test", composedDefinition, "TestParseWorkflow_Defaults", EmptyParameters)
+		_, missingErr := parser.ParseWorkflow("test", composedDefinition, "TestParseWorkflow_Missing", EmptyParameters)
+		ginkgo.It("must include the fragments and iterate the lists of the parameters", func() {
+			gomega.Expect(err).To(gomega.BeNil())
+			gomega.Expect(len(workflow.Commands)).To(gomega.Equal(4))
+			gomega.Expect(workflow.Commands[0].(*sync.Logger).Msg).To(gomega.Equal("first"))
+			gomega.Expect(workflow.Commands[1].(*sync.Exec).Cmd).To(gomega.Equal("mkdir"))
+			gomega.Expect(workflow.Commands[1].(*sync.Exec).Args).To(gomega.Equal([]string{"-p", "/tmp/composed"}))
+			gomega.Expect(workflow.Commands[3].(*sync.Exec).Cmd).To(gomega.Equal("cmd1"))
+			gomega.Expect(workflow.Commands[3].(*sync.Exec).Args).To(gomega.Equal([]string{"node1"}))
+			gomega.Expect(defaultsErr).To(gomega.BeNil())
+			gomega.Expect(len(withDefaults.Commands)).To(gomega.Equal(3))
+			gomega.Expect(withDefaults.Commands[2].(*sync.Exec).Args).To(gomega.Equal([]string{"default"}))
+		})
+		ginkgo.It("must write an empty array for an empty list", func() {
+			emptyParams := EmptyParameters
+			emptyParams.Lists = map[string][]string{"args": {}}
+			emptyList, emptyErr := parser.ParseWorkflow("test",
+				`{"commands":[{"type":"sync", "name": "exec", "cmd": "ls", "args":[{{joinStringArray ($.List "args" (list "-l"))}}]}]}`,
+				"TestParseWorkflow_EmptyList", emptyParams)
+			gomega.Expect(emptyErr).To(gomega.BeNil())
+			gomega.Expect(emptyList.Commands[0].(*sync.Exec).Args).To(gomega.BeEmpty())
+		})
+		ginkgo.It("must fail if a fragment is not available", func() {
+			gomega.Expect(missingErr).ToNot(gomega.BeNil())
+			gomega.Expect(missingErr.Error()).To(gomega.Equal(errors.CannotApplyTemplate))
+		})
+	})
+
+	ginkgo.Context("parses a workflow with values that are not valid JSON strings", func() {
+		params := *GetTestInstallParameters(2, true)
+		params.AuthSecret = "a\"secret\\\n"
+		params.CACertPath = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+		params.ManagementClusterHost = ""
+		workflow, err := parser.ParseWorkflow("test", escapedDefinition, "TestParseWorkflow_Escaped", params)
+		fragmentParser := NewParser()
+		fragmentParser.SetFragments(map[string]string{
+			"secretLogger": `{"type":"sync", "name": "logger", "msg": "{{.AuthSecret}}"}`,
+		})
+		fragment, fragmentErr := fragmentParser.ParseWorkflow("test", `{"commands":[{{template "secretLogger" $}}]}`,
+			"TestParseWorkflow_EscapedFragment", params)
+		rawParser := NewParser()
+		rawParser.SetRenderMode(RawRenderMode)
+		_, rawErr := rawParser.ParseWorkflow("test", escapedDefinition, "TestParseWorkflow_Raw", params)
+		_, requiredErr := parser.ParseWorkflow("test", `{"commands":[{"type":"sync", "name": "logger", "msg": "{{required "hostname must be set" $.ManagementClusterHost}}"}]}`,
+			"TestParseWorkflow_Required", params)
+		ginkgo.It("must escape the values in the JSON render mode", func() {
+			gomega.Expect(err).To(gomega.BeNil())
+			gomega.Expect(len(workflow.Commands)).To(gomega.Equal(4))
+			gomega.Expect(workflow.Commands[0].(*sync.Logger).Msg).To(gomega.Equal(params.AuthSecret))
+			gomega.Expect(workflow.Commands[1].(*sync.Logger).Msg).To(gomega.Equal(params.CACertPath))
+			gomega.Expect(workflow.Commands[2].(*sync.Exec).Cmd).To(gomega.Equal("localhost"))
+			gomega.Expect(workflow.Commands[2].(*sync.Exec).Args).To(gomega.Equal(params.InstallRequest.Nodes))
+			gomega.Expect(workflow.Commands[3].(*sync.Exec).Args).To(gomega.Equal([]string{"YSJzZWNyZXRcCg==", "- a\n- b"}))
+		})
+		ginkgo.It("must escape the values of the fragments in the JSON render mode", func() {
+			gomega.Expect(fragmentErr).To(gomega.BeNil())
+			gomega.Expect(fragment.Commands[0].(*sync.Logger).Msg).To(gomega.Equal(params.AuthSecret))
+		})
+		ginkgo.It("must report the invalid JSON in the raw render mode", func() {
+			gomega.Expect(rawErr).ToNot(gomega.BeNil())
+			gomega.Expect(rawErr.Error()).To(gomega.Equal(errors.InvalidWorkflowJSON))
+		})
+		ginkgo.It("must fail if a required value is empty", func() {
+			gomega.Expect(requiredErr).ToNot(gomega.BeNil())
+			gomega.Expect(requiredErr.Error()).To(gomega.Equal(errors.CannotApplyTemplate))
+		})
+		ginkgo.It("must locate the errors of the JSON", func() {
+			line, column, content := syntaxErrorContext("{\n \"a\": \"b\"c\n}", 12)
+			gomega.Expect(line).To(gomega.Equal(2))
+			gomega.Expect(column).To(gomega.Equal(11))
+			gomega.Expect(content).To(gomega.Equal(`"a": "b"c`))
+		})
+	})
+
+	ginkgo.Context("parses workflows with invalid dependencies", func() {
+		_, cycleErr := parser.ParseWorkflow("test", dependencyCycleDefinition, "TestParseWorkflow_Cycle", EmptyParameters)
+		_, unknownErr := parser.ParseWorkflow("test", unknownDependencyDefinition, "TestParseWorkflow_Unknown", EmptyParameters)
+		_, duplicatedErr := parser.ParseWorkflow("test", duplicatedIDDefinition, "TestParseWorkflow_Duplicated", EmptyParameters)
+		ginkgo.It("must reject them", func() {
+			gomega.Expect(cycleErr).ToNot(gomega.BeNil())
+			gomega.Expect(cycleErr.Error()).To(gomega.Equal(errors.DependencyCycle))
+			gomega.Expect(unknownErr).ToNot(gomega.BeNil())
+			gomega.Expect(unknownErr.Error()).To(gomega.Equal(errors.UnknownDependency))
+			gomega.Expect(duplicatedErr).ToNot(gomega.BeNil())
+			gomega.Expect(duplicatedErr.Error()).To(gomega.Equal(errors.DuplicatedNodeID))
+		})
+	})
 })
@@ -0,0 +1,256 @@
+/*
+ * Copyright 2019 Nalej
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file contains the functions available to the workflow templates
+//
+// In the JSON render mode, the output of every action of a template is escaped so that it can be written inside a
+// JSON string. The functions that already produce JSON, such as json, quote, joinStringArray or include, return a
+// jsonFragment that is written as it is.
+
+package workflow
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/nalej/derrors"
+	"github.com/nalej/installer/internal/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// RenderMode defines how the values of the actions of a workflow template are written.
+type RenderMode string
+
+// JSONRenderMode escapes the values so that a value cannot break the JSON of the workflow.
+const JSONRenderMode RenderMode = "json"
+
+// RawRenderMode writes the values as they are.
+const RawRenderMode RenderMode = "raw"
+
+// escapeFunction is the name of the function added to the actions in the JSON render mode.
+const escapeFunction = "escapeJSON"
+
+// RenderModeFromString returns the render mode with the given name.
+func RenderModeFromString(mode string) (RenderMode, derrors.Error) {
+	switch RenderMode(mode) {
+	case JSONRenderMode, RawRenderMode:
+		return RenderMode(mode), nil
+	case "":
+		return JSONRenderMode, nil
+	}
+	return "", derrors.NewInvalidArgumentError(errors.InvalidRenderMode).WithParams(mode)
+}
+
+// jsonFragment is a piece of JSON that is written as it is in the JSON render mode.
+type jsonFragment string
+
+// toJSONString returns the content of the JSON string that represents a value, without the quotes.
+func toJSONString(value string) string {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string cannot fail.
+	_ = encoder.Encode(value)
+	encoded := strings.TrimSuffix(buf.String(), "\n")
+	return encoded[1 : len(encoded)-1]
+}
+
+// escapeJSON escapes the output of an action so it can be written inside a JSON string.
+func escapeJSON(value interface{}) jsonFragment {
+	switch v := value.(type) {
+	case jsonFragment:
+		return v
+	case string:
+		return jsonFragment(toJSONString(v))
+	case nil:
+		return ""
+	}
+	return jsonFragment(toJSONString(fmt.Sprint(value)))
+}
+
+// isEmpty checks if a value is the zero value of its type, or an empty collection.
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(value, reflect.Zero(v.Type()).Interface())
+}
+
+// templateFuncs creates the functions available to the templates.
+//
+//	params:
+//	  root The template in which the include function looks for the named templates.
+//	returns:
+//	  The function map.
+func templateFuncs(root *template.Template) template.FuncMap {
+	return template.FuncMap{
+		escapeFunction: escapeJSON,
+		// joinStringArray writes the elements of a list as JSON strings separated by commas. An empty list writes
+		// nothing so the enclosing array stays empty.
+		"joinStringArray": func(elements []string) jsonFragment {
+			if len(elements) == 0 {
+				return ""
+			}
+			escaped := make([]string, 0, len(elements))
+			for _, element := range elements {
+				escaped = append(escaped, toJSONString(element))
+			}
+			return jsonFragment("\"" + strings.Join(escaped, "\",\"") + "\"")
+		},
+		// json writes a value as JSON, e.g. "nodes":{{json $.InstallRequest.Nodes}}.
+		"json": func(value interface{}) (jsonFragment, error) {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			return jsonFragment(encoded), nil
+		},
+		// quote writes a value as a JSON string including the quotes, e.g. "hostname":{{quote $.InstallRequest.Hostname}}.
+		"quote": func(value interface{}) jsonFragment {
+			if s, ok := value.(string); ok {
+				return jsonFragment("\"" + toJSONString(s) + "\"")
+			}
+			return jsonFragment("\"" + toJSONString(fmt.Sprint(value)) + "\"")
+		},
+		// default returns the value, or the default one if the value is empty, e.g. {{$.IstioPath | default "/istio"}}.
+		"default": func(defaultValue interface{}, value interface{}) interface{} {
+			if isEmpty(value) {
+				return defaultValue
+			}
+			return value
+		},
+		// required fails with the given message if the value is empty, e.g. {{required "hostname must be set" $.Hostname}}.
+		"required": func(message string, value interface{}) (interface{}, error) {
+			if isEmpty(value) {
+				return nil, fmt.Errorf("%s", message)
+			}
+			return value, nil
+		},
+		// toYaml writes a value as YAML.
+		"toYaml": func(value interface{}) (string, error) {
+			encoded, err := yaml.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(string(encoded), "\n"), nil
+		},
+		// b64enc encodes a value in base64.
+		"b64enc": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		// list creates a list of strings, e.g. {{range list "a" "b"}}.
+		"list": func(elements ...string) []string {
+			return elements
+		},
+		// dict creates a map from key and value pairs to pass several parameters to a named template, e.g.
+		// {{template "name" dict "root" $ "namespace" "nalej"}}.
+		"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+			if len(pairs)%2 != 0 {
+				return nil, fmt.Errorf("dict expects key and value pairs, found %d elements", len(pairs))
+			}
+			result := make(map[string]interface{}, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				key, ok := pairs[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("dict expects string keys, found %v", pairs[i])
+				}
+				result[key] = pairs[i+1]
+			}
+			return result, nil
+		},
+		// include renders a named template with the given data so that its output can be used in a pipeline.
+		"include": func(name string, data interface{}) (jsonFragment, error) {
+			buf := new(bytes.Buffer)
+			if err := root.ExecuteTemplate(buf, name, data); err != nil {
+				return "", err
+			}
+			return jsonFragment(buf.String()), nil
+		},
+	}
+}
+
+// escapeActions adds the escape function to the actions that write a value in the templates of a set.
+func escapeActions(root *template.Template) {
+	for _, t := range root.Templates() {
+		if t.Tree != nil {
+			escapeNode(t.Tree, t.Tree.Root)
+		}
+	}
+}
+
+// escapeNode adds the escape function to the actions of a node, without changing the conditions of the if, range
+// and with actions.
+func escapeNode(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeNode(tree, child)
+		}
+	case *parse.ActionNode:
+		// Variable declarations and assignments do not write anything.
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+		if len(last.Args) > 0 {
+			if identifier, ok := last.Args[0].(*parse.IdentifierNode); ok && identifier.Ident == escapeFunction {
+				return
+			}
+		}
+		escape := parse.NewIdentifier(escapeFunction).SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{escape}})
+	case *parse.IfNode:
+		escapeNode(tree, n.List)
+		escapeNode(tree, n.ElseList)
+	case *parse.RangeNode:
+		escapeNode(tree, n.List)
+		escapeNode(tree, n.ElseList)
+	case *parse.WithNode:
+		escapeNode(tree, n.List)
+		escapeNode(tree, n.ElseList)
+	}
+}
+
+// syntaxErrorContext returns the line and the column of an offset of a JSON document, and the content of that line.
+func syntaxErrorContext(payload string, offset int64) (int, int, string) {
+	if offset > int64(len(payload)) {
+		offset = int64(len(payload))
+	}
+	before := payload[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+	lineEnd := strings.Index(payload[lineStart:], "\n")
+	if lineEnd < 0 {
+		lineEnd = len(payload) - lineStart
+	}
+	return line, int(offset) - lineStart + 1, strings.TrimSpace(payload[lineStart : lineStart+lineEnd])
+}
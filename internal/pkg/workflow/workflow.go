@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"github.com/nalej/installer/internal/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"time"
 
 	"github.com/nalej/derrors"
 	"github.com/nalej/installer/internal/pkg/workflow/commands"
@@ -50,6 +51,15 @@ const ErrorState WorkflowState = "error"
 // FinishedState represents a workflow that has finished.
 const FinishedState WorkflowState = "finished"
 
+// CanceledState represents a workflow whose execution has been stopped by the user.
+const CanceledState WorkflowState = "canceled"
+
+// RollingBackState represents a failed workflow that is executing the undo commands of the finished commands.
+const RollingBackState WorkflowState = "rolling-back"
+
+// RolledBackState represents a failed workflow whose finished commands have been undone.
+const RolledBackState WorkflowState = "rolled-back"
+
 // Workflow defines a basic structure for a pipeline workflow definition.
 type Workflow struct {
 	// WorkflowID contains the workflow identifier.
@@ -60,6 +70,17 @@ type Workflow struct {
 	Description string `json:"description"`
 	// Commands that are going to be executed.
 	Commands []entities.Command `json:"commands"`
+	// Undo contains the compensation commands indexed by the position of the command they revert.
+	Undo map[int]entities.Command `json:"undo,omitempty"`
+	// Timeout with the maximum duration of the workflow execution. No deadline is applied if zero.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// NodeIDs contains the identifiers defined by the commands indexed by position.
+	NodeIDs map[int]string `json:"nodeIds,omitempty"`
+	// DependsOn contains the indexes of the commands that must finish before a command is launched, indexed by
+	// the position of the command. Commands without an entry depend on the previous command.
+	DependsOn map[int][]int `json:"dependsOn,omitempty"`
+	// MaxParallelism with the maximum number of commands executed at the same time. No limit is applied if zero.
+	MaxParallelism int `json:"maxParallelism,omitempty"`
 }
 
 // NewWorkflow creates a new workflow.
@@ -75,18 +96,50 @@ func NewWorkflow(workflowID string, name string, description string, commands []
 		Name:        name,
 		Description: description,
 		Commands:    commands,
+		Undo:        make(map[int]entities.Command, 0),
+		NodeIDs:     make(map[int]string, 0),
+		DependsOn:   make(map[int][]int, 0),
 	}
 
 }
 
+// UndoCommands returns the compensation commands of the given finished commands in reverse order of execution.
+func (w *Workflow) UndoCommands(finished []int) []entities.Command {
+	result := make([]entities.Command, 0)
+	for i := len(finished) - 1; i >= 0; i-- {
+		if undo, exists := w.Undo[finished[i]]; exists {
+			result = append(result, undo)
+		}
+	}
+	return result
+}
+
 // PrettyPrint creates a string with the debug information of this workflow.
 func (w *Workflow) PrettyPrint() string {
 	var buffer bytes.Buffer
 	buffer.WriteString("WorkflowID: " + w.WorkflowID)
 	buffer.WriteString("\nName: " + w.Name)
 	buffer.WriteString("\nDescription: " + w.Description + "\n")
+	if w.Timeout > 0 {
+		buffer.WriteString("Timeout: " + w.Timeout.String() + "\n")
+	}
+	if w.MaxParallelism > 0 {
+		buffer.WriteString(fmt.Sprintf("MaxParallelism: %d\n", w.MaxParallelism))
+	}
 	for index, cmd := range w.Commands {
 		buffer.WriteString(fmt.Sprintf("%d) - %s\n", index, cmd.PrettyPrint(0)))
+		if id, exists := w.NodeIDs[index]; exists {
+			buffer.WriteString(fmt.Sprintf("   id: %s\n", id))
+		}
+		if deps, exists := w.DependsOn[index]; exists {
+			buffer.WriteString(fmt.Sprintf("   dependsOn: %v\n", deps))
+		}
+		if policy := cmd.RetryPolicy(); policy != nil {
+			buffer.WriteString(fmt.Sprintf("   %s\n", policy.String()))
+		}
+		if undo, exists := w.Undo[index]; exists {
+			buffer.WriteString(fmt.Sprintf("   undo:\n%s\n", undo.PrettyPrint(5)))
+		}
 	}
 	return buffer.String()
 }
@@ -168,6 +221,10 @@ type WorkflowFromJSON struct {
 	Description string `json:"description"`
 	// Commands that are going to be executed.
 	Commands []json.RawMessage `json:"commands"`
+	// Timeout with the maximum duration of the workflow execution.
+	Timeout string `json:"timeout"`
+	// MaxParallelism with the maximum number of commands executed at the same time.
+	MaxParallelism int `json:"maxParallelism"`
 }
 
 // ToWorkflow transforms the current structure into a workflow by parsing individual parameters.
@@ -175,6 +232,7 @@ func (wfj *WorkflowFromJSON) ToWorkflow() (*Workflow, derrors.Error) {
 
 	p := commands.NewCmdParser()
 	result := make([]entities.Command, 0)
+	undo := make(map[int]entities.Command, 0)
 	for index, raw := range wfj.Commands {
 		log.Debug().Int("index", index).Str("raw", string(raw)).Msg("processing raw command")
 		var gc entities.GenericCommand
@@ -187,11 +245,109 @@ func (wfj *WorkflowFromJSON) ToWorkflow() (*Workflow, derrors.Error) {
 			return nil, err
 		}
 		result = append(result, *cmd)
+		undoCmd, err := parseUndo(p, raw)
+		if err != nil {
+			return nil, err
+		}
+		if undoCmd != nil {
+			undo[index] = *undoCmd
+		}
 	}
 
-	return &Workflow{
+	timeout, err := parseWorkflowTimeout(wfj.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	nodeIDs, dependsOn, err := parseDependencies(wfj.Commands)
+	if err != nil {
+		return nil, err
+	}
+
+	wf := &Workflow{
 		wfj.WorkflowID,
 		wfj.Name,
 		wfj.Description,
-		result}, nil
+		result,
+		undo,
+		timeout,
+		nodeIDs,
+		dependsOn,
+		wfj.MaxParallelism}
+	err = wf.ValidateDependencies()
+	if err != nil {
+		return nil, err
+	}
+	return wf, nil
+}
+
+// parseWorkflowTimeout transforms the timeout of a workflow definition into a duration.
+func parseWorkflowTimeout(value string) (time.Duration, derrors.Error) {
+	if value == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, derrors.NewInvalidArgumentError(errors.InvalidTimeout).WithParams(value)
+	}
+	return timeout, nil
+}
+
+// undoFromJSON is used to extract the optional undo block of a command, and the commands nested in a group,
+// parallel or try command.
+type undoFromJSON struct {
+	Undo     json.RawMessage   `json:"undo"`
+	Commands []json.RawMessage `json:"commands"`
+	Cmd      json.RawMessage   `json:"cmd"`
+	OnFail   json.RawMessage   `json:"onFail"`
+}
+
+// nested returns the raw commands nested in a group, parallel or try command.
+func (ufj undoFromJSON) nested() []json.RawMessage {
+	result := make([]json.RawMessage, 0, len(ufj.Commands)+2)
+	result = append(result, ufj.Commands...)
+	for _, raw := range []json.RawMessage{ufj.Cmd, ufj.OnFail} {
+		// The exec command uses cmd with the name of the executable.
+		if len(raw) > 0 && raw[0] == '{' {
+			result = append(result, raw)
+		}
+	}
+	return result
+}
+
+// rejectNestedUndo checks that the commands nested in a command do not define undo blocks, as the rollback only
+// reverts the top level commands of a workflow.
+func rejectNestedUndo(raw []byte) derrors.Error {
+	var ufj undoFromJSON
+	if err := json.Unmarshal(raw, &ufj); err != nil {
+		return derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	for _, child := range ufj.nested() {
+		var nested undoFromJSON
+		if err := json.Unmarshal(child, &nested); err != nil {
+			return derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+		}
+		if len(nested.Undo) > 0 && string(nested.Undo) != "null" {
+			return derrors.NewInvalidArgumentError(errors.NestedUndo).WithParams(string(child))
+		}
+		if err := rejectNestedUndo(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseUndo extracts the command defined in the undo block of a raw command. It returns nil if the command does
+// not define how to revert it.
+func parseUndo(p *commands.CmdParser, raw []byte) (*entities.Command, derrors.Error) {
+	var ufj undoFromJSON
+	if err := json.Unmarshal(raw, &ufj); err != nil {
+		return nil, derrors.NewInvalidArgumentError(errors.UnmarshalError, err)
+	}
+	if err := rejectNestedUndo(raw); err != nil {
+		return nil, err
+	}
+	if len(ufj.Undo) == 0 || string(ufj.Undo) == "null" {
+		return nil, nil
+	}
+	return p.ParseCommand(ufj.Undo)
 }